@@ -0,0 +1,160 @@
+package assets
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/storyblok"
+)
+
+// SubDir is the directory below the schema directory that holds an exported asset library.
+const SubDir = "assets"
+
+// The library is stored as ManifestFile, listing folders and asset metadata, with the
+// files themselves below FilesDir.
+const (
+	ManifestFile = "assets.json"
+	FilesDir     = "files"
+)
+
+// Manifest describes an exported asset library.
+type Manifest struct {
+	SpaceID int                     `json:"space_id"`
+	Folders []storyblok.AssetFolder `json:"folders,omitempty"`
+	Assets  []LocalAsset            `json:"assets"`
+}
+
+// LocalAsset is an exported asset: its metadata in the source space and its file,
+// relative to the asset directory.
+type LocalAsset struct {
+	storyblok.Asset
+	File string `json:"file"`
+}
+
+// Mapping pairs assets of a source space with their copies in a target space. It is
+// written by push-assets and read by story import and preset image uploads, so assets
+// already copied are referenced instead of uploaded again.
+type Mapping struct {
+	SourceSpaceID int           `json:"source_space_id"`
+	TargetSpaceID int           `json:"target_space_id"`
+	Assets        []MappedAsset `json:"assets"`
+}
+
+// MappedAsset is one source asset and its copy.
+type MappedAsset struct {
+	SourceID  int    `json:"source_id"`
+	SourceURL string `json:"source_url"`
+	TargetID  int    `json:"target_id"`
+	TargetURL string `json:"target_url"`
+}
+
+// Dir returns the asset directory below the schema directory base.
+func Dir(base string) string {
+	return filepath.Join(base, SubDir)
+}
+
+// MappingFileName names the mapping file for copies into targetSpaceID.
+func MappingFileName(targetSpaceID int) string {
+	return fmt.Sprintf("mapping-%d.json", targetSpaceID)
+}
+
+// LoadManifest reads the exported asset library below base.
+func LoadManifest(base string) (Manifest, error) {
+	path := filepath.Join(Dir(base), ManifestFile)
+	var manifest Manifest
+	if err := fsutil.ReadJSON(path, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// LoadMapping reads the mapping for targetSpaceID below base. A missing file yields an
+// empty mapping.
+func LoadMapping(base string, targetSpaceID int) (Mapping, error) {
+	path := filepath.Join(Dir(base), MappingFileName(targetSpaceID))
+	mapping := Mapping{TargetSpaceID: targetSpaceID}
+	exists, err := fsutil.Exists(path)
+	if err != nil || !exists {
+		return mapping, err
+	}
+	if err := fsutil.ReadJSON(path, &mapping); err != nil {
+		return Mapping{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	return mapping, nil
+}
+
+// saveMapping merges entries into the mapping file for the target space, replacing
+// earlier entries for the same source asset.
+func saveMapping(base string, mapping Mapping, entries []MappedAsset) (string, error) {
+	bySource := make(map[string]MappedAsset, len(mapping.Assets)+len(entries))
+	for _, entry := range append(mapping.Assets, entries...) {
+		bySource[urlKey(entry.SourceURL)] = entry
+	}
+	mapping.Assets = mapping.Assets[:0]
+	for _, entry := range bySource {
+		mapping.Assets = append(mapping.Assets, entry)
+	}
+	sort.Slice(mapping.Assets, func(i, j int) bool {
+		a, b := mapping.Assets[i], mapping.Assets[j]
+		if a.SourceID != b.SourceID {
+			return a.SourceID < b.SourceID
+		}
+		return a.SourceURL < b.SourceURL
+	})
+	path := filepath.Join(Dir(base), MappingFileName(mapping.TargetSpaceID))
+	return path, fsutil.WriteJSON(path, mapping, 0)
+}
+
+// localFile names the exported file of an asset, prefixed with its ID so equal file
+// names from different folders do not collide.
+func localFile(asset storyblok.Asset) string {
+	name := storyblok.AssetFilename(asset.Filename)
+	if name == "" {
+		name = "asset"
+	}
+	return filepath.ToSlash(filepath.Join(FilesDir, strconv.Itoa(asset.ID)+"-"+name))
+}
+
+// folderPaths returns the slash-separated path of every folder, keyed by folder ID.
+func folderPaths(folders []storyblok.AssetFolder) map[int]string {
+	byID := make(map[int]storyblok.AssetFolder, len(folders))
+	for _, folder := range folders {
+		byID[folder.ID] = folder
+	}
+	paths := make(map[int]string, len(folders))
+	var resolve func(id int, seen int) string
+	resolve = func(id int, seen int) string {
+		if path, ok := paths[id]; ok {
+			return path
+		}
+		folder, ok := byID[id]
+		if !ok || seen > len(folders) {
+			return ""
+		}
+		path := folder.Name
+		if parent := resolve(folder.ParentID, seen+1); parent != "" {
+			path = parent + "/" + folder.Name
+		}
+		paths[id] = path
+		return path
+	}
+	for _, folder := range folders {
+		resolve(folder.ID, 0)
+	}
+	return paths
+}
+
+// urlKey normalises an asset URL so protocol-relative, https and S3 bucket forms of the
+// same file compare equal.
+func urlKey(rawURL string) string {
+	key := strings.TrimSpace(rawURL)
+	key = strings.TrimPrefix(key, "https:")
+	key = strings.TrimPrefix(key, "http:")
+	key = strings.TrimPrefix(key, "//")
+	key = strings.TrimPrefix(key, "s3.amazonaws.com/")
+	return key
+}
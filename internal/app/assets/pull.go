@@ -0,0 +1,169 @@
+package assets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// PullOptions configures an asset library export.
+type PullOptions struct {
+	Token   string
+	SpaceID int
+	OutDir  string
+	DryRun  bool
+}
+
+// PullResult summarises an asset library export.
+type PullResult struct {
+	ExitCode         int
+	Assets           int
+	Folders          int
+	Downloaded       int
+	Skipped          int
+	Duration         time.Duration
+	RateLimitRetries int64
+}
+
+// Pull downloads every asset of the space into OutDir/assets/files and writes their
+// metadata and the asset folders to OutDir/assets/assets.json. Files already on disk with
+// the expected size are not downloaded again.
+func Pull(ctx context.Context, opts PullOptions) (PullResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := PullResult{ExitCode: 0}
+	start := time.Now()
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	var list []storyblok.Asset
+	var folders []storyblok.AssetFolder
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		assets, err := client.ListAssets(egCtx, opts.SpaceID)
+		if err != nil {
+			return err
+		}
+		list = assets
+		return nil
+	})
+	eg.Go(func() error {
+		f, err := client.ListAssetFolders(egCtx, opts.SpaceID)
+		if err != nil {
+			return err
+		}
+		folders = f
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		report.Errorf(ctx, "failed to list assets from Storyblok: %v", err)
+		result.ExitCode = 2
+		return result, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	sort.Slice(folders, func(i, j int) bool { return folders[i].ID < folders[j].ID })
+
+	dir := Dir(opts.OutDir)
+	paths := folderPaths(folders)
+	manifest := Manifest{SpaceID: opts.SpaceID, Folders: folders, Assets: make([]LocalAsset, 0, len(list))}
+	var pending []LocalAsset
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run: pulling assets from space %d", opts.SpaceID)
+	}
+	for _, asset := range list {
+		local := LocalAsset{Asset: asset, File: localFile(asset)}
+		manifest.Assets = append(manifest.Assets, local)
+
+		path := filepath.Join(dir, filepath.FromSlash(local.File))
+		if current(path, asset) {
+			result.Skipped++
+			continue
+		}
+		pending = append(pending, local)
+		if opts.DryRun {
+			folder := ""
+			if p := paths[asset.AssetFolderID]; p != "" {
+				folder = " [" + p + "]"
+			}
+			report.Printf(ctx, "  - asset %s%s -> %s", storyblok.AssetFilename(asset.Filename), folder, path)
+		}
+	}
+
+	result.Assets = len(manifest.Assets)
+	result.Folders = len(folders)
+	if !opts.DryRun {
+		eg, egCtx = errgroup.WithContext(ctx)
+		eg.SetLimit(4)
+		for _, local := range pending {
+			eg.Go(func() error {
+				data, err := client.Download(egCtx, local.Filename)
+				if err != nil {
+					return err
+				}
+				path := filepath.Join(dir, filepath.FromSlash(local.File))
+				if err := fsutil.EnsureDir(filepath.Dir(path)); err != nil {
+					return err
+				}
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					return err
+				}
+				report.Printf(ctx, "Saved asset %s to %s", storyblok.AssetFilename(local.Filename), path)
+				return nil
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			result.ExitCode = 2
+			return result, err
+		}
+		result.Downloaded = len(pending)
+
+		if err := fsutil.WriteJSON(filepath.Join(dir, ManifestFile), manifest, 0); err != nil {
+			result.ExitCode = 2
+			return result, err
+		}
+	}
+
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+
+	report.Printf(ctx, "")
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run summary: %d assets in %d folders, %d to download, %d up to date (rate-limit retries: %d)",
+			result.Assets, result.Folders, len(pending), result.Skipped, result.RateLimitRetries)
+	} else {
+		report.Printf(ctx, "Pulled %d assets in %d folders from space %d in %s (rate-limit retries: %d)",
+			result.Assets,
+			result.Folders,
+			opts.SpaceID,
+			result.Duration.Truncate(time.Millisecond),
+			result.RateLimitRetries,
+		)
+		report.Printf(ctx, "  Downloaded: %d, up to date: %d", result.Downloaded, result.Skipped)
+	}
+	return result, nil
+}
+
+// current reports whether path already holds the asset's file. Without a known size any
+// existing file counts as current.
+func current(path string, asset storyblok.Asset) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return asset.ContentLength == 0 || info.Size() == asset.ContentLength
+}
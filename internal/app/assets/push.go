@@ -0,0 +1,244 @@
+package assets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/infra/limiter"
+	"sbx/internal/report"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// PushOptions configures an asset library import.
+type PushOptions struct {
+	Token   string
+	SpaceID int
+	Dir     string
+	DryRun  bool
+}
+
+// PushResult summarises an asset library import.
+type PushResult struct {
+	ExitCode           int
+	FoldersCreated     int
+	AssetsUploaded     int
+	AssetsReused       int
+	AssetsMapped       int
+	MappingPath        string
+	Duration           time.Duration
+	RateLimitRetries   int64
+	ServerErrorRetries int64
+}
+
+// Push uploads an exported asset library into the target space. Folders are matched by
+// path or created; files are uploaded through signed uploads with their metadata, unless
+// the mapping or the lockfile shows they were copied before. The old to new asset mapping
+// is written to Dir/assets/mapping-<space>.json.
+func Push(ctx context.Context, opts PushOptions) (PushResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := PushResult{ExitCode: 0}
+	start := time.Now()
+
+	manifest, err := LoadManifest(opts.Dir)
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+	if len(manifest.Assets) == 0 {
+		result.ExitCode = 1
+		return result, fmt.Errorf("no exported assets in %s", Dir(opts.Dir))
+	}
+	mapping, err := LoadMapping(opts.Dir, opts.SpaceID)
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+	if mapping.SourceSpaceID == 0 {
+		mapping.SourceSpaceID = manifest.SpaceID
+	}
+	lock, err := state.Load(opts.Dir)
+	if err != nil {
+		return result, err
+	}
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run: pushing assets to space %d", opts.SpaceID)
+	}
+	folderIDs, err := syncFolders(ctx, client, opts, manifest.Folders, &result)
+	if err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+
+	mapped := make(map[string]struct{}, len(mapping.Assets))
+	for _, entry := range mapping.Assets {
+		mapped[urlKey(entry.SourceURL)] = struct{}{}
+	}
+	var pending []LocalAsset
+	for _, local := range manifest.Assets {
+		if _, ok := mapped[urlKey(local.Filename)]; ok {
+			result.AssetsMapped++
+			continue
+		}
+		pending = append(pending, local)
+	}
+
+	transfer := NewTransfer(client, opts.SpaceID, lock)
+	dir := Dir(opts.Dir)
+	var entries []MappedAsset
+	var mu sync.Mutex
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(4)
+	for _, local := range pending {
+		eg.Go(func() error {
+			path := filepath.Join(dir, filepath.FromSlash(local.File))
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			name := storyblok.AssetFilename(local.Filename)
+			if opts.DryRun {
+				verb := "upload"
+				if _, ok := transfer.Lookup(data); ok {
+					verb = "reuse"
+				}
+				report.Printf(ctx, "  - %s asset %s", verb, name)
+				return nil
+			}
+			meta := local.Asset
+			meta.ID = 0
+			meta.Filename = name
+			meta.AssetFolderID = folderIDs[local.AssetFolderID]
+			asset, err := transfer.Upload(egCtx, meta, data)
+			if err != nil {
+				return fmt.Errorf("upload %s: %w", path, err)
+			}
+			mu.Lock()
+			entries = append(entries, MappedAsset{
+				SourceID:  local.ID,
+				SourceURL: local.Filename,
+				TargetID:  asset.ID,
+				TargetURL: asset.URL,
+			})
+			mu.Unlock()
+			return nil
+		})
+	}
+	err = eg.Wait()
+
+	result.AssetsUploaded, result.AssetsReused = transfer.Counts()
+	if len(entries) > 0 {
+		// Save what was copied even when a later upload failed, so a rerun resumes.
+		path, saveErr := saveMapping(opts.Dir, mapping, entries)
+		if saveErr != nil {
+			report.Errorf(ctx, "failed to write asset mapping %s: %v", path, saveErr)
+		}
+		result.MappingPath = path
+	}
+	if result.AssetsUploaded > 0 {
+		if saveErr := lock.Save(); saveErr != nil {
+			report.Errorf(ctx, "failed to record uploaded assets in %s: %v", lock.Path(), saveErr)
+		}
+	}
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+	result.ServerErrorRetries = counters.Status5xx.Load()
+	if err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+
+	printPushSummary(ctx, result, opts, len(pending))
+	return result, nil
+}
+
+// syncFolders matches the exported folders to target folders by path, creating missing
+// ones parents first, and returns target folder IDs keyed by source folder ID.
+func syncFolders(ctx context.Context, client *storyblok.Client, opts PushOptions, folders []storyblok.AssetFolder, result *PushResult) (map[int]int, error) {
+	ids := make(map[int]int, len(folders))
+	if len(folders) == 0 {
+		return ids, nil
+	}
+
+	existing, err := client.ListAssetFolders(ctx, opts.SpaceID)
+	if err != nil {
+		return nil, fmt.Errorf("load target asset folders: %w", err)
+	}
+	byPath := make(map[string]int, len(existing))
+	for id, path := range folderPaths(existing) {
+		byPath[strings.ToLower(path)] = id
+	}
+
+	sourcePaths := folderPaths(folders)
+	ordered := append([]storyblok.AssetFolder(nil), folders...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := sourcePaths[ordered[i].ID], sourcePaths[ordered[j].ID]
+		if da, db := strings.Count(a, "/"), strings.Count(b, "/"); da != db {
+			return da < db
+		}
+		return a < b
+	})
+
+	for _, folder := range ordered {
+		path := sourcePaths[folder.ID]
+		if id, ok := byPath[strings.ToLower(path)]; ok {
+			ids[folder.ID] = id
+			continue
+		}
+		result.FoldersCreated++
+		if opts.DryRun {
+			report.Printf(ctx, "  - create folder %s", path)
+			continue
+		}
+		created, err := client.CreateAssetFolder(ctx, opts.SpaceID, storyblok.AssetFolder{
+			Name:     folder.Name,
+			ParentID: ids[folder.ParentID],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create asset folder %s: %w", path, err)
+		}
+		ids[folder.ID] = created.ID
+		byPath[strings.ToLower(path)] = created.ID
+		report.Printf(ctx, "Created asset folder %s (id=%d)", path, created.ID)
+	}
+	return ids, nil
+}
+
+func printPushSummary(ctx context.Context, result PushResult, opts PushOptions, pending int) {
+	report.Printf(ctx, "")
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run summary: %d folders to create, %d assets to copy, %d already mapped (rate-limit retries: %d)",
+			result.FoldersCreated, pending, result.AssetsMapped, result.RateLimitRetries)
+		return
+	}
+	report.Printf(ctx, "Pushed %d assets to space %d in %s (rate-limit retries: %d, server retries: %d)",
+		result.AssetsUploaded+result.AssetsReused,
+		opts.SpaceID,
+		result.Duration.Truncate(time.Millisecond),
+		result.RateLimitRetries,
+		result.ServerErrorRetries,
+	)
+	report.Printf(ctx, "  Folders created: %d; assets: %d uploaded, %d reused, %d already mapped",
+		result.FoldersCreated, result.AssetsUploaded, result.AssetsReused, result.AssetsMapped)
+	if result.MappingPath != "" {
+		report.Printf(ctx, "  Asset mapping written to %s", result.MappingPath)
+	}
+}
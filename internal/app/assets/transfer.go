@@ -0,0 +1,150 @@
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/report"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// Transfer copies files into a space's asset library. Uploads are keyed by content hash,
+// so identical files are uploaded once per space: within a run, and across runs through
+// the assets recorded in the lockfile. It is safe for concurrent use.
+type Transfer struct {
+	client  *storyblok.Client
+	spaceID int
+	// Local maps source URLs to files that are read from FS instead of downloading.
+	Local map[string]string
+	FS    fsutil.FS
+	// mapped holds assets push-assets already copied into the space, by source URL key.
+	mapped map[string]state.AssetState
+
+	mu       sync.Mutex
+	lock     *state.Lockfile
+	group    singleflight.Group
+	uploaded int
+	reused   int
+}
+
+// NewTransfer returns a Transfer into spaceID that records uploads in lock.
+func NewTransfer(client *storyblok.Client, spaceID int, lock *state.Lockfile) *Transfer {
+	return &Transfer{client: client, spaceID: spaceID, lock: lock, Local: make(map[string]string)}
+}
+
+// Needed reports whether rawURL must be copied, that is, it is set and not already an
+// asset of the target space.
+func (t *Transfer) Needed(rawURL string) bool {
+	return rawURL != "" && !storyblok.IsSpaceAsset(rawURL, t.spaceID)
+}
+
+// UseMapping makes Copy resolve URLs listed in the asset mapping file for the target space
+// below base, as written by push-assets, without uploading them again. A missing mapping
+// file is not an error.
+func (t *Transfer) UseMapping(base string) error {
+	mapping, err := LoadMapping(base, t.spaceID)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.mapped == nil {
+		t.mapped = make(map[string]state.AssetState, len(mapping.Assets))
+	}
+	for _, entry := range mapping.Assets {
+		t.mapped[urlKey(entry.SourceURL)] = state.AssetState{ID: entry.TargetID, URL: entry.TargetURL}
+	}
+	return nil
+}
+
+// Copy makes the file at rawURL available in the target space and returns the asset.
+func (t *Transfer) Copy(ctx context.Context, rawURL string) (state.AssetState, error) {
+	t.mu.Lock()
+	asset, ok := t.mapped[urlKey(rawURL)]
+	if ok {
+		t.reused++
+	}
+	t.mu.Unlock()
+	if ok {
+		return asset, nil
+	}
+
+	data, err := t.load(ctx, rawURL)
+	if err != nil {
+		return state.AssetState{}, err
+	}
+	return t.Upload(ctx, storyblok.Asset{Filename: storyblok.AssetFilename(rawURL)}, data)
+}
+
+// Lookup returns the asset an earlier upload of data created, if any.
+func (t *Transfer) Lookup(data []byte) (state.AssetState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lock.Asset(t.spaceID, contentHash(data))
+}
+
+// Upload stores data in the target space unless a file with the same content was uploaded
+// before, in which case that asset is returned. meta names the file and may set its folder
+// and metadata; they are not applied to a reused asset.
+func (t *Transfer) Upload(ctx context.Context, meta storyblok.Asset, data []byte) (state.AssetState, error) {
+	hash := contentHash(data)
+
+	t.mu.Lock()
+	asset, ok := t.lock.Asset(t.spaceID, hash)
+	if ok {
+		t.reused++
+	}
+	t.mu.Unlock()
+	if ok {
+		return asset, nil
+	}
+
+	value, err, _ := t.group.Do(hash, func() (any, error) {
+		name := meta.Filename
+		if name == "" {
+			name = hash[:12]
+		}
+		meta.Filename = name
+		uploaded, err := t.client.UploadAsset(ctx, t.spaceID, meta, data)
+		if err != nil {
+			return state.AssetState{}, err
+		}
+		asset := state.AssetState{ID: uploaded.ID, URL: uploaded.Filename}
+		t.mu.Lock()
+		t.lock.RecordAsset(t.spaceID, hash, asset)
+		t.uploaded++
+		t.mu.Unlock()
+		report.Printf(ctx, "Uploaded asset %s to space %d", name, t.spaceID)
+		return asset, nil
+	})
+	if err != nil {
+		return state.AssetState{}, err
+	}
+	return value.(state.AssetState), nil
+}
+
+// Counts returns how many files were uploaded and how many reused an earlier upload.
+func (t *Transfer) Counts() (uploaded, reused int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.uploaded, t.reused
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// load prefers a local copy of the file and downloads it from its URL otherwise.
+func (t *Transfer) load(ctx context.Context, rawURL string) ([]byte, error) {
+	if path, ok := t.Local[rawURL]; ok {
+		return fsutil.Or(t.FS).ReadFile(path)
+	}
+	return t.client.Download(ctx, rawURL)
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/auditlog"
+	"sbx/internal/report"
+)
+
+// Output formats supported by the audit report.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures a query of the audit log.
+type Options struct {
+	engine.Env
+	// Path is the audit log file.
+	Path    string
+	SpaceID int
+	// Entity is a case-insensitive glob matched against entity names.
+	Entity string
+	Since  time.Time
+	Until  time.Time
+	Format string
+}
+
+// Result holds the entries that matched.
+type Result struct {
+	ExitCode int
+	Entries  []auditlog.Entry
+}
+
+// Run prints the entries of the audit log that match opts, oldest first.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	ctx = opts.Context(ctx)
+
+	result := Result{ExitCode: 0}
+
+	if opts.Format == "" {
+		opts.Format = FormatText
+	}
+	if opts.Format != FormatText && opts.Format != FormatJSON {
+		return result, fmt.Errorf("invalid format %q (expected text or json)", opts.Format)
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Until.After(opts.Since) {
+		return result, fmt.Errorf("--until must be later than --since")
+	}
+
+	entries, err := auditlog.Read(opts.Path, auditlog.Filter{
+		SpaceID: opts.SpaceID,
+		Entity:  opts.Entity,
+		Since:   opts.Since,
+		Until:   opts.Until,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Entries = entries
+
+	if opts.Format == FormatJSON {
+		if entries == nil {
+			entries = []auditlog.Entry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return result, err
+		}
+		report.Printf(ctx, "%s", data)
+		return result, nil
+	}
+
+	for _, entry := range entries {
+		printEntry(ctx, entry)
+	}
+	report.Printf(ctx, "")
+	report.Printf(ctx, "%d writes in %s", len(entries), opts.Path)
+	return result, nil
+}
+
+func printEntry(ctx context.Context, entry auditlog.Entry) {
+	entity := entry.Entity
+	if entity == "" {
+		entity = "-"
+	}
+	line := fmt.Sprintf("%s  %-6s %s  space %d  %s  %d",
+		entry.Time.Format(time.RFC3339), entry.Method, entity, entry.SpaceID, entry.Path, entry.Status)
+	if entry.BeforeHash != "" || entry.AfterHash != "" {
+		line += fmt.Sprintf("  %s -> %s", shortHash(entry.BeforeHash), shortHash(entry.AfterHash))
+	}
+	var by []string
+	if entry.Actor != "" {
+		by = append(by, entry.Actor)
+	}
+	if entry.GitSHA != "" {
+		by = append(by, "at "+shortHash(entry.GitSHA))
+	}
+	if len(by) > 0 {
+		line += "  by " + strings.Join(by, " ")
+	}
+	if entry.Error != "" {
+		report.Errorf(ctx, "%s  error: %s", line, entry.Error)
+		return
+	}
+	report.Printf(ctx, "%s", line)
+}
+
+func shortHash(hash string) string {
+	if hash == "" {
+		return "-"
+	}
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// ParseTime reads a --since or --until value: an RFC 3339 timestamp, a date such as
+// 2026-01-31 (midnight UTC), or a duration such as 24h or 7d counted back from now.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err == nil && n >= 0 && fmt.Sprint(n) == days {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected RFC 3339, YYYY-MM-DD or a duration such as 24h or 7d)", value)
+}
@@ -0,0 +1,245 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sbx/internal/fsutil"
+)
+
+// ManifestFile sits at the top of a backup, next to the component and preset files. Like
+// the lockfile it has no .json suffix, so schema discovery never mistakes it for a
+// component file.
+const ManifestFile = "sbx-backup.manifest"
+
+// Space settings that no pull command covers are stored below space/, out of reach of
+// schema discovery.
+const (
+	SpaceFile  = "space/options.json"
+	GroupsFile = "space/component-groups.json"
+	TagsFile   = "space/internal-tags.json"
+)
+
+// formatVersion is bumped whenever the backup layout changes incompatibly.
+const formatVersion = 1
+
+// Manifest describes a backup: where it came from, what it holds and the sha256 of every
+// file, keyed by slash-separated path relative to the backup root.
+type Manifest struct {
+	Version   int               `json:"version"`
+	SpaceID   int               `json:"space_id"`
+	SpaceName string            `json:"space_name,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Contents  map[string]int    `json:"contents"`
+	Files     map[string]string `json:"files"`
+}
+
+// IsArchive reports whether path names a tar.gz backup rather than a directory.
+func IsArchive(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// checksums hashes every file below dir except the manifest.
+func checksums(dir string) (map[string]string, error) {
+	sums := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFile {
+			return nil
+		}
+		sum, err := fileHash(path)
+		if err != nil {
+			return err
+		}
+		sums[rel] = sum
+		return nil
+	})
+	return sums, err
+}
+
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readManifest loads and checks the manifest of the backup in dir against its files.
+func readManifest(dir string) (Manifest, error) {
+	path := filepath.Join(dir, ManifestFile)
+	var manifest Manifest
+	if err := fsutil.ReadJSON(path, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("read %s: %w", ManifestFile, err)
+	}
+	if manifest.Version == 0 || manifest.Version > formatVersion {
+		return Manifest{}, fmt.Errorf("%s has unsupported version %d", ManifestFile, manifest.Version)
+	}
+
+	actual, err := checksums(dir)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var problems []string
+	for name, want := range manifest.Files {
+		got, ok := actual[name]
+		switch {
+		case !ok:
+			problems = append(problems, name+" is missing")
+		case got != want:
+			problems = append(problems, name+" does not match its checksum")
+		}
+	}
+	for name := range actual {
+		if _, ok := manifest.Files[name]; !ok {
+			problems = append(problems, name+" is not listed in the manifest")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return Manifest{}, fmt.Errorf("backup failed verification:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return manifest, nil
+}
+
+// pack writes the contents of dir to a gzip-compressed tar archive at path.
+func pack(dir, path string) error {
+	if err := fsutil.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	for _, closer := range []io.Closer{tw, gz, out} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// unpack extracts the archive at path into dir, refusing entries that would land outside.
+func unpack(path, dir string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("%s: entry %s escapes the archive", path, header.Name)
+		}
+		target := filepath.Join(dir, name)
+		if err := fsutil.EnsureDir(filepath.Dir(target)); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// copyDir copies the regular files below src into dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := fsutil.EnsureDir(filepath.Dir(target)); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+}
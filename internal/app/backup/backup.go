@@ -0,0 +1,213 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/app/assets"
+	"sbx/internal/app/datasources"
+	"sbx/internal/app/pull"
+	"sbx/internal/app/stories"
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// Options configures a space backup.
+type Options struct {
+	Token   string
+	SpaceID int
+	// Output is a directory, or a .tar.gz/.tgz archive path. Empty writes a timestamped
+	// archive to the working directory.
+	Output      string
+	WithStories bool
+	WithAssets  bool
+}
+
+// Result summarises a space backup.
+type Result struct {
+	ExitCode         int
+	Path             string
+	Contents         map[string]int
+	Files            int
+	Duration         time.Duration
+	RateLimitRetries int64
+}
+
+// Run writes a backup of the space: components with presets and their screenshots,
+// component groups, internal tags, datasources and space options, plus stories and the
+// asset library when requested. Every part is written by the matching pull command into
+// one directory, so the backup doubles as a schema directory. A manifest records the
+// format version and a checksum per file.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+	result := Result{ExitCode: 0, Path: opts.Output}
+	if result.Path == "" {
+		result.Path = fmt.Sprintf("sbx-backup-%d-%s.tar.gz", opts.SpaceID, start.UTC().Format("20060102-150405"))
+	}
+
+	dir := result.Path
+	if IsArchive(result.Path) {
+		tmp, err := os.MkdirTemp("", "sbx-backup-")
+		if err != nil {
+			return result, err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	} else if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		result.ExitCode = 1
+		return result, fmt.Errorf("backup directory %s is not empty", dir)
+	}
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	var space storyblok.SpaceOptions
+	var groups []storyblok.ComponentGroup
+	var tags []storyblok.InternalTag
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		options, err := client.GetSpaceOptions(egCtx, opts.SpaceID)
+		if err != nil {
+			return err
+		}
+		space = options
+		return nil
+	})
+	eg.Go(func() error {
+		list, err := client.ListComponentGroups(egCtx, opts.SpaceID)
+		if err != nil {
+			return err
+		}
+		groups = list
+		return nil
+	})
+	eg.Go(func() error {
+		list, err := client.ListInternalTags(egCtx, opts.SpaceID)
+		if err != nil {
+			return err
+		}
+		tags = list
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load space settings from Storyblok: %v\n", err)
+		result.ExitCode = 2
+		return result, err
+	}
+	for name, value := range map[string]any{SpaceFile: space, GroupsFile: groups, TagsFile: tags} {
+		if err := fsutil.WriteJSON(filepath.Join(dir, filepath.FromSlash(name)), value, 0); err != nil {
+			result.ExitCode = 2
+			return result, err
+		}
+	}
+
+	contents := map[string]int{"component_groups": len(groups), "internal_tags": len(tags)}
+	if err := pullAll(ctx, opts, dir, contents); err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+	// The lockfile tracks sync state of a working directory; it is not part of the space.
+	if err := os.Remove(filepath.Join(dir, state.LockFileName)); err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	files, err := checksums(dir)
+	if err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+	manifest := Manifest{
+		Version:   formatVersion,
+		SpaceID:   opts.SpaceID,
+		SpaceName: space.Name,
+		CreatedAt: start.UTC(),
+		Contents:  contents,
+		Files:     files,
+	}
+	if err := fsutil.WriteJSON(filepath.Join(dir, ManifestFile), manifest, 0); err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+	if dir != result.Path {
+		if err := pack(dir, result.Path); err != nil {
+			result.ExitCode = 2
+			return result, fmt.Errorf("write %s: %w", result.Path, err)
+		}
+	}
+
+	result.Contents = contents
+	result.Files = len(files) + 1
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+
+	fmt.Println()
+	fmt.Printf("Backed up space %d to %s in %s (%d files)\n",
+		opts.SpaceID, result.Path, result.Duration.Truncate(time.Millisecond), result.Files)
+	fmt.Printf("  Components: %d, presets: %d, groups: %d, internal tags: %d, datasources: %d\n",
+		contents["components"], contents["presets"], contents["component_groups"], contents["internal_tags"], contents["datasources"])
+	if opts.WithStories || opts.WithAssets {
+		fmt.Printf("  Stories: %d, folders: %d, assets: %d\n",
+			contents["stories"], contents["story_folders"], contents["assets"])
+	}
+	return result, nil
+}
+
+// pullAll runs each pull workflow into dir and records what it wrote in contents.
+func pullAll(ctx context.Context, opts Options, dir string, contents map[string]int) error {
+	components, err := pull.Run(ctx, pull.Options{
+		Token:      opts.Token,
+		SpaceID:    opts.SpaceID,
+		All:        true,
+		MatchMode:  "exact",
+		OutDir:     dir,
+		WithImages: true,
+	})
+	if err != nil {
+		return fmt.Errorf("back up components: %w", err)
+	}
+	contents["components"] = components.ComponentsSynced
+	contents["presets"] = components.PresetsSynced
+
+	ds, err := datasources.Pull(ctx, datasources.PullOptions{
+		Token:     opts.Token,
+		SpaceID:   opts.SpaceID,
+		All:       true,
+		MatchMode: "exact",
+		OutDir:    dir,
+	})
+	if err != nil {
+		return fmt.Errorf("back up datasources: %w", err)
+	}
+	contents["datasources"] = ds.Datasources
+
+	if opts.WithStories {
+		st, err := stories.Pull(ctx, stories.PullOptions{Token: opts.Token, SpaceID: opts.SpaceID, OutDir: dir})
+		if err != nil {
+			return fmt.Errorf("back up stories: %w", err)
+		}
+		contents["stories"] = st.Stories
+		contents["story_folders"] = st.Folders
+	}
+	if opts.WithAssets {
+		as, err := assets.Pull(ctx, assets.PullOptions{Token: opts.Token, SpaceID: opts.SpaceID, OutDir: dir})
+		if err != nil {
+			return fmt.Errorf("back up assets: %w", err)
+		}
+		contents["assets"] = as.Assets
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sbx/internal/app/assets"
+	"sbx/internal/app/datasources"
+	"sbx/internal/app/push"
+	"sbx/internal/app/stories"
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/storyblok"
+)
+
+// RestoreOptions configures a space restore.
+type RestoreOptions struct {
+	Token   string
+	SpaceID int
+	// Source is a backup directory or archive written by Run.
+	Source string
+	DryRun bool
+	// Protected refuses breaking changes to the components of the space, and overwriting
+	// components changed outside sbx, unless AllowDestructive is set.
+	Protected        bool
+	AllowDestructive bool
+}
+
+// RestoreResult summarises a space restore.
+type RestoreResult struct {
+	ExitCode           int
+	LanguagesAdded     []string
+	GroupsCreated      int
+	TagsCreated        int
+	Steps              []string
+	Duration           time.Duration
+	RateLimitRetries   int64
+	ServerErrorRetries int64
+}
+
+// Restore rebuilds a backup into the target space, which is expected to be empty. The
+// backup is verified against its manifest and staged in a temporary directory, so the
+// state written by the push workflows never alters it. Parts are restored in dependency
+// order: languages, component groups and internal tags, assets, datasources, components
+// with presets, and finally stories, which reference all of them.
+func Restore(ctx context.Context, opts RestoreOptions) (RestoreResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := RestoreResult{ExitCode: 0}
+	start := time.Now()
+
+	dir, err := os.MkdirTemp("", "sbx-restore-")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(dir)
+	if IsArchive(opts.Source) {
+		err = unpack(opts.Source, dir)
+	} else {
+		err = copyDir(opts.Source, dir)
+	}
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+	manifest, err := readManifest(dir)
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	fmt.Printf("Restoring backup of space %d from %s (taken %s) into space %d\n",
+		manifest.SpaceID, opts.Source, manifest.CreatedAt.Format(time.RFC3339), opts.SpaceID)
+	if opts.DryRun {
+		fmt.Println("Dry run: no changes will be written")
+	}
+	warnIfNotEmpty(ctx, client, opts.SpaceID)
+
+	err = restoreSettings(ctx, client, opts, dir, &result)
+	if err == nil {
+		err = restoreContent(ctx, opts, dir, manifest.Contents, &result)
+	}
+
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+	result.ServerErrorRetries = counters.Status5xx.Load()
+	if err != nil {
+		if result.ExitCode == 0 {
+			result.ExitCode = 2
+		}
+		return result, err
+	}
+
+	fmt.Println()
+	verb := "Restored"
+	if opts.DryRun {
+		verb = "Dry run: would restore"
+	}
+	fmt.Printf("%s space %d into space %d in %s (rate-limit retries: %d, server retries: %d)\n",
+		verb, manifest.SpaceID, opts.SpaceID, result.Duration.Truncate(time.Millisecond),
+		result.RateLimitRetries, result.ServerErrorRetries)
+	fmt.Printf("  Steps: %s\n", strings.Join(result.Steps, ", "))
+	return result, nil
+}
+
+// warnIfNotEmpty points out that existing components and stories with the same names are
+// overwritten.
+func warnIfNotEmpty(ctx context.Context, client *storyblok.Client, spaceID int) {
+	components, err := client.ListComponents(ctx, spaceID)
+	if err != nil {
+		return
+	}
+	list, err := client.ListStories(ctx, spaceID, storyblok.StoryFilter{})
+	if err != nil {
+		return
+	}
+	if len(components) > 0 || len(list) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: space %d is not empty (%d components, %d stories); items with the same name or slug are overwritten\n",
+			spaceID, len(components), len(list))
+	}
+}
+
+// restoreSettings adds missing languages, component groups and internal tags.
+func restoreSettings(ctx context.Context, client *storyblok.Client, opts RestoreOptions, dir string, result *RestoreResult) error {
+	var space storyblok.SpaceOptions
+	var groups []storyblok.ComponentGroup
+	var tags []storyblok.InternalTag
+	for name, value := range map[string]any{SpaceFile: &space, GroupsFile: &groups, TagsFile: &tags} {
+		if err := fsutil.ReadJSON(filepath.Join(dir, filepath.FromSlash(name)), value); err != nil {
+			result.ExitCode = 1
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+
+	if err := restoreLanguages(ctx, client, opts, space.Languages, result); err != nil {
+		return fmt.Errorf("restore languages: %w", err)
+	}
+	if err := restoreGroups(ctx, client, opts, groups, result); err != nil {
+		return fmt.Errorf("restore component groups: %w", err)
+	}
+	if err := restoreTags(ctx, client, opts, tags, result); err != nil {
+		return fmt.Errorf("restore internal tags: %w", err)
+	}
+	result.Steps = append(result.Steps, "settings")
+	return nil
+}
+
+func restoreLanguages(ctx context.Context, client *storyblok.Client, opts RestoreOptions, languages []storyblok.LangOption, result *RestoreResult) error {
+	target, err := client.GetSpaceOptions(ctx, opts.SpaceID)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]struct{}, len(target.Languages))
+	for _, lang := range target.Languages {
+		have[strings.ToLower(lang.Code)] = struct{}{}
+	}
+	merged := append([]storyblok.LangOption(nil), target.Languages...)
+	for _, lang := range languages {
+		if lang.Code == "" || lang.IsDefault {
+			continue
+		}
+		if _, ok := have[strings.ToLower(lang.Code)]; ok {
+			continue
+		}
+		merged = append(merged, lang)
+		result.LanguagesAdded = append(result.LanguagesAdded, lang.Code)
+		if opts.DryRun {
+			fmt.Printf("  - add language %s\n", lang.Code)
+		}
+	}
+	if len(result.LanguagesAdded) == 0 || opts.DryRun {
+		return nil
+	}
+	if err := client.UpdateSpaceLanguages(ctx, opts.SpaceID, merged); err != nil {
+		return err
+	}
+	fmt.Printf("Added languages %s\n", strings.Join(result.LanguagesAdded, ", "))
+	return nil
+}
+
+// restoreGroups creates the component groups missing in the target, parents first.
+func restoreGroups(ctx context.Context, client *storyblok.Client, opts RestoreOptions, groups []storyblok.ComponentGroup, result *RestoreResult) error {
+	existing, err := client.ListComponentGroups(ctx, opts.SpaceID)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]int, len(existing))
+	for _, group := range existing {
+		byName[strings.ToLower(group.Name)] = group.ID
+	}
+
+	byID := make(map[int]storyblok.ComponentGroup, len(groups))
+	for _, group := range groups {
+		byID[group.ID] = group
+	}
+	depth := func(group storyblok.ComponentGroup) int {
+		d := 0
+		for group.ParentID != nil && d <= len(groups) {
+			parent, ok := byID[*group.ParentID]
+			if !ok {
+				break
+			}
+			group = parent
+			d++
+		}
+		return d
+	}
+	ordered := append([]storyblok.ComponentGroup(nil), groups...)
+	sort.SliceStable(ordered, func(i, j int) bool { return depth(ordered[i]) < depth(ordered[j]) })
+
+	ids := make(map[int]int, len(groups))
+	for _, group := range ordered {
+		if id, ok := byName[strings.ToLower(group.Name)]; ok {
+			ids[group.ID] = id
+			continue
+		}
+		result.GroupsCreated++
+		if opts.DryRun {
+			fmt.Printf("  - create component group %s\n", group.Name)
+			continue
+		}
+		create := storyblok.ComponentGroup{Name: group.Name}
+		if group.ParentID != nil {
+			if parent, ok := ids[*group.ParentID]; ok && parent != 0 {
+				create.ParentID = &parent
+			}
+		}
+		created, err := client.CreateComponentGroup(ctx, opts.SpaceID, create)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", group.Name, err)
+		}
+		ids[group.ID] = created.ID
+		byName[strings.ToLower(group.Name)] = created.ID
+		fmt.Printf("Created component group %s (id=%d)\n", group.Name, created.ID)
+	}
+	return nil
+}
+
+// restoreTags creates the internal tags missing in the target. Tags used by components are
+// also created by the component push; this keeps unused ones and tags of other objects.
+func restoreTags(ctx context.Context, client *storyblok.Client, opts RestoreOptions, tags []storyblok.InternalTag, result *RestoreResult) error {
+	existing, err := client.ListInternalTags(ctx, opts.SpaceID)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]struct{}, len(existing))
+	for _, tag := range existing {
+		have[tag.ObjectType+"/"+strings.ToLower(tag.Name)] = struct{}{}
+	}
+	for _, tag := range tags {
+		key := tag.ObjectType + "/" + strings.ToLower(tag.Name)
+		if _, ok := have[key]; ok || tag.Name == "" {
+			continue
+		}
+		have[key] = struct{}{}
+		result.TagsCreated++
+		if opts.DryRun {
+			fmt.Printf("  - create internal tag %s\n", tag.Name)
+			continue
+		}
+		created, err := client.CreateInternalTag(ctx, opts.SpaceID, storyblok.InternalTag{Name: tag.Name, ObjectType: tag.ObjectType})
+		if err != nil {
+			return fmt.Errorf("create %s: %w", tag.Name, err)
+		}
+		fmt.Printf("Created internal tag %s (id=%d)\n", tag.Name, created.ID)
+	}
+	return nil
+}
+
+// restoreContent runs the push workflows against the staged backup. Assets go first so
+// that the mapping they write lets presets and stories reference the copies.
+func restoreContent(ctx context.Context, opts RestoreOptions, dir string, contents map[string]int, result *RestoreResult) error {
+	if contents["assets"] > 0 {
+		fmt.Println()
+		r, err := assets.Push(ctx, assets.PushOptions{Token: opts.Token, SpaceID: opts.SpaceID, Dir: dir, DryRun: opts.DryRun})
+		if err != nil {
+			result.ExitCode = r.ExitCode
+			return fmt.Errorf("restore assets: %w", err)
+		}
+		result.Steps = append(result.Steps, "assets")
+	}
+	if contents["datasources"] > 0 {
+		fmt.Println()
+		r, err := datasources.Push(ctx, datasources.PushOptions{
+			Token:     opts.Token,
+			SpaceID:   opts.SpaceID,
+			All:       true,
+			MatchMode: "exact",
+			Dir:       dir,
+			DryRun:    opts.DryRun,
+		})
+		if err != nil {
+			result.ExitCode = r.ExitCode
+			return fmt.Errorf("restore datasources: %w", err)
+		}
+		result.Steps = append(result.Steps, "datasources")
+	}
+	if contents["components"] > 0 {
+		fmt.Println()
+		// A protected space keeps components edited outside sbx, and its breaking changes
+		// are refused, unless destructive restores are allowed.
+		r, err := push.Run(ctx, push.Options{
+			Token:            opts.Token,
+			SpaceID:          opts.SpaceID,
+			All:              true,
+			MatchMode:        "exact",
+			Dir:              dir,
+			DryRun:           opts.DryRun,
+			Force:            !opts.Protected || opts.AllowDestructive,
+			UploadImages:     true,
+			Protected:        opts.Protected,
+			AllowDestructive: opts.AllowDestructive,
+		})
+		if err != nil {
+			result.ExitCode = r.ExitCode
+			return fmt.Errorf("restore components: %w", err)
+		}
+		if r.ExitCode != 0 {
+			result.ExitCode = r.ExitCode
+			return fmt.Errorf("restore components: push exited with code %d", r.ExitCode)
+		}
+		result.Steps = append(result.Steps, "components")
+	}
+	if contents["stories"] > 0 {
+		fmt.Println()
+		r, err := stories.Push(ctx, stories.PushOptions{
+			Token:   opts.Token,
+			SpaceID: opts.SpaceID,
+			Dir:     dir,
+			Publish: true,
+			DryRun:  opts.DryRun,
+		})
+		if err != nil {
+			result.ExitCode = r.ExitCode
+			return fmt.Errorf("restore stories: %w", err)
+		}
+		result.Steps = append(result.Steps, "stories")
+	}
+	return nil
+}
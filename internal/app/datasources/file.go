@@ -0,0 +1,166 @@
+package datasources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// SubDir is the directory below the schema directory that holds datasource files. Keeping
+// them out of the base directory stops component discovery from treating them as schemas.
+const SubDir = "datasources"
+
+// File is the local representation of a datasource with all of its entries.
+type File struct {
+	Name       string      `json:"name"`
+	Slug       string      `json:"slug"`
+	Dimensions []Dimension `json:"dimensions,omitempty"`
+	Entries    []Entry     `json:"entries"`
+}
+
+// Dimension is an alternative value set, identified by its entry_value.
+type Dimension struct {
+	Name       string `json:"name"`
+	EntryValue string `json:"entry_value"`
+}
+
+// Entry is a datasource entry keyed by name. DimensionValues maps dimension entry_values to
+// the entry's value in that dimension.
+type Entry struct {
+	Name            string            `json:"name"`
+	Value           string            `json:"value"`
+	DimensionValues map[string]string `json:"dimension_values,omitempty"`
+}
+
+// LocalFile couples a datasource file with its path.
+type LocalFile struct {
+	Path       string
+	Datasource File
+}
+
+// Dir returns the datasource directory below the schema directory base.
+func Dir(base string) string {
+	return filepath.Join(base, SubDir)
+}
+
+// FileName returns the file name pull uses for a datasource from spaceID.
+func FileName(slug string, spaceID int) string {
+	return fmt.Sprintf("%s-%d.json", slug, spaceID)
+}
+
+// LoadFiles reads the datasource files below base in fsys. Files that cannot be parsed or lack a
+// slug are skipped with a warning, as push-components does for schemas.
+func LoadFiles(ctx context.Context, fsys fsutil.FS, base string) ([]LocalFile, error) {
+	dir := Dir(base)
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []LocalFile
+	var skipped []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var file File
+		if err := json.Unmarshal(data, &file); err != nil || strings.TrimSpace(file.Slug) == "" {
+			skipped = append(skipped, path)
+			continue
+		}
+		if file.Name == "" {
+			file.Name = file.Slug
+		}
+		files = append(files, LocalFile{Path: path, Datasource: file})
+	}
+	if len(skipped) > 0 {
+		report.Errorf(ctx, "Skipped %d datasource files that are not valid JSON or lack a slug: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// remote is a datasource as it exists in a space, with entries indexed by name.
+type remote struct {
+	datasource storyblok.Datasource
+	entries    map[string]*remoteEntry
+	order      []string
+}
+
+type remoteEntry struct {
+	entry      storyblok.DatasourceEntry
+	dimensions map[string]string
+}
+
+// fetch loads the entries of datasource, once for the default values and once per dimension.
+func fetch(ctx context.Context, client *storyblok.Client, spaceID int, datasource storyblok.Datasource) (*remote, error) {
+	list, err := client.ListDatasourceEntries(ctx, spaceID, datasource.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	r := &remote{datasource: datasource, entries: make(map[string]*remoteEntry, len(list))}
+	for _, entry := range list {
+		if _, dup := r.entries[entry.Name]; dup {
+			continue
+		}
+		r.entries[entry.Name] = &remoteEntry{entry: entry, dimensions: make(map[string]string)}
+		r.order = append(r.order, entry.Name)
+	}
+
+	for _, dim := range datasource.Dimensions {
+		list, err := client.ListDatasourceEntries(ctx, spaceID, datasource.ID, dim.EntryValue)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range list {
+			if existing, ok := r.entries[entry.Name]; ok && existing.entry.ID == entry.ID {
+				existing.dimensions[dim.EntryValue] = entry.DimensionValue
+			}
+		}
+	}
+	return r, nil
+}
+
+// file converts a remote datasource into its local representation.
+func (r *remote) file() File {
+	file := File{
+		Name:    r.datasource.Name,
+		Slug:    r.datasource.Slug,
+		Entries: make([]Entry, 0, len(r.order)),
+	}
+	for _, dim := range r.datasource.Dimensions {
+		file.Dimensions = append(file.Dimensions, Dimension{Name: dim.Name, EntryValue: dim.EntryValue})
+	}
+	for _, name := range r.order {
+		re := r.entries[name]
+		entry := Entry{Name: re.entry.Name, Value: re.entry.Value}
+		for key, value := range re.dimensions {
+			if value == "" {
+				continue
+			}
+			if entry.DimensionValues == nil {
+				entry.DimensionValues = make(map[string]string)
+			}
+			entry.DimensionValues[key] = value
+		}
+		file.Entries = append(file.Entries, entry)
+	}
+	return file
+}
@@ -0,0 +1,145 @@
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/fsutil"
+	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// PullOptions configures a datasource pull.
+type PullOptions struct {
+	engine.Env
+	Token     string
+	SpaceID   int
+	Names     []string
+	MatchMode string
+	All       bool
+	OutDir    string
+	DryRun    bool
+}
+
+// PullResult summarises a datasource pull.
+type PullResult struct {
+	ExitCode         int
+	Datasources      int
+	Entries          int
+	Duration         time.Duration
+	RateLimitRetries int64
+	MissingSelectors []string
+}
+
+// Pull downloads the selected datasources, matched by slug, with all entries and dimension
+// values into OutDir/datasources.
+func Pull(ctx context.Context, opts PullOptions) (PullResult, error) {
+	ctx = opts.Context(ctx)
+
+	result := PullResult{ExitCode: 0}
+
+	if err := matcher.ValidateMode(opts.MatchMode); err != nil {
+		return result, err
+	}
+	if !opts.All && len(opts.Names) == 0 {
+		return result, fmt.Errorf("no datasource slugs provided; use --all to pull every datasource")
+	}
+
+	start := opts.Clock()()
+
+	client := opts.ClientFor(opts.Token)
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	list, err := client.ListDatasources(ctx, opts.SpaceID)
+	if err != nil {
+		report.Errorf(ctx, "failed to load datasources from Storyblok: %v", err)
+		result.ExitCode = 2
+		return result, err
+	}
+
+	selected, missing, err := matcher.Filter(list, func(ds storyblok.Datasource) string {
+		return ds.Slug
+	}, opts.Names, opts.MatchMode, opts.All)
+	if err != nil {
+		return result, err
+	}
+	result.MissingSelectors = missing
+	if len(missing) > 0 {
+		result.ExitCode = 1
+	}
+
+	files := make([]File, len(selected))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(4)
+	for i, datasource := range selected {
+		eg.Go(func() error {
+			r, err := fetch(egCtx, client, opts.SpaceID, datasource)
+			if err != nil {
+				return fmt.Errorf("load entries of datasource %s: %w", datasource.Slug, err)
+			}
+			files[i] = r.file()
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+
+	dir := Dir(opts.OutDir)
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run: pulling datasources from space %d", opts.SpaceID)
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, FileName(file.Slug, opts.SpaceID))
+		if opts.DryRun {
+			verb := "create"
+			if exists, _ := fsutil.ExistsFS(opts.Files(), path); exists {
+				verb = "overwrite"
+			}
+			report.Printf(ctx, "  - datasource %s (%d entries) -> %s (%s)", file.Slug, len(file.Entries), path, verb)
+		} else {
+			if err := fsutil.WriteJSONFS(opts.Files(), path, file, 0); err != nil {
+				result.ExitCode = 2
+				return result, err
+			}
+			report.Printf(ctx, "Saved datasource %s (%d entries) to %s", file.Slug, len(file.Entries), path)
+		}
+		result.Entries += len(file.Entries)
+	}
+
+	result.Datasources = len(files)
+	result.Duration = opts.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+
+	printPullSummary(ctx, result, opts)
+	return result, nil
+}
+
+func printPullSummary(ctx context.Context, result PullResult, opts PullOptions) {
+	report.Printf(ctx, "")
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run summary: %d datasources, %d entries (rate-limit retries: %d)",
+			result.Datasources, result.Entries, result.RateLimitRetries)
+	} else {
+		report.Printf(ctx, "Pulled %d datasources and %d entries from space %d in %s (rate-limit retries: %d)",
+			result.Datasources,
+			result.Entries,
+			opts.SpaceID,
+			result.Duration.Truncate(time.Millisecond),
+			result.RateLimitRetries,
+		)
+	}
+	if len(result.MissingSelectors) > 0 {
+		report.Errorf(ctx, "Missing datasources matching: %s", strings.Join(result.MissingSelectors, ", "))
+	}
+}
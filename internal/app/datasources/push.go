@@ -0,0 +1,398 @@
+package datasources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// PushOptions configures a datasource push.
+type PushOptions struct {
+	engine.Env
+	Token     string
+	SpaceID   int
+	Names     []string
+	MatchMode string
+	All       bool
+	Dir       string
+	DryRun    bool
+}
+
+// PushResult summarises a datasource push.
+type PushResult struct {
+	Summary
+	ExitCode           int
+	Duration           time.Duration
+	RateLimitRetries   int64
+	ServerErrorRetries int64
+	MissingSelectors   []string
+}
+
+// Summary counts what Apply changed, or would change in a dry run. Entries that exist only
+// in the target are never deleted; they are counted as kept.
+type Summary struct {
+	Created          []string
+	Updated          []string
+	Unchanged        []string
+	EntriesCreated   int
+	EntriesUpdated   int
+	EntriesUnchanged int
+	EntriesKept      int
+}
+
+// Push creates or updates the selected local datasources, matched by slug, in the target
+// space. Entries are matched by name.
+func Push(ctx context.Context, opts PushOptions) (PushResult, error) {
+	ctx = opts.Context(ctx)
+
+	result := PushResult{ExitCode: 0}
+
+	if err := matcher.ValidateMode(opts.MatchMode); err != nil {
+		return result, err
+	}
+	if !opts.All && len(opts.Names) == 0 {
+		return result, fmt.Errorf("no datasource slugs provided; use --all to push every datasource")
+	}
+
+	start := opts.Clock()()
+
+	local, err := LoadFiles(ctx, opts.Files(), opts.Dir)
+	if err != nil {
+		return result, err
+	}
+	if len(local) == 0 {
+		return result, fmt.Errorf("no datasource files found in %s", Dir(opts.Dir))
+	}
+
+	selected, missing, err := matcher.Filter(local, func(file LocalFile) string {
+		return file.Datasource.Slug
+	}, opts.Names, opts.MatchMode, opts.All)
+	if err != nil {
+		return result, err
+	}
+	result.MissingSelectors = missing
+	if len(missing) > 0 {
+		result.ExitCode = 1
+	}
+
+	client := opts.ClientFor(opts.Token)
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	files := make([]File, 0, len(selected))
+	for _, file := range selected {
+		files = append(files, file.Datasource)
+	}
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run: pushing datasources to space %d", opts.SpaceID)
+	}
+	summary, err := Apply(ctx, client, opts.SpaceID, files, opts.DryRun)
+	result.Summary = summary
+	result.Duration = opts.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+	result.ServerErrorRetries = counters.Status5xx.Load()
+	if err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+
+	printPushSummary(ctx, result, opts)
+	return result, nil
+}
+
+// Apply creates or updates each datasource in spaceID so that it holds the given entries
+// and dimensions. In a dry run it only reads the target and prints the planned changes.
+func Apply(ctx context.Context, client *storyblok.Client, spaceID int, files []File, dryRun bool) (Summary, error) {
+	var summary Summary
+
+	list, err := client.ListDatasources(ctx, spaceID)
+	if err != nil {
+		return summary, fmt.Errorf("load target datasources: %w", err)
+	}
+	bySlug := make(map[string]storyblok.Datasource, len(list))
+	for _, ds := range list {
+		bySlug[strings.ToLower(ds.Slug)] = ds
+	}
+
+	for _, file := range files {
+		existing, exists := bySlug[strings.ToLower(file.Slug)]
+		var err error
+		if exists {
+			err = updateDatasource(ctx, client, spaceID, existing, file, dryRun, &summary)
+		} else {
+			err = createDatasource(ctx, client, spaceID, file, dryRun, &summary)
+		}
+		if err != nil {
+			return summary, fmt.Errorf("datasource %s: %w", file.Slug, err)
+		}
+	}
+	sort.Strings(summary.Created)
+	sort.Strings(summary.Updated)
+	sort.Strings(summary.Unchanged)
+	return summary, nil
+}
+
+func createDatasource(ctx context.Context, client *storyblok.Client, spaceID int, file File, dryRun bool, summary *Summary) error {
+	summary.Created = append(summary.Created, file.Slug)
+	summary.EntriesCreated += len(file.Entries)
+	if dryRun {
+		report.Printf(ctx, "  - create datasource %s (%d entries, %d dimensions)", file.Slug, len(file.Entries), len(file.Dimensions))
+		return nil
+	}
+
+	datasource := storyblok.Datasource{Name: file.Name, Slug: file.Slug}
+	for _, dim := range file.Dimensions {
+		datasource.Dimensions = append(datasource.Dimensions, storyblok.DatasourceDimension{Name: dim.Name, EntryValue: dim.EntryValue})
+	}
+	created, err := client.CreateDatasource(ctx, spaceID, datasource)
+	if err != nil {
+		return err
+	}
+	dimensions, err := dimensionIDs(ctx, client, spaceID, created, file.Dimensions)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range file.Entries {
+		saved, err := client.CreateDatasourceEntry(ctx, spaceID, storyblok.DatasourceEntry{
+			Name:         entry.Name,
+			Value:        entry.Value,
+			DatasourceID: created.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("create entry %s: %w", entry.Name, err)
+		}
+		for key, value := range entry.DimensionValues {
+			if value == "" {
+				continue
+			}
+			saved.DimensionValue = value
+			if saved.DatasourceID == 0 {
+				saved.DatasourceID = created.ID
+			}
+			if err := client.UpdateDatasourceEntry(ctx, spaceID, saved, dimensions[key]); err != nil {
+				return fmt.Errorf("set %s value of entry %s: %w", key, entry.Name, err)
+			}
+		}
+	}
+	report.Printf(ctx, "Created datasource %s (id=%d, %d entries)", file.Slug, created.ID, len(file.Entries))
+	return nil
+}
+
+// entryChange is a write needed to bring one target entry in line with the local file.
+type entryChange struct {
+	entry     Entry
+	target    *remoteEntry
+	value     bool
+	dimension []string
+}
+
+func updateDatasource(ctx context.Context, client *storyblok.Client, spaceID int, existing storyblok.Datasource, file File, dryRun bool, summary *Summary) error {
+	target, err := fetch(ctx, client, spaceID, existing)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]struct{}, len(existing.Dimensions))
+	for _, dim := range existing.Dimensions {
+		known[dim.EntryValue] = struct{}{}
+	}
+	var newDimensions []Dimension
+	for _, dim := range file.Dimensions {
+		if _, ok := known[dim.EntryValue]; !ok {
+			newDimensions = append(newDimensions, dim)
+		}
+	}
+	renamed := file.Name != "" && file.Name != existing.Name
+
+	var changes []entryChange
+	seen := make(map[string]struct{}, len(file.Entries))
+	for _, entry := range file.Entries {
+		seen[entry.Name] = struct{}{}
+		current, ok := target.entries[entry.Name]
+		if !ok {
+			changes = append(changes, entryChange{entry: entry})
+			continue
+		}
+		change := entryChange{entry: entry, target: current, value: current.entry.Value != entry.Value}
+		for _, dim := range file.Dimensions {
+			if current.dimensions[dim.EntryValue] != entry.DimensionValues[dim.EntryValue] {
+				change.dimension = append(change.dimension, dim.EntryValue)
+			}
+		}
+		if change.value || len(change.dimension) > 0 {
+			changes = append(changes, change)
+		} else {
+			summary.EntriesUnchanged++
+		}
+	}
+	kept := 0
+	for _, name := range target.order {
+		if _, ok := seen[name]; !ok {
+			kept++
+		}
+	}
+	summary.EntriesKept += kept
+
+	created, updated := 0, 0
+	for _, change := range changes {
+		if change.target == nil {
+			created++
+		} else {
+			updated++
+		}
+	}
+	summary.EntriesCreated += created
+	summary.EntriesUpdated += updated
+
+	if len(changes) == 0 && len(newDimensions) == 0 && !renamed {
+		summary.Unchanged = append(summary.Unchanged, file.Slug)
+		if dryRun {
+			report.Printf(ctx, "  - datasource %s is up to date", file.Slug)
+		}
+		return nil
+	}
+	summary.Updated = append(summary.Updated, file.Slug)
+
+	if dryRun {
+		report.Printf(ctx, "  - update datasource %s: %d entries to create, %d to update, %d only in target", file.Slug, created, updated, kept)
+		for _, dim := range newDimensions {
+			report.Printf(ctx, "    + dimension %s (%s)", dim.Name, dim.EntryValue)
+		}
+		for _, change := range changes {
+			switch {
+			case change.target == nil:
+				report.Printf(ctx, "    + %s = %q", change.entry.Name, change.entry.Value)
+			case change.value:
+				report.Printf(ctx, "    ~ %s: %q -> %q", change.entry.Name, change.target.entry.Value, change.entry.Value)
+			default:
+				report.Printf(ctx, "    ~ %s (%s)", change.entry.Name, strings.Join(change.dimension, ", "))
+			}
+		}
+		return nil
+	}
+
+	datasource := existing
+	if len(newDimensions) > 0 || renamed {
+		datasource.Name = file.Name
+		for _, dim := range newDimensions {
+			datasource.Dimensions = append(datasource.Dimensions, storyblok.DatasourceDimension{Name: dim.Name, EntryValue: dim.EntryValue})
+		}
+		saved, err := client.UpdateDatasource(ctx, spaceID, datasource)
+		if err != nil {
+			return err
+		}
+		datasource = saved
+	}
+	dimensions, err := dimensionIDs(ctx, client, spaceID, datasource, file.Dimensions)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		entry := storyblok.DatasourceEntry{Name: change.entry.Name, Value: change.entry.Value, DatasourceID: existing.ID}
+		dims := change.dimension
+		if change.target == nil {
+			saved, err := client.CreateDatasourceEntry(ctx, spaceID, entry)
+			if err != nil {
+				return fmt.Errorf("create entry %s: %w", entry.Name, err)
+			}
+			entry.ID = saved.ID
+			dims = nil
+			for key, value := range change.entry.DimensionValues {
+				if value != "" {
+					dims = append(dims, key)
+				}
+			}
+		} else {
+			entry.ID = change.target.entry.ID
+			if change.value {
+				if err := client.UpdateDatasourceEntry(ctx, spaceID, entry, 0); err != nil {
+					return fmt.Errorf("update entry %s: %w", entry.Name, err)
+				}
+			}
+		}
+		for _, key := range dims {
+			entry.DimensionValue = change.entry.DimensionValues[key]
+			if err := client.UpdateDatasourceEntry(ctx, spaceID, entry, dimensions[key]); err != nil {
+				return fmt.Errorf("set %s value of entry %s: %w", key, entry.Name, err)
+			}
+		}
+	}
+	report.Printf(ctx, "Updated datasource %s (id=%d): %d entries created, %d updated, %d only in target", file.Slug, existing.ID, created, updated, kept)
+	return nil
+}
+
+// dimensionIDs maps the entry_value of each wanted dimension to its ID in the target. When
+// a write response omits the dimensions, the datasource is listed again to find them.
+func dimensionIDs(ctx context.Context, client *storyblok.Client, spaceID int, datasource storyblok.Datasource, wanted []Dimension) (map[string]int, error) {
+	ids := make(map[string]int, len(datasource.Dimensions))
+	for _, dim := range datasource.Dimensions {
+		ids[dim.EntryValue] = dim.ID
+	}
+	complete := func() bool {
+		for _, dim := range wanted {
+			if ids[dim.EntryValue] == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if complete() {
+		return ids, nil
+	}
+
+	list, err := client.ListDatasources(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range list {
+		if ds.ID != datasource.ID {
+			continue
+		}
+		for _, dim := range ds.Dimensions {
+			ids[dim.EntryValue] = dim.ID
+		}
+	}
+	if !complete() {
+		return nil, fmt.Errorf("target did not create all dimensions of datasource %s", datasource.Slug)
+	}
+	return ids, nil
+}
+
+func printPushSummary(ctx context.Context, result PushResult, opts PushOptions) {
+	report.Printf(ctx, "")
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run summary: %d datasources to create, %d to update, %d unchanged; entries: %d to create, %d to update, %d only in target (rate-limit retries: %d, server retries: %d)",
+			len(result.Created), len(result.Updated), len(result.Unchanged),
+			result.EntriesCreated, result.EntriesUpdated, result.EntriesKept,
+			result.RateLimitRetries, result.ServerErrorRetries)
+	} else {
+		report.Printf(ctx, "Pushed %d datasources to space %d in %s (rate-limit retries: %d, server retries: %d)",
+			len(result.Created)+len(result.Updated)+len(result.Unchanged),
+			opts.SpaceID,
+			result.Duration.Truncate(time.Millisecond),
+			result.RateLimitRetries,
+			result.ServerErrorRetries,
+		)
+		if len(result.Created) > 0 {
+			report.Printf(ctx, "  Created: %s", strings.Join(result.Created, ", "))
+		}
+		if len(result.Updated) > 0 {
+			report.Printf(ctx, "  Updated: %s", strings.Join(result.Updated, ", "))
+		}
+		report.Printf(ctx, "  Entries: %d created, %d updated, %d unchanged, %d only in target",
+			result.EntriesCreated, result.EntriesUpdated, result.EntriesUnchanged, result.EntriesKept)
+	}
+	if len(result.MissingSelectors) > 0 {
+		report.Errorf(ctx, "Missing datasources matching: %s", strings.Join(result.MissingSelectors, ", "))
+	}
+}
@@ -0,0 +1,250 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/app/push"
+	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/schema"
+	"sbx/internal/storyblok"
+)
+
+// Output formats supported by the diff report.
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Options configures a semantic diff between local schemas and a space.
+type Options struct {
+	engine.Env
+	Token   string
+	SpaceID int
+	Dir     string
+	// Revision is the commit Dir was read from, if it was not the working tree.
+	Revision  string
+	Names     []string
+	MatchMode string
+	Format    string
+	FailOn    string
+}
+
+// Result captures the classified changes a push would make.
+type Result struct {
+	ExitCode         int
+	Changes          []schema.Change
+	Compared         int
+	Duration         time.Duration
+	RateLimitRetries int64
+	MissingSelectors []string
+}
+
+// Run compares local component schemas with the target space and classifies each change.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	ctx = opts.Context(ctx)
+
+	result := Result{ExitCode: 0}
+
+	if opts.MatchMode == "" {
+		opts.MatchMode = matcher.ModeExact
+	}
+	if err := matcher.ValidateMode(opts.MatchMode); err != nil {
+		return result, err
+	}
+	if opts.Format == "" {
+		opts.Format = FormatText
+	}
+	if err := validateFormat(opts.Format); err != nil {
+		return result, err
+	}
+
+	var failOn *schema.Severity
+	if opts.FailOn != "" {
+		threshold, err := schema.ParseSeverity(opts.FailOn)
+		if err != nil {
+			return result, err
+		}
+		failOn = &threshold
+	}
+
+	start := opts.Clock()()
+	client := opts.ClientFor(opts.Token)
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	pending, err := Compare(ctx, client, opts)
+	if err != nil {
+		if pending.APIError {
+			result.ExitCode = 2
+		}
+		return result, err
+	}
+	result.MissingSelectors = pending.MissingSelectors
+	if len(pending.MissingSelectors) > 0 {
+		result.ExitCode = 1
+	}
+
+	result.Changes = pending.Changes
+	result.Compared = pending.Compared
+	result.Duration = opts.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+
+	if failOn != nil && len(schema.AtLeast(result.Changes, *failOn)) > 0 {
+		result.ExitCode = 1
+	}
+
+	if err := printReport(ctx, result, opts); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Pending holds the changes a push of the selected local components would make.
+type Pending struct {
+	Changes          []schema.Change
+	Compared         int
+	Local            []storyblok.Component
+	Target           []storyblok.Component
+	MissingSelectors []string
+	APIError         bool
+}
+
+// Compare loads the selected local components from opts.Dir and diffs them against
+// their current versions in the target space.
+func Compare(ctx context.Context, client *storyblok.Client, opts Options) (Pending, error) {
+	var pending Pending
+
+	if opts.MatchMode == "" {
+		opts.MatchMode = matcher.ModeExact
+	}
+
+	files, err := push.LoadComponentFilesFS(ctx, opts.Files(), opts.Dir)
+	if err != nil {
+		return pending, err
+	}
+
+	selected, missing, err := matcher.Filter(files, func(cf push.ComponentFile) string {
+		return cf.Component.Name
+	}, opts.Names, opts.MatchMode, len(opts.Names) == 0)
+	if err != nil {
+		return pending, err
+	}
+	pending.MissingSelectors = missing
+
+	targetComponents, err := client.ListComponents(ctx, opts.SpaceID)
+	if err != nil {
+		report.Errorf(ctx, "failed to load components from Storyblok: %v", err)
+		pending.APIError = true
+		return pending, err
+	}
+
+	groups, err := client.ListComponentGroups(ctx, opts.SpaceID)
+	if err != nil {
+		report.Errorf(ctx, "failed to load component groups from Storyblok: %v", err)
+		pending.APIError = true
+		return pending, err
+	}
+
+	targets := make(map[string]storyblok.Component, len(targetComponents))
+	for _, component := range targetComponents {
+		targets[strings.ToLower(component.Name)] = component
+	}
+
+	for _, file := range selected {
+		// Lint suppressions are never pushed, so they are no change to the target.
+		pending.Local = append(pending.Local, schema.StripLocal(file.Component))
+		if target, ok := targets[strings.ToLower(file.Component.Name)]; ok {
+			pending.Target = append(pending.Target, target)
+		}
+	}
+
+	// Components missing locally are not pushed, so only compare what push would touch.
+	// Group whitelists hold each space's own UUIDs and are compared by group name.
+	names := schema.NewGroupNames(groups, pending.Local...)
+	pending.Changes = schema.DiffComponents(names.Components(pending.Target), names.Components(pending.Local))
+	pending.Compared = len(pending.Local)
+	return pending, nil
+}
+
+func validateFormat(format string) error {
+	if format != FormatText && format != FormatJSON && format != FormatMarkdown {
+		return fmt.Errorf("invalid format %q (expected text, json or markdown)", format)
+	}
+	return nil
+}
+
+func printReport(ctx context.Context, result Result, opts Options) error {
+	if opts.Format == FormatJSON {
+		payload := struct {
+			SpaceID  int             `json:"space_id"`
+			Revision string          `json:"revision,omitempty"`
+			Compared int             `json:"compared"`
+			Max      schema.Severity `json:"max_severity"`
+			Changes  []schema.Change `json:"changes"`
+		}{
+			SpaceID:  opts.SpaceID,
+			Revision: opts.Revision,
+			Compared: result.Compared,
+			Max:      schema.MaxSeverity(result.Changes),
+			Changes:  result.Changes,
+		}
+		if payload.Changes == nil {
+			payload.Changes = []schema.Change{}
+		}
+		data, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return err
+		}
+		report.Printf(ctx, "%s", data)
+		return nil
+	}
+
+	source := opts.Dir
+	if opts.Revision != "" {
+		source += " at commit " + opts.Revision
+	}
+	if opts.Format == FormatMarkdown {
+		printMarkdown(ctx, fmt.Sprintf("`%s` against space %d", source, opts.SpaceID), result)
+	} else {
+		report.Printf(ctx, "Diff: %s against space %d", source, opts.SpaceID)
+		printChanges(ctx, result, true)
+	}
+	if len(result.MissingSelectors) > 0 {
+		report.Errorf(ctx, "Missing components matching: %s", strings.Join(result.MissingSelectors, ", "))
+	}
+	return nil
+}
+
+// printChanges lists the changes one per line, followed by a summary by severity. Only
+// diffs against a space talk to the API and mention retries.
+func printChanges(ctx context.Context, result Result, api bool) {
+	for _, change := range result.Changes {
+		report.Printf(ctx, "  - %s", change)
+	}
+
+	counts := map[schema.Severity]int{}
+	for _, change := range result.Changes {
+		counts[change.Severity]++
+	}
+	summary := fmt.Sprintf("Compared %d components in %s: %d breaking, %d risky, %d safe changes",
+		result.Compared,
+		result.Duration.Truncate(time.Millisecond),
+		counts[schema.SeverityBreaking],
+		counts[schema.SeverityRisky],
+		counts[schema.SeveritySafe],
+	)
+	if api {
+		summary += fmt.Sprintf(" (rate-limit retries: %d)", result.RateLimitRetries)
+	}
+	report.Printf(ctx, "")
+	report.Printf(ctx, "%s", summary)
+}
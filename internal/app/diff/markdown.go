@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sbx/internal/report"
+	"sbx/internal/schema"
+)
+
+// printMarkdown reports the changes as a Markdown section for a pull request comment:
+// added and removed components first, then a table of field changes per component.
+func printMarkdown(ctx context.Context, subject string, result Result) {
+	report.Printf(ctx, "### Schema changes: %s", subject)
+	report.Printf(ctx, "")
+
+	if len(result.Changes) == 0 {
+		report.Printf(ctx, "No schema changes across %d components.", result.Compared)
+		return
+	}
+	counts := map[schema.Severity]int{}
+	for _, change := range result.Changes {
+		counts[change.Severity]++
+	}
+	breaking := fmt.Sprintf("%d breaking", counts[schema.SeverityBreaking])
+	if counts[schema.SeverityBreaking] > 0 {
+		breaking = "**" + breaking + "**"
+	}
+	report.Printf(ctx, "%s, %d risky, %d safe changes across %d components.",
+		breaking, counts[schema.SeverityRisky], counts[schema.SeveritySafe], result.Compared)
+
+	var added, removed []schema.Change
+	var order []string
+	fields := map[string][]schema.Change{}
+	for _, change := range result.Changes {
+		switch change.Kind {
+		case schema.ComponentAdded:
+			added = append(added, change)
+		case schema.ComponentRemoved:
+			removed = append(removed, change)
+		default:
+			if _, ok := fields[change.Component]; !ok {
+				order = append(order, change.Component)
+			}
+			fields[change.Component] = append(fields[change.Component], change)
+		}
+	}
+
+	printComponentList(ctx, "Added components", added)
+	printComponentList(ctx, "Removed components", removed)
+	for _, component := range order {
+		report.Printf(ctx, "")
+		report.Printf(ctx, "#### `%s`", component)
+		report.Printf(ctx, "")
+		report.Printf(ctx, "| Severity | Field | Change |")
+		report.Printf(ctx, "| --- | --- | --- |")
+		for _, change := range fields[component] {
+			field := ""
+			if change.Field != "" {
+				field = "`" + markdownCell(change.Field) + "`"
+			}
+			report.Printf(ctx, "| %s | %s | %s |", markdownSeverity(change.Severity), field, markdownCell(describe(change)))
+		}
+	}
+}
+
+func printComponentList(ctx context.Context, title string, changes []schema.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	report.Printf(ctx, "")
+	report.Printf(ctx, "**%s**", title)
+	report.Printf(ctx, "")
+	for _, change := range changes {
+		report.Printf(ctx, "- `%s` (%s)", change.Component, markdownSeverity(change.Severity))
+	}
+}
+
+// describe is the change without its location, as Change.String words it.
+func describe(change schema.Change) string {
+	if change.Detail != "" {
+		return change.Detail
+	}
+	return strings.ReplaceAll(string(change.Kind), "_", " ")
+}
+
+func markdownSeverity(severity schema.Severity) string {
+	if severity == schema.SeverityBreaking {
+		return "**breaking**"
+	}
+	return severity.String()
+}
+
+// markdownCell keeps text from breaking out of a table cell.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "\n", " ")
+}
@@ -0,0 +1,196 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/app/push"
+	"sbx/internal/fsutil"
+	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/schema"
+	"sbx/internal/storyblok"
+)
+
+// Revision is one version of the schema directory.
+type Revision struct {
+	// Name is how reports refer to the revision, such as the git ref it was read from.
+	Name string
+	// SHA is the commit the revision was read from, if any.
+	SHA string
+	FS  fsutil.FS
+}
+
+// RevisionsOptions configures a semantic diff between two versions of the schema
+// directory, such as two git commits.
+type RevisionsOptions struct {
+	engine.Env
+	Dir       string
+	Base      Revision
+	Head      Revision
+	Names     []string
+	MatchMode string
+	Format    string
+	FailOn    string
+}
+
+// Revisions classifies the changes between the components in Dir at Base and at Head,
+// including components that Head adds or removes.
+func Revisions(ctx context.Context, opts RevisionsOptions) (Result, error) {
+	ctx = opts.Context(ctx)
+
+	result := Result{ExitCode: 0}
+
+	if opts.MatchMode == "" {
+		opts.MatchMode = matcher.ModeExact
+	}
+	if err := matcher.ValidateMode(opts.MatchMode); err != nil {
+		return result, err
+	}
+	if opts.Format == "" {
+		opts.Format = FormatMarkdown
+	}
+	if err := validateFormat(opts.Format); err != nil {
+		return result, err
+	}
+
+	var failOn *schema.Severity
+	if opts.FailOn != "" {
+		threshold, err := schema.ParseSeverity(opts.FailOn)
+		if err != nil {
+			return result, err
+		}
+		failOn = &threshold
+	}
+
+	start := opts.Clock()()
+
+	base, baseMissing, err := loadRevision(ctx, opts.Base.FS, opts)
+	if err != nil {
+		return result, err
+	}
+	head, headMissing, err := loadRevision(ctx, opts.Head.FS, opts)
+	if err != nil {
+		return result, err
+	}
+	// A selector only counts as missing when it matches nothing on either side.
+	result.MissingSelectors = intersect(baseMissing, headMissing)
+	if len(result.MissingSelectors) > 0 {
+		result.ExitCode = 1
+	}
+
+	names := schema.NewGroupNames(nil, append(append([]storyblok.Component{}, base...), head...)...)
+	result.Changes = schema.DiffComponents(names.Components(base), names.Components(head))
+	result.Compared = countNames(base, head)
+	result.Duration = opts.Since(start)
+
+	if failOn != nil && len(schema.AtLeast(result.Changes, *failOn)) > 0 {
+		result.ExitCode = 1
+	}
+
+	if err := printRevisionsReport(ctx, result, opts); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// loadRevision returns the selected components of one revision and the selectors that
+// matched none of them.
+func loadRevision(ctx context.Context, fsys fsutil.FS, opts RevisionsOptions) ([]storyblok.Component, []string, error) {
+	files, err := push.LoadComponentFilesFS(ctx, fsutil.Or(fsys), opts.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected, missing, err := matcher.Filter(files, func(cf push.ComponentFile) string {
+		return cf.Component.Name
+	}, opts.Names, opts.MatchMode, len(opts.Names) == 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	components := make([]storyblok.Component, 0, len(selected))
+	for _, file := range selected {
+		components = append(components, schema.StripLocal(file.Component))
+	}
+	return components, missing, nil
+}
+
+func intersect(a, b []string) []string {
+	inB := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		inB[s] = struct{}{}
+	}
+	var both []string
+	for _, s := range a {
+		if _, ok := inB[s]; ok {
+			both = append(both, s)
+		}
+	}
+	return both
+}
+
+// countNames counts the distinct component names across both revisions.
+func countNames(base, head []storyblok.Component) int {
+	names := make(map[string]struct{}, len(base)+len(head))
+	for _, component := range append(append([]storyblok.Component(nil), base...), head...) {
+		names[strings.ToLower(component.Name)] = struct{}{}
+	}
+	return len(names)
+}
+
+// label names a revision in reports: its name and, when it differs, its short SHA.
+func (r Revision) label() string {
+	short := r.SHA
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	switch {
+	case r.Name == "":
+		return short
+	case short == "" || strings.HasPrefix(r.SHA, r.Name):
+		return r.Name
+	}
+	return fmt.Sprintf("%s (%s)", r.Name, short)
+}
+
+func printRevisionsReport(ctx context.Context, result Result, opts RevisionsOptions) error {
+	switch opts.Format {
+	case FormatJSON:
+		payload := struct {
+			Base     string          `json:"base"`
+			BaseSHA  string          `json:"base_sha,omitempty"`
+			Head     string          `json:"head"`
+			HeadSHA  string          `json:"head_sha,omitempty"`
+			Compared int             `json:"compared"`
+			Max      schema.Severity `json:"max_severity"`
+			Changes  []schema.Change `json:"changes"`
+		}{
+			Base:     opts.Base.Name,
+			BaseSHA:  opts.Base.SHA,
+			Head:     opts.Head.Name,
+			HeadSHA:  opts.Head.SHA,
+			Compared: result.Compared,
+			Max:      schema.MaxSeverity(result.Changes),
+			Changes:  result.Changes,
+		}
+		if payload.Changes == nil {
+			payload.Changes = []schema.Change{}
+		}
+		data, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return err
+		}
+		report.Printf(ctx, "%s", data)
+	case FormatMarkdown:
+		printMarkdown(ctx, fmt.Sprintf("`%s` from %s to %s", opts.Dir, opts.Base.label(), opts.Head.label()), result)
+	default:
+		report.Printf(ctx, "Diff: %s from %s to %s", opts.Dir, opts.Base.label(), opts.Head.label())
+		printChanges(ctx, result, false)
+	}
+	if len(result.MissingSelectors) > 0 {
+		report.Errorf(ctx, "Missing components matching: %s", strings.Join(result.MissingSelectors, ", "))
+	}
+	return nil
+}
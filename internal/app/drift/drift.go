@@ -0,0 +1,180 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"sbx/internal/infra/limiter"
+	"sbx/internal/matcher"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// Options configures a drift check against a space.
+type Options struct {
+	Token     string
+	SpaceID   int
+	Dir       string
+	Names     []string
+	MatchMode string
+}
+
+// Result summarises drift detected in a space.
+type Result struct {
+	ExitCode         int
+	Checked          int
+	Drifted          []ComponentDrift
+	Untracked        []string
+	Deleted          []string
+	Duration         time.Duration
+	RateLimitRetries int64
+	MissingSelectors []string
+}
+
+// ComponentDrift lists what changed in the target since sbx last synced a component.
+type ComponentDrift struct {
+	Name    string
+	Changes []state.FieldChange
+}
+
+// Fields renders the changed fields for log output.
+func (d ComponentDrift) Fields() string {
+	parts := make([]string, 0, len(d.Changes))
+	for _, change := range d.Changes {
+		field := change.Field
+		if change.Attribute {
+			field = "@" + field
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", field, change.Kind))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Detect compares the target's current state of component against the lockfile.
+// It returns false when the component was never synced or is unchanged.
+func Detect(lock *state.Lockfile, spaceID int, component storyblok.Component) (ComponentDrift, bool) {
+	recorded, ok := lock.Get(spaceID, component.Name)
+	if !ok {
+		return ComponentDrift{}, false
+	}
+	changes := state.Compare(recorded, state.Fingerprint(component))
+	if len(changes) == 0 {
+		return ComponentDrift{}, false
+	}
+	return ComponentDrift{Name: component.Name, Changes: changes}, true
+}
+
+// Run reports drift for every tracked component in a space.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := Result{ExitCode: 0}
+
+	if opts.MatchMode == "" {
+		opts.MatchMode = matcher.ModeExact
+	}
+	if err := matcher.ValidateMode(opts.MatchMode); err != nil {
+		return result, err
+	}
+
+	start := time.Now()
+
+	lock, err := state.Load(opts.Dir)
+	if err != nil {
+		return result, err
+	}
+	tracked := lock.Names(opts.SpaceID)
+	if len(tracked) == 0 {
+		fmt.Fprintf(os.Stderr, "No sync state recorded for space %d in %s\n", opts.SpaceID, lock.Path())
+	}
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	components, err := client.ListComponents(ctx, opts.SpaceID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load components from Storyblok: %v\n", err)
+		result.ExitCode = 2
+		return result, err
+	}
+
+	selected, missing, err := matcher.Filter(components, func(c storyblok.Component) string {
+		return c.Name
+	}, opts.Names, opts.MatchMode, len(opts.Names) == 0)
+	if err != nil {
+		return result, err
+	}
+	result.MissingSelectors = missing
+	if len(missing) > 0 {
+		result.ExitCode = 1
+	}
+
+	present := make(map[string]struct{}, len(components))
+	for _, component := range components {
+		present[strings.ToLower(component.Name)] = struct{}{}
+	}
+
+	for _, component := range selected {
+		if _, ok := lock.Get(opts.SpaceID, component.Name); !ok {
+			result.Untracked = append(result.Untracked, component.Name)
+			continue
+		}
+		result.Checked++
+		if drifted, ok := Detect(lock, opts.SpaceID, component); ok {
+			result.Drifted = append(result.Drifted, drifted)
+		}
+	}
+
+	if len(opts.Names) == 0 {
+		for _, name := range tracked {
+			if _, ok := present[strings.ToLower(name)]; !ok {
+				result.Deleted = append(result.Deleted, name)
+			}
+		}
+	}
+
+	sort.Slice(result.Drifted, func(i, j int) bool { return result.Drifted[i].Name < result.Drifted[j].Name })
+	sort.Strings(result.Untracked)
+
+	if len(result.Drifted) > 0 || len(result.Deleted) > 0 {
+		result.ExitCode = 1
+	}
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+
+	printReport(result, opts)
+
+	return result, nil
+}
+
+func printReport(result Result, opts Options) {
+	for _, drifted := range result.Drifted {
+		fmt.Printf("Drifted: %s — %s\n", drifted.Name, drifted.Fields())
+	}
+	for _, name := range result.Deleted {
+		fmt.Printf("Deleted: %s no longer exists in space %d\n", name, opts.SpaceID)
+	}
+
+	fmt.Println()
+	fmt.Printf("Checked %d tracked components in space %d in %s: %d drifted, %d deleted, %d untracked (rate-limit retries: %d)\n",
+		result.Checked,
+		opts.SpaceID,
+		result.Duration.Truncate(time.Millisecond),
+		len(result.Drifted),
+		len(result.Deleted),
+		len(result.Untracked),
+		result.RateLimitRetries,
+	)
+	if len(result.MissingSelectors) > 0 {
+		fmt.Fprintf(os.Stderr, "Missing components matching: %s\n", strings.Join(result.MissingSelectors, ", "))
+	}
+}
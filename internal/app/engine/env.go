@@ -0,0 +1,58 @@
+// Package engine holds the dependencies shared by the pull, push and diff workflows, so
+// that callers embedding sbx can replace them.
+package engine
+
+import (
+	"context"
+	"time"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// Env carries what a workflow runs against. Zero values select what the CLI uses: a
+// rate-limited client for the workflow's token, the local disk, the system clock and
+// console output.
+type Env struct {
+	Client   *storyblok.Client
+	FS       fsutil.FS
+	Now      func() time.Time
+	Observer report.Observer
+}
+
+// ClientFor returns the injected client, or a new rate-limited client for token.
+func (e Env) ClientFor(token string) *storyblok.Client {
+	if e.Client != nil {
+		return e.Client
+	}
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	return storyblok.NewClient(token, storyblok.WithLimiter(lim))
+}
+
+// Files returns the injected file system or the local disk.
+func (e Env) Files() fsutil.FS {
+	return fsutil.Or(e.FS)
+}
+
+// Clock returns the injected clock or time.Now.
+func (e Env) Clock() func() time.Time {
+	if e.Now != nil {
+		return e.Now
+	}
+	return time.Now
+}
+
+// Since returns the time elapsed since start on the env's clock.
+func (e Env) Since(start time.Time) time.Duration {
+	return e.Clock()().Sub(start)
+}
+
+// Context attaches the injected observer to ctx.
+func (e Env) Context(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return report.WithObserver(ctx, e.Observer)
+}
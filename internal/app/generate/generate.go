@@ -0,0 +1,224 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/app/push"
+	"sbx/internal/codegen"
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/storyblok"
+)
+
+// Supported target languages.
+const (
+	LangTypeScript = "ts"
+	LangGo         = "go"
+)
+
+// Options configures code generation from component schemas.
+type Options struct {
+	Token   string
+	SpaceID int
+	Dir     string
+	Lang    string
+	Out     string
+	Package string
+	// Split writes one file per component into the Out directory instead of a single document.
+	Split bool
+}
+
+// Result summarises a generation run.
+type Result struct {
+	ExitCode   int
+	Components int
+	Output     string
+	Files      int
+	Duration   time.Duration
+}
+
+// Types generates typed models for every component, reading local schema files from
+// opts.Dir or, when SpaceID is set, the components of that space.
+func Types(ctx context.Context, opts Options) (Result, error) {
+	result := Result{ExitCode: 0}
+
+	var render func(codegen.Input) ([]byte, error)
+	switch opts.Lang {
+	case LangTypeScript:
+		render = codegen.TypeScript
+	case LangGo:
+		render = func(in codegen.Input) ([]byte, error) {
+			return codegen.Go(in, opts.Package)
+		}
+	default:
+		result.ExitCode = 1
+		return result, fmt.Errorf("unsupported language %q (expected ts or go)", opts.Lang)
+	}
+
+	return run(ctx, opts, render)
+}
+
+// JSONSchema generates draft 2020-12 JSON Schema documents for every component, either
+// bundled into one document or, with opts.Split, one file per component in opts.Out.
+func JSONSchema(ctx context.Context, opts Options) (Result, error) {
+	if !opts.Split {
+		return run(ctx, opts, codegen.JSONSchemaBundle)
+	}
+	if opts.Out == "" || opts.Out == "-" {
+		return Result{ExitCode: 1}, fmt.Errorf("--split requires an output directory")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+	result, input, err := load(ctx, opts)
+	if err != nil {
+		return result, err
+	}
+	files, err := codegen.JSONSchemaFiles(input)
+	if err != nil {
+		return result, err
+	}
+	if err := fsutil.EnsureDir(opts.Out); err != nil {
+		return result, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(opts.Out, name), files[name], 0o644); err != nil {
+			return result, err
+		}
+	}
+	result.Output = opts.Out
+	result.Files = len(files)
+	result.Duration = time.Since(start)
+
+	fmt.Fprintf(os.Stderr, "Generated %d schema files into %s in %s\n", result.Files, opts.Out, result.Duration.Truncate(time.Millisecond))
+	return result, nil
+}
+
+func run(ctx context.Context, opts Options, render func(codegen.Input) ([]byte, error)) (Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+	result, input, err := load(ctx, opts)
+	if err != nil {
+		return result, err
+	}
+
+	data, err := render(input)
+	if err != nil {
+		return result, err
+	}
+
+	result.Output = opts.Out
+	result.Files = 1
+	if err := writeOutput(opts.Out, data); err != nil {
+		return result, err
+	}
+	result.Duration = time.Since(start)
+
+	if opts.Out != "" && opts.Out != "-" {
+		fmt.Fprintf(os.Stderr, "Generated %d components into %s in %s\n", result.Components, opts.Out, result.Duration.Truncate(time.Millisecond))
+	}
+	return result, nil
+}
+
+// load reads the schema input and rejects an empty schema set.
+func load(ctx context.Context, opts Options) (Result, codegen.Input, error) {
+	result := Result{ExitCode: 0}
+	input, err := LoadInput(ctx, opts)
+	if err != nil {
+		result.ExitCode = 2
+		if opts.SpaceID == 0 {
+			result.ExitCode = 1
+		}
+		return result, input, err
+	}
+	if len(input.Components) == 0 {
+		result.ExitCode = 1
+		return result, input, fmt.Errorf("no components found to generate from")
+	}
+	result.Components = len(input.Components)
+	for _, message := range input.Unresolved() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+	}
+	return result, input, nil
+}
+
+// LoadInput reads components and presets from the local schema directory, or from a
+// space when opts.SpaceID is set.
+func LoadInput(ctx context.Context, opts Options) (codegen.Input, error) {
+	if opts.SpaceID == 0 {
+		return loadLocal(ctx, opts.Dir)
+	}
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	var input codegen.Input
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		list, err := client.ListComponents(egCtx, opts.SpaceID)
+		if err != nil {
+			return err
+		}
+		input.Components = list
+		return nil
+	})
+	eg.Go(func() error {
+		list, err := client.ListPresets(egCtx, opts.SpaceID)
+		if err != nil {
+			return err
+		}
+		input.Presets = list
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		return codegen.Input{}, fmt.Errorf("failed to load schemas from space %d: %w", opts.SpaceID, err)
+	}
+	return input, nil
+}
+
+func loadLocal(ctx context.Context, dir string) (codegen.Input, error) {
+	var input codegen.Input
+	components, err := push.LoadComponentFiles(ctx, dir)
+	if err != nil {
+		return input, err
+	}
+	for _, file := range components {
+		input.Components = append(input.Components, file.Component)
+	}
+	presets, err := push.LoadPresetFiles(ctx, dir)
+	if err != nil {
+		return input, err
+	}
+	for _, file := range presets {
+		input.Presets = append(input.Presets, file.Preset)
+	}
+	return input, nil
+}
+
+func writeOutput(path string, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := fsutil.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,341 @@
+package impact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/app/diff"
+	"sbx/internal/content"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/matcher"
+	"sbx/internal/schema"
+	"sbx/internal/storyblok"
+)
+
+// Output formats supported by the impact report.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures an impact scan of pending schema changes.
+type Options struct {
+	Token     string
+	SpaceID   int
+	Dir       string
+	Names     []string
+	MatchMode string
+	Format    string
+}
+
+// Result summarises which stories pending schema changes affect.
+type Result struct {
+	ExitCode         int
+	Components       []ComponentImpact
+	StoriesScanned   int
+	Duration         time.Duration
+	RateLimitRetries int64
+	MissingSelectors []string
+}
+
+// ComponentImpact lists the stories using a changed component.
+type ComponentImpact struct {
+	Component string        `json:"component"`
+	Stories   int           `json:"stories"`
+	Fields    []FieldImpact `json:"fields"`
+}
+
+// FieldImpact lists stories holding a value for a changed or removed field.
+type FieldImpact struct {
+	Field   string          `json:"field"`
+	Changes []schema.Change `json:"changes"`
+	Count   int             `json:"count"`
+	Stories []StoryRef      `json:"stories"`
+}
+
+// StoryRef identifies an affected story.
+type StoryRef struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullSlug string `json:"full_slug"`
+}
+
+// Run computes pending changes like diff and counts the stories each one affects.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := Result{ExitCode: 0}
+
+	if opts.MatchMode == "" {
+		opts.MatchMode = matcher.ModeExact
+	}
+	if err := matcher.ValidateMode(opts.MatchMode); err != nil {
+		return result, err
+	}
+	if opts.Format == "" {
+		opts.Format = FormatText
+	}
+	if opts.Format != FormatText && opts.Format != FormatJSON {
+		return result, fmt.Errorf("invalid format %q (expected text or json)", opts.Format)
+	}
+
+	start := time.Now()
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	pending, err := diff.Compare(ctx, client, diff.Options{
+		SpaceID:   opts.SpaceID,
+		Dir:       opts.Dir,
+		Names:     opts.Names,
+		MatchMode: opts.MatchMode,
+	})
+	if err != nil {
+		if pending.APIError {
+			result.ExitCode = 2
+		}
+		return result, err
+	}
+	result.MissingSelectors = pending.MissingSelectors
+	if len(pending.MissingSelectors) > 0 {
+		result.ExitCode = 1
+	}
+
+	affected := affectingChanges(pending.Changes)
+	scanner := newStoryScanner(client, opts.SpaceID)
+
+	names := make([]string, 0, len(affected))
+	for name := range affected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		impact, err := scanner.Scan(ctx, name, affected[name])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to scan stories for %s: %v\n", name, err)
+			result.ExitCode = 2
+			return result, err
+		}
+		result.Components = append(result.Components, impact)
+	}
+
+	result.StoriesScanned = scanner.Loaded()
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+
+	if err := printReport(result, opts); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// affectingChanges groups changes that can invalidate stored content by component and
+// field. Additions and cosmetic property edits never affect existing values.
+func affectingChanges(changes []schema.Change) map[string]map[string][]schema.Change {
+	grouped := make(map[string]map[string][]schema.Change)
+	for _, change := range changes {
+		if change.Kind == schema.ComponentAdded || change.Kind == schema.FieldAdded {
+			continue
+		}
+		if change.Severity < schema.SeverityRisky && change.Kind != schema.ComponentRemoved {
+			continue
+		}
+		fields, ok := grouped[change.Component]
+		if !ok {
+			fields = make(map[string][]schema.Change)
+			grouped[change.Component] = fields
+		}
+		fields[change.Field] = append(fields[change.Field], change)
+	}
+	return grouped
+}
+
+// storyScanner loads story content once and reuses it across components.
+type storyScanner struct {
+	client  *storyblok.Client
+	spaceID int
+
+	mu      sync.Mutex
+	stories map[int]storyblok.Story
+}
+
+func newStoryScanner(client *storyblok.Client, spaceID int) *storyScanner {
+	return &storyScanner{
+		client:  client,
+		spaceID: spaceID,
+		stories: make(map[int]storyblok.Story),
+	}
+}
+
+func (s *storyScanner) Loaded() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.stories)
+}
+
+// Scan finds stories containing component and checks each changed field for values.
+// An empty field name stands for the component itself.
+func (s *storyScanner) Scan(ctx context.Context, component string, fields map[string][]schema.Change) (ComponentImpact, error) {
+	impact := ComponentImpact{Component: component}
+
+	listed, err := s.client.ListStories(ctx, s.spaceID, storyblok.StoryFilter{ContainComponent: component})
+	if err != nil {
+		return impact, err
+	}
+	stories, err := s.load(ctx, listed)
+	if err != nil {
+		return impact, err
+	}
+
+	fieldNames := make([]string, 0, len(fields))
+	for field := range fields {
+		fieldNames = append(fieldNames, field)
+	}
+	sort.Strings(fieldNames)
+
+	byField := make(map[string][]StoryRef, len(fieldNames))
+	for _, story := range stories {
+		used := false
+		hits := make(map[string]bool, len(fieldNames))
+		content.Walk(story.Content, func(blok map[string]any) bool {
+			if !content.IsComponent(blok, component) {
+				return true
+			}
+			used = true
+			for _, field := range fieldNames {
+				if field == "" || content.HasValue(blok, field) {
+					hits[field] = true
+				}
+			}
+			return true
+		})
+		if !used {
+			continue
+		}
+		impact.Stories++
+		ref := StoryRef{ID: story.ID, Name: story.Name, FullSlug: story.FullSlug}
+		for _, field := range fieldNames {
+			if hits[field] {
+				byField[field] = append(byField[field], ref)
+			}
+		}
+	}
+
+	for _, field := range fieldNames {
+		refs := byField[field]
+		sort.Slice(refs, func(i, j int) bool { return refs[i].FullSlug < refs[j].FullSlug })
+		if refs == nil {
+			refs = []StoryRef{}
+		}
+		impact.Fields = append(impact.Fields, FieldImpact{
+			Field:   field,
+			Changes: fields[field],
+			Count:   len(refs),
+			Stories: refs,
+		})
+	}
+	return impact, nil
+}
+
+func (s *storyScanner) load(ctx context.Context, listed []storyblok.Story) ([]storyblok.Story, error) {
+	stories := make([]storyblok.Story, len(listed))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(4)
+	for i, entry := range listed {
+		if entry.IsFolder {
+			continue
+		}
+		eg.Go(func() error {
+			s.mu.Lock()
+			cached, ok := s.stories[entry.ID]
+			s.mu.Unlock()
+			if ok {
+				stories[i] = cached
+				return nil
+			}
+			story, err := s.client.GetStory(egCtx, s.spaceID, entry.ID)
+			if err != nil {
+				return err
+			}
+			s.mu.Lock()
+			s.stories[entry.ID] = story
+			s.mu.Unlock()
+			stories[i] = story
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return stories, nil
+}
+
+func printReport(result Result, opts Options) error {
+	if opts.Format == FormatJSON {
+		payload := struct {
+			SpaceID        int               `json:"space_id"`
+			StoriesScanned int               `json:"stories_scanned"`
+			Components     []ComponentImpact `json:"components"`
+		}{
+			SpaceID:        opts.SpaceID,
+			StoriesScanned: result.StoriesScanned,
+			Components:     result.Components,
+		}
+		if payload.Components == nil {
+			payload.Components = []ComponentImpact{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(payload)
+	}
+
+	fmt.Printf("Impact of pending changes from %s on space %d\n", opts.Dir, opts.SpaceID)
+	for _, component := range result.Components {
+		fmt.Printf("  %s (%d stories)\n", component.Component, component.Stories)
+		for _, field := range component.Fields {
+			label := field.Field
+			if label == "" {
+				label = "(component)"
+			}
+			details := make([]string, 0, len(field.Changes))
+			for _, change := range field.Changes {
+				detail := change.Detail
+				if detail == "" {
+					detail = strings.ReplaceAll(string(change.Kind), "_", " ")
+				}
+				details = append(details, fmt.Sprintf("[%s] %s", change.Severity, detail))
+			}
+			fmt.Printf("    - %s %s: %d stories with values\n", label, strings.Join(details, "; "), field.Count)
+			for _, story := range field.Stories {
+				fmt.Printf("        %s (id=%d)\n", story.FullSlug, story.ID)
+			}
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Scanned %d stories for %d changed components in %s (rate-limit retries: %d)\n",
+		result.StoriesScanned,
+		len(result.Components),
+		result.Duration.Truncate(time.Millisecond),
+		result.RateLimitRetries,
+	)
+	if len(result.MissingSelectors) > 0 {
+		fmt.Fprintf(os.Stderr, "Missing components matching: %s\n", strings.Join(result.MissingSelectors, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sbx/internal/app/validate"
+	"sbx/internal/config"
+	"sbx/internal/diag"
+	"sbx/internal/schema"
+)
+
+// Output formats for lint reports.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// SuppressKey lists rule IDs, or "*", to skip. It is read from the top level of a component
+// file and from individual field definitions in its schema; push leaves it out of the
+// payloads it sends.
+const SuppressKey = schema.LintSuppressKey
+
+// Options configures a lint run over the local schema directory.
+type Options struct {
+	Dir    string
+	Format string
+	Config config.LintConfig
+}
+
+// Result summarises a lint run.
+type Result struct {
+	ExitCode    int
+	Components  int
+	Errors      int
+	Warnings    int
+	Suppressed  int
+	Diagnostics []diag.Diagnostic
+}
+
+// ruleState is a rule's effective configuration for one component.
+type ruleState struct {
+	rule     Rule
+	enabled  bool
+	severity diag.Severity
+	options  ruleOptions
+}
+
+// Run lints every component under opts.Dir and prints the findings. Files that fail to
+// load are reported as well so a broken file is never skipped silently.
+func Run(opts Options) (Result, error) {
+	result := Result{ExitCode: 0}
+
+	if opts.Format == "" {
+		opts.Format = FormatText
+	}
+	switch opts.Format {
+	case FormatText, FormatJSON, FormatSARIF:
+	default:
+		result.ExitCode = 1
+		return result, fmt.Errorf("invalid format %q (expected text, json, or sarif)", opts.Format)
+	}
+
+	if err := checkConfig(opts.Config); err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+
+	tree, err := validate.LoadTree(opts.Dir)
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+
+	diagnostics := append([]diag.Diagnostic{}, tree.Diagnostics...)
+	for _, file := range tree.Components {
+		found, suppressed, err := lintComponent(file, opts.Config)
+		if err != nil {
+			result.ExitCode = 1
+			return result, err
+		}
+		diagnostics = append(diagnostics, found...)
+		result.Suppressed += suppressed
+	}
+	diag.Sort(diagnostics)
+
+	result.Components = len(tree.Components)
+	result.Diagnostics = diagnostics
+	result.Errors, result.Warnings = diag.Count(diagnostics)
+	if result.Errors > 0 {
+		result.ExitCode = 1
+	}
+
+	if err := printReport(result, opts); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// lintComponent runs the rules enabled for a component and drops suppressed findings.
+func lintComponent(file *validate.File, cfg config.LintConfig) ([]diag.Diagnostic, int, error) {
+	states := resolve(cfg, file.Component.Name)
+	componentSuppressed := suppressions(file.Component.Extras[SuppressKey])
+	var diagnostics []diag.Diagnostic
+	suppressed := 0
+	for _, state := range states {
+		if !state.enabled {
+			continue
+		}
+		findings, err := state.rule.check(file, state.options)
+		if err != nil {
+			return nil, 0, fmt.Errorf("lint rule %s: %w", state.rule.ID, err)
+		}
+		for _, f := range findings {
+			if isSuppressed(componentSuppressed, state.rule.ID) || fieldSuppressed(file, f.field, state.rule.ID) {
+				suppressed++
+				continue
+			}
+			diagnostics = append(diagnostics, diag.At(file.Path, file.Data, state.severity, state.rule.ID, f.path, "%s", f.message))
+		}
+	}
+	return diagnostics, suppressed, nil
+}
+
+// resolve applies the global rule configuration and then each matching override in order.
+func resolve(cfg config.LintConfig, component string) []ruleState {
+	rules := Rules()
+	states := make([]ruleState, len(rules))
+	index := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		options := make(ruleOptions, len(rule.Defaults))
+		for key, value := range rule.Defaults {
+			options[key] = value
+		}
+		states[i] = ruleState{rule: rule, enabled: rule.Enabled, severity: rule.Severity, options: options}
+		index[rule.ID] = i
+	}
+
+	apply := func(configured map[string]config.RuleConfig) {
+		for id, rc := range configured {
+			state := &states[index[id]]
+			switch strings.ToLower(rc.Severity) {
+			case "off":
+				state.enabled = false
+			case "error":
+				state.enabled, state.severity = true, diag.SeverityError
+			case "warning", "warn":
+				state.enabled, state.severity = true, diag.SeverityWarning
+			case "":
+				// Configuring options alone enables a rule at its current severity.
+				if len(rc.Options) > 0 {
+					state.enabled = true
+				}
+			}
+			merged := make(ruleOptions, len(state.options)+len(rc.Options))
+			for key, value := range state.options {
+				merged[key] = value
+			}
+			for key, value := range rc.Options {
+				merged[key] = value
+			}
+			state.options = merged
+		}
+	}
+
+	apply(cfg.Rules)
+	name := strings.ToLower(component)
+	for _, override := range cfg.Overrides {
+		for _, glob := range override.Components {
+			if ok, _ := filepath.Match(strings.ToLower(glob), name); ok {
+				apply(override.Rules)
+				break
+			}
+		}
+	}
+	return states
+}
+
+// checkConfig rejects unknown rule IDs, severities and malformed globs up front.
+func checkConfig(cfg config.LintConfig) error {
+	ids := RuleIDs()
+	known := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		known[id] = struct{}{}
+	}
+	checkRules := func(scope string, rules map[string]config.RuleConfig) error {
+		for id, rc := range rules {
+			if _, ok := known[id]; !ok {
+				return fmt.Errorf("%s: unknown lint rule %q (known: %s)", scope, id, strings.Join(ids, ", "))
+			}
+			switch strings.ToLower(rc.Severity) {
+			case "", "off", "error", "warning", "warn":
+			default:
+				return fmt.Errorf("%s: rule %s has invalid severity %q (expected error, warning, or off)", scope, id, rc.Severity)
+			}
+		}
+		return nil
+	}
+
+	if err := checkRules("lint.rules", cfg.Rules); err != nil {
+		return err
+	}
+	for i, override := range cfg.Overrides {
+		scope := fmt.Sprintf("lint.overrides[%d]", i)
+		if len(override.Components) == 0 {
+			return fmt.Errorf("%s: components must list at least one glob", scope)
+		}
+		for _, glob := range override.Components {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("%s: invalid glob %q: %w", scope, glob, err)
+			}
+		}
+		if err := checkRules(scope, override.Rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func suppressions(value any) map[string]struct{} {
+	set := make(map[string]struct{})
+	switch v := value.(type) {
+	case string:
+		set[v] = struct{}{}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				set[s] = struct{}{}
+			}
+		}
+	}
+	return set
+}
+
+func isSuppressed(set map[string]struct{}, id string) bool {
+	_, all := set["*"]
+	_, one := set[id]
+	return all || one
+}
+
+func fieldSuppressed(file *validate.File, field, id string) bool {
+	if field == "" {
+		return false
+	}
+	def, _ := file.Component.Schema[field].(map[string]any)
+	return isSuppressed(suppressions(def[SuppressKey]), id)
+}
+
+func printReport(result Result, opts Options) error {
+	switch opts.Format {
+	case FormatSARIF:
+		rules := Rules()
+		infos := make([]diag.RuleInfo, 0, len(rules))
+		for _, rule := range rules {
+			infos = append(infos, diag.RuleInfo{ID: rule.ID, Description: rule.Description})
+		}
+		return diag.WriteSARIF(os.Stdout, "sbx lint", infos, result.Diagnostics)
+	case FormatJSON:
+		payload := struct {
+			Dir         string            `json:"dir"`
+			Components  int               `json:"components"`
+			Errors      int               `json:"errors"`
+			Warnings    int               `json:"warnings"`
+			Suppressed  int               `json:"suppressed"`
+			Diagnostics []diag.Diagnostic `json:"diagnostics"`
+		}{
+			Dir:         opts.Dir,
+			Components:  result.Components,
+			Errors:      result.Errors,
+			Warnings:    result.Warnings,
+			Suppressed:  result.Suppressed,
+			Diagnostics: result.Diagnostics,
+		}
+		if payload.Diagnostics == nil {
+			payload.Diagnostics = []diag.Diagnostic{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(payload)
+	}
+
+	for _, d := range result.Diagnostics {
+		fmt.Println(d)
+	}
+	if len(result.Diagnostics) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("Linted %d components in %s: %d errors, %d warnings, %d suppressed\n",
+		result.Components, opts.Dir, result.Errors, result.Warnings, result.Suppressed)
+	return nil
+}
+
+// RuleIDs returns the IDs of the built-in rules in order.
+func RuleIDs() []string {
+	rules := Rules()
+	ids := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.ID)
+	}
+	return ids
+}
+
+// PrintRules lists the built-in rules with their default state.
+func PrintRules() {
+	for _, rule := range Rules() {
+		state := "off"
+		if rule.Enabled {
+			state = rule.Severity.String()
+		}
+		fmt.Printf("%-28s %-8s %s\n", rule.ID, state, rule.Description)
+	}
+}
@@ -0,0 +1,275 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sbx/internal/app/validate"
+	"sbx/internal/diag"
+)
+
+// Rule is a built-in lint rule. Options are merged over Defaults before check runs.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    diag.Severity
+	Enabled     bool
+	Defaults    map[string]any
+	check       func(file *validate.File, options ruleOptions) ([]finding, error)
+}
+
+// finding is a rule violation. Field scopes it for field-level suppression; empty means
+// the component as a whole.
+type finding struct {
+	path    []string
+	field   string
+	message string
+}
+
+// Rules lists the built-in rules ordered by ID.
+func Rules() []Rule {
+	rules := []Rule{
+		{
+			ID:          "component-name-case",
+			Description: "Component technical names follow the configured case style (kebab, snake, camel).",
+			Severity:    diag.SeverityWarning,
+			Enabled:     true,
+			Defaults:    map[string]any{"style": "kebab"},
+			check:       checkNameCase,
+		},
+		{
+			ID:          "display-name-required",
+			Description: "Components set a display_name.",
+			Severity:    diag.SeverityWarning,
+			Enabled:     true,
+			check:       checkDisplayName,
+		},
+		{
+			ID:          "field-description-required",
+			Description: "Every content field has a description.",
+			Severity:    diag.SeverityWarning,
+			check:       checkFieldDescriptions,
+		},
+		{
+			ID:          "max-fields-per-tab",
+			Description: "No editor tab holds more than options.max fields.",
+			Severity:    diag.SeverityWarning,
+			Enabled:     true,
+			Defaults:    map[string]any{"max": 20},
+			check:       checkFieldsPerTab,
+		},
+		{
+			ID:          "required-internal-tag",
+			Description: "Components carry at least one of the internal tags in options.tags.",
+			Severity:    diag.SeverityError,
+			check:       checkInternalTag,
+		},
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+var namePatterns = map[string]*regexp.Regexp{
+	"kebab": regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`),
+	"snake": regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`),
+	"camel": regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+}
+
+func checkNameCase(file *validate.File, options ruleOptions) ([]finding, error) {
+	style, err := options.String("style")
+	if err != nil {
+		return nil, err
+	}
+	pattern, ok := namePatterns[style]
+	if !ok {
+		return nil, fmt.Errorf("unknown style %q (expected kebab, snake, or camel)", style)
+	}
+	name := file.Component.Name
+	if pattern.MatchString(name) {
+		return nil, nil
+	}
+	return []finding{{path: []string{"name"}, message: fmt.Sprintf("component name %q is not %s-case", name, style)}}, nil
+}
+
+func checkDisplayName(file *validate.File, _ ruleOptions) ([]finding, error) {
+	if strings.TrimSpace(file.Component.DisplayName) != "" {
+		return nil, nil
+	}
+	path := []string{"name"}
+	if hasKey(file, "display_name") {
+		path = []string{"display_name"}
+	}
+	return []finding{{path: path, message: fmt.Sprintf("component %q has no display_name", file.Component.Name)}}, nil
+}
+
+func checkFieldDescriptions(file *validate.File, _ ruleOptions) ([]finding, error) {
+	var findings []finding
+	for _, name := range sortedFields(file) {
+		def, _ := file.Component.Schema[name].(map[string]any)
+		if isLayoutField(def) {
+			continue
+		}
+		if description, _ := def["description"].(string); strings.TrimSpace(description) != "" {
+			continue
+		}
+		findings = append(findings, finding{
+			path:    []string{"schema", name},
+			field:   name,
+			message: fmt.Sprintf("field %q has no description", name),
+		})
+	}
+	return findings, nil
+}
+
+// checkFieldsPerTab counts content fields per editor tab; fields listed by no tab belong to
+// the default "General" tab.
+func checkFieldsPerTab(file *validate.File, options ruleOptions) ([]finding, error) {
+	limit, err := options.Int("max")
+	if err != nil {
+		return nil, err
+	}
+
+	owner := make(map[string]string)
+	var tabs []string
+	for _, name := range sortedFields(file) {
+		def, _ := file.Component.Schema[name].(map[string]any)
+		if fieldType, _ := def["type"].(string); fieldType != "tab" {
+			continue
+		}
+		tabs = append(tabs, name)
+		keys, _ := def["keys"].([]any)
+		for _, key := range keys {
+			if s, ok := key.(string); ok {
+				owner[s] = name
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, name := range sortedFields(file) {
+		def, _ := file.Component.Schema[name].(map[string]any)
+		if isLayoutField(def) {
+			continue
+		}
+		counts[owner[name]]++
+	}
+
+	var findings []finding
+	if counts[""] > limit {
+		findings = append(findings, finding{
+			path:    []string{"schema"},
+			message: fmt.Sprintf("the General tab has %d fields (max %d)", counts[""], limit),
+		})
+	}
+	for _, tab := range tabs {
+		if counts[tab] <= limit {
+			continue
+		}
+		label := tab
+		def, _ := file.Component.Schema[tab].(map[string]any)
+		if display, _ := def["display_name"].(string); display != "" {
+			label = display
+		}
+		findings = append(findings, finding{
+			path:    []string{"schema", tab},
+			field:   tab,
+			message: fmt.Sprintf("tab %q has %d fields (max %d)", label, counts[tab], limit),
+		})
+	}
+	return findings, nil
+}
+
+func checkInternalTag(file *validate.File, options ruleOptions) ([]finding, error) {
+	required, err := options.Strings("tags")
+	if err != nil {
+		return nil, err
+	}
+	if len(required) == 0 {
+		return nil, fmt.Errorf("options.tags must list at least one tag")
+	}
+	for _, tag := range file.Component.InternalTagsList {
+		for _, name := range required {
+			if strings.EqualFold(tag.Name, name) {
+				return nil, nil
+			}
+		}
+	}
+	path := []string{"name"}
+	if hasKey(file, "internal_tags_list") {
+		path = []string{"internal_tags_list"}
+	}
+	return []finding{{
+		path:    path,
+		message: fmt.Sprintf("component %q has none of the internal tags %s", file.Component.Name, strings.Join(required, ", ")),
+	}}, nil
+}
+
+func isLayoutField(def map[string]any) bool {
+	fieldType, _ := def["type"].(string)
+	return fieldType == "tab" || fieldType == "section"
+}
+
+func sortedFields(file *validate.File) []string {
+	names := make([]string, 0, len(file.Component.Schema))
+	for name := range file.Component.Schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasKey reports whether the component file sets key at the top level.
+func hasKey(file *validate.File, key string) bool {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(file.Data, &raw); err != nil {
+		return false
+	}
+	_, ok := raw[key]
+	return ok
+}
+
+// ruleOptions holds a rule's merged options.
+type ruleOptions map[string]any
+
+func (o ruleOptions) String(key string) (string, error) {
+	value, ok := o[key].(string)
+	if !ok {
+		return "", fmt.Errorf("options.%s must be a string", key)
+	}
+	return strings.ToLower(strings.TrimSpace(value)), nil
+}
+
+func (o ruleOptions) Int(key string) (int, error) {
+	switch v := o[key].(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("options.%s must be an integer", key)
+}
+
+func (o ruleOptions) Strings(key string) ([]string, error) {
+	switch v := o[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("options.%s must be a list of strings", key)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("options.%s must be a list of strings", key)
+}
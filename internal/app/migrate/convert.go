@@ -0,0 +1,174 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// converter transforms a stored field value into the representation of another field type.
+type converter func(value any) (any, error)
+
+var converters = map[string]converter{
+	"text-to-richtext":    textToRichtext,
+	"richtext-to-text":    richtextToText,
+	"to-number":           toNumber,
+	"to-string":           toString,
+	"to-boolean":          toBoolean,
+	"option-to-options":   singleToList,
+	"options-to-option":   listToSingle,
+	"asset-to-multiasset": singleToList,
+	"multiasset-to-asset": listToSingle,
+}
+
+func converterNames() []string {
+	names := make([]string, 0, len(converters))
+	for name := range converters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// textToRichtext wraps plain text in a richtext document, one paragraph per blank-line block.
+func textToRichtext(value any) (any, error) {
+	if doc, ok := value.(map[string]any); ok && doc["type"] == "doc" {
+		return doc, nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected string, got %T", value)
+	}
+	var paragraphs []any
+	for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		paragraphs = append(paragraphs, map[string]any{
+			"type": "paragraph",
+			"content": []any{
+				map[string]any{"type": "text", "text": block},
+			},
+		})
+	}
+	if paragraphs == nil {
+		paragraphs = []any{}
+	}
+	return map[string]any{"type": "doc", "content": paragraphs}, nil
+}
+
+// richtextToText flattens a richtext document to text, separating blocks with blank lines.
+func richtextToText(value any) (any, error) {
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	doc, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected richtext document, got %T", value)
+	}
+	blocks, _ := doc["content"].([]any)
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		var sb strings.Builder
+		collectText(block, &sb)
+		if text := strings.TrimSpace(sb.String()); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func collectText(node any, sb *strings.Builder) {
+	m, ok := node.(map[string]any)
+	if !ok {
+		return
+	}
+	if text, ok := m["text"].(string); ok {
+		sb.WriteString(text)
+	}
+	if m["type"] == "hard_break" {
+		sb.WriteString("\n")
+	}
+	children, _ := m["content"].([]any)
+	for _, child := range children {
+		collectText(child, sb)
+	}
+}
+
+func toNumber(value any) (any, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+		return n, nil
+	case bool:
+		if v {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to number", value)
+}
+
+func toString(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to string", value)
+}
+
+func toBoolean(value any) (any, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "0", "false", "no", "off":
+			return false, nil
+		case "1", "true", "yes", "on":
+			return true, nil
+		}
+		return nil, fmt.Errorf("%q is not a boolean", v)
+	}
+	return nil, fmt.Errorf("cannot convert %T to boolean", value)
+}
+
+// singleToList wraps a single value (option, asset) in a list.
+func singleToList(value any) (any, error) {
+	if list, ok := value.([]any); ok {
+		return list, nil
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return []any{}, nil
+	}
+	return []any{value}, nil
+}
+
+// listToSingle keeps the first entry of a list; further entries are dropped.
+func listToSingle(value any) (any, error) {
+	list, ok := value.([]any)
+	if !ok {
+		return value, nil
+	}
+	if len(list) == 0 {
+		return "", nil
+	}
+	return list[0], nil
+}
@@ -0,0 +1,421 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/content"
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/storyblok"
+)
+
+// Publish modes for migrated stories.
+const (
+	PublishNone      = "none"
+	PublishPublished = "published"
+	PublishAll       = "all"
+)
+
+// Options configures a content migration run.
+type Options struct {
+	Token     string
+	SpaceID   int
+	Files     []string
+	Dir       string
+	BackupDir string
+	Publish   string
+	DryRun    bool
+	// Protected refuses rename, move and delete operations, as for spaces the project
+	// configuration protects, unless AllowDestructive is set.
+	Protected        bool
+	AllowDestructive bool
+}
+
+// Result summarises a migration run.
+type Result struct {
+	ExitCode           int
+	StoriesScanned     int
+	StoriesChanged     int
+	StoriesUpdated     int
+	StoriesPublished   int
+	Failed             []string
+	BackupDir          string
+	Duration           time.Duration
+	RateLimitRetries   int64
+	ServerErrorRetries int64
+}
+
+// storyChange holds the migrated version of a story and a readable diff.
+type storyChange struct {
+	original storyblok.Story
+	migrated storyblok.Story
+	diff     []string
+}
+
+// Run applies migrations to every story containing a migrated component.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := Result{ExitCode: 0}
+
+	if opts.Publish == "" {
+		opts.Publish = PublishNone
+	}
+	switch opts.Publish {
+	case PublishNone, PublishPublished, PublishAll:
+	default:
+		return result, fmt.Errorf("invalid publish mode %q (expected none, published, or all)", opts.Publish)
+	}
+
+	migrations, err := LoadMigrations(opts.Files)
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+	if len(migrations) == 0 {
+		result.ExitCode = 1
+		return result, fmt.Errorf("no migration files found")
+	}
+	if opts.Protected && !opts.AllowDestructive && !opts.DryRun {
+		if err := refuseDestructive(opts.SpaceID, migrations); err != nil {
+			result.ExitCode = 1
+			return result, err
+		}
+	}
+
+	byComponent := make(map[string][]Migration)
+	for _, migration := range migrations {
+		key := strings.ToLower(migration.Component)
+		byComponent[key] = append(byComponent[key], migration)
+	}
+
+	start := time.Now()
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	stories, err := loadStories(ctx, client, opts.SpaceID, byComponent)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load stories from Storyblok: %v\n", err)
+		result.ExitCode = 2
+		return result, err
+	}
+	result.StoriesScanned = len(stories)
+
+	var changes []storyChange
+	for _, story := range stories {
+		change, changed, err := migrateStory(story, byComponent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s (id=%d): %v\n", story.FullSlug, story.ID, err)
+			result.Failed = append(result.Failed, story.FullSlug)
+			continue
+		}
+		if changed {
+			changes = append(changes, change)
+		}
+	}
+	result.StoriesChanged = len(changes)
+
+	if opts.DryRun {
+		printDryRun(changes, opts)
+	} else if len(changes) > 0 {
+		result.BackupDir = opts.BackupDir
+		if result.BackupDir == "" {
+			result.BackupDir = filepath.Join(opts.Dir, "backups", fmt.Sprintf("stories-%d-%s", opts.SpaceID, start.Format("20060102-150405")))
+		}
+		updated, published, err := applyChanges(ctx, client, opts, result.BackupDir, changes)
+		result.StoriesUpdated = updated
+		result.StoriesPublished = published
+		if err != nil {
+			result.ExitCode = 2
+			result.Duration = time.Since(start)
+			printSummary(result, opts)
+			return result, err
+		}
+	}
+
+	if len(result.Failed) > 0 {
+		result.ExitCode = 1
+	}
+
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+	result.ServerErrorRetries = counters.Status5xx.Load()
+
+	printSummary(result, opts)
+
+	return result, nil
+}
+
+// refuseDestructive reports the operations that rename, move or delete stored values in a
+// protected space and returns an error when there are any.
+func refuseDestructive(spaceID int, migrations []Migration) error {
+	count := 0
+	for _, migration := range migrations {
+		for _, op := range migration.Operations {
+			switch op.Op {
+			case OpRename, OpMove:
+				fmt.Fprintf(os.Stderr, "%s: %s %s.%s to %s\n", migration.Path, op.Op, migration.Component, op.From, op.To)
+			case OpDelete:
+				fmt.Fprintf(os.Stderr, "%s: delete %s.%s\n", migration.Path, migration.Component, op.Field)
+			default:
+				continue
+			}
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return fmt.Errorf("space %d is protected: refusing %d rename, move and delete operations without --allow-destructive", spaceID, count)
+}
+
+// loadStories lists every story containing a migrated component and loads its content once.
+func loadStories(ctx context.Context, client *storyblok.Client, spaceID int, byComponent map[string][]Migration) ([]storyblok.Story, error) {
+	components := make([]string, 0, len(byComponent))
+	for _, migrations := range byComponent {
+		components = append(components, migrations[0].Component)
+	}
+	sort.Strings(components)
+
+	seen := make(map[int]struct{})
+	var ids []int
+	for _, component := range components {
+		listed, err := client.ListStories(ctx, spaceID, storyblok.StoryFilter{ContainComponent: component})
+		if err != nil {
+			return nil, err
+		}
+		for _, story := range listed {
+			if story.IsFolder {
+				continue
+			}
+			if _, ok := seen[story.ID]; ok {
+				continue
+			}
+			seen[story.ID] = struct{}{}
+			ids = append(ids, story.ID)
+		}
+	}
+
+	stories := make([]storyblok.Story, len(ids))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(4)
+	for i, id := range ids {
+		eg.Go(func() error {
+			story, err := client.GetStory(egCtx, spaceID, id)
+			if err != nil {
+				return err
+			}
+			stories[i] = story
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(stories, func(i, j int) bool { return stories[i].FullSlug < stories[j].FullSlug })
+	return stories, nil
+}
+
+// migrateStory applies migrations to a copy of the story content, recursing through nested bloks.
+func migrateStory(story storyblok.Story, byComponent map[string][]Migration) (storyChange, bool, error) {
+	migrated := story
+	migrated.Content = cloneContent(story.Content)
+
+	var diff []string
+	var applyErr error
+	content.Walk(migrated.Content, func(blok map[string]any) bool {
+		if applyErr != nil {
+			return false
+		}
+		migrations, ok := byComponent[strings.ToLower(content.ComponentName(blok))]
+		if !ok {
+			return true
+		}
+		before := cloneContent(blok)
+		changed := false
+		for _, migration := range migrations {
+			for _, op := range migration.Operations {
+				opChanged, err := op.apply(blok)
+				if err != nil {
+					applyErr = fmt.Errorf("%s: %w", migration.Path, err)
+					return false
+				}
+				changed = changed || opChanged
+			}
+		}
+		if changed {
+			diff = append(diff, blokDiff(before, blok)...)
+		}
+		return true
+	})
+	if applyErr != nil {
+		return storyChange{}, false, applyErr
+	}
+	if len(diff) == 0 {
+		return storyChange{}, false, nil
+	}
+	return storyChange{original: story, migrated: migrated, diff: diff}, true, nil
+}
+
+// applyChanges backs up and saves migrated stories using four workers.
+func applyChanges(ctx context.Context, client *storyblok.Client, opts Options, backupDir string, changes []storyChange) (int, int, error) {
+	var mu sync.Mutex
+	updated, published := 0, 0
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(4)
+	for _, change := range changes {
+		eg.Go(func() error {
+			story := change.original
+			backupPath := filepath.Join(backupDir, fmt.Sprintf("%d-%s.json", story.ID, strings.ReplaceAll(story.FullSlug, "/", "_")))
+			if err := fsutil.WriteJSON(backupPath, story, 0); err != nil {
+				return fmt.Errorf("backup %s: %w", story.FullSlug, err)
+			}
+
+			publish := shouldPublish(opts.Publish, story)
+			if _, err := client.UpdateStoryContent(egCtx, opts.SpaceID, story.ID, change.migrated.Content, publish); err != nil {
+				return fmt.Errorf("update %s: %w", story.FullSlug, err)
+			}
+
+			mu.Lock()
+			updated++
+			if publish {
+				published++
+			}
+			mu.Unlock()
+			fmt.Printf("Migrated %s (id=%d)\n", story.FullSlug, story.ID)
+			return nil
+		})
+	}
+	err := eg.Wait()
+	return updated, published, err
+}
+
+// shouldPublish decides whether a migrated story goes live. In published mode only
+// stories that are live without pending draft edits are republished.
+func shouldPublish(mode string, story storyblok.Story) bool {
+	switch mode {
+	case PublishAll:
+		return true
+	case PublishPublished:
+		pending, _ := story.Extras["unpublished_changes"].(bool)
+		return story.Published && !pending
+	}
+	return false
+}
+
+func blokDiff(before, after map[string]any) []string {
+	uid, _ := after["_uid"].(string)
+	header := fmt.Sprintf("@ %s", content.ComponentName(after))
+	if uid != "" {
+		header += fmt.Sprintf(" (_uid=%s)", uid)
+	}
+	lines := []string{header}
+
+	keys := make(map[string]struct{})
+	for key := range before {
+		keys[key] = struct{}{}
+	}
+	for key := range after {
+		keys[key] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		oldValue, inBefore := before[key]
+		newValue, inAfter := after[key]
+		oldJSON, newJSON := encode(oldValue), encode(newValue)
+		if inBefore == inAfter && oldJSON == newJSON {
+			continue
+		}
+		if inBefore {
+			lines = append(lines, fmt.Sprintf("- %s: %s", key, oldJSON))
+		}
+		if inAfter {
+			lines = append(lines, fmt.Sprintf("+ %s: %s", key, newJSON))
+		}
+	}
+	return lines
+}
+
+func encode(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func cloneContent(src map[string]any) map[string]any {
+	if src == nil {
+		return nil
+	}
+	data, err := json.Marshal(src)
+	if err != nil {
+		return src
+	}
+	var dst map[string]any
+	if err := json.Unmarshal(data, &dst); err != nil {
+		return src
+	}
+	return dst
+}
+
+func printDryRun(changes []storyChange, opts Options) {
+	fmt.Printf("Dry run: migrating stories in space %d (publish: %s)\n", opts.SpaceID, opts.Publish)
+	for _, change := range changes {
+		action := "save draft"
+		if shouldPublish(opts.Publish, change.original) {
+			action = "publish"
+		}
+		fmt.Printf("  - %s (id=%d, %s)\n", change.original.FullSlug, change.original.ID, action)
+		for _, line := range change.diff {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+}
+
+func printSummary(result Result, opts Options) {
+	fmt.Println()
+	if opts.DryRun {
+		fmt.Printf("Dry run summary: %d of %d stories would change (rate-limit retries: %d)\n",
+			result.StoriesChanged, result.StoriesScanned, result.RateLimitRetries)
+	} else {
+		fmt.Printf("Migrated %d of %d stories in space %d in %s, %d published (rate-limit retries: %d, server retries: %d)\n",
+			result.StoriesUpdated,
+			result.StoriesScanned,
+			opts.SpaceID,
+			result.Duration.Truncate(time.Millisecond),
+			result.StoriesPublished,
+			result.RateLimitRetries,
+			result.ServerErrorRetries,
+		)
+		if result.BackupDir != "" && result.StoriesUpdated > 0 {
+			fmt.Printf("  Backups: %s\n", result.BackupDir)
+		}
+	}
+	if len(result.Failed) > 0 {
+		fmt.Fprintf(os.Stderr, "Failed to migrate %d stories: %s\n", len(result.Failed), strings.Join(result.Failed, ", "))
+	}
+}
@@ -0,0 +1,213 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sbx/internal/content"
+	"sbx/internal/fsutil"
+)
+
+// Operation names supported in migration files.
+const (
+	OpRename     = "rename"
+	OpMove       = "move"
+	OpConvert    = "convert"
+	OpSetDefault = "set_default"
+	OpDelete     = "delete"
+)
+
+// Migration is a declarative list of operations applied to every blok of a component.
+type Migration struct {
+	Path       string      `json:"-"`
+	Component  string      `json:"component"`
+	Operations []Operation `json:"operations"`
+}
+
+// Operation is a single field transformation. Which keys apply depends on Op:
+//   - rename: From → To within the blok
+//   - move: From → To where either may be a dotted path into nested objects
+//   - convert: Field converted with the named Converter
+//   - set_default: Field set to Value when missing or empty
+//   - delete: Field removed
+//
+// rename and move fail rather than overwrite a value already stored at To.
+type Operation struct {
+	Op        string `json:"op"`
+	Field     string `json:"field,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Converter string `json:"converter,omitempty"`
+	Value     any    `json:"value,omitempty"`
+}
+
+func (o Operation) validate() error {
+	switch o.Op {
+	case OpRename, OpMove:
+		if o.From == "" || o.To == "" {
+			return fmt.Errorf("%s requires from and to", o.Op)
+		}
+		if o.Op == OpRename && (strings.Contains(o.From, ".") || strings.Contains(o.To, ".")) {
+			return fmt.Errorf("rename works on top-level fields; use move for nested paths")
+		}
+	case OpConvert:
+		if o.Field == "" {
+			return fmt.Errorf("convert requires field")
+		}
+		if _, ok := converters[o.Converter]; !ok {
+			return fmt.Errorf("unknown converter %q (available: %s)", o.Converter, strings.Join(converterNames(), ", "))
+		}
+	case OpSetDefault:
+		if o.Field == "" || o.Value == nil {
+			return fmt.Errorf("set_default requires field and value")
+		}
+	case OpDelete:
+		if o.Field == "" {
+			return fmt.Errorf("delete requires field")
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", o.Op)
+	}
+	return nil
+}
+
+// apply runs the operation on a blok, reporting whether it changed anything.
+func (o Operation) apply(blok map[string]any) (bool, error) {
+	switch o.Op {
+	case OpRename, OpMove:
+		value, ok := getPath(blok, o.From)
+		if !ok {
+			return false, nil
+		}
+		if existing, ok := getPath(blok, o.To); ok && !content.IsEmpty(existing) {
+			return false, fmt.Errorf("%s %s → %s: %s already holds a value", o.Op, o.From, o.To, o.To)
+		}
+		deletePath(blok, o.From)
+		setPath(blok, o.To, value)
+		return true, nil
+	case OpConvert:
+		value, ok := blok[o.Field]
+		if !ok || value == nil {
+			return false, nil
+		}
+		converted, err := converters[o.Converter](value)
+		if err != nil {
+			return false, fmt.Errorf("convert %s with %s: %w", o.Field, o.Converter, err)
+		}
+		blok[o.Field] = converted
+		return true, nil
+	case OpSetDefault:
+		if value, ok := blok[o.Field]; ok && !content.IsEmpty(value) {
+			return false, nil
+		}
+		blok[o.Field] = o.Value
+		return true, nil
+	case OpDelete:
+		if _, ok := blok[o.Field]; !ok {
+			return false, nil
+		}
+		delete(blok, o.Field)
+		return true, nil
+	}
+	return false, nil
+}
+
+// LoadMigrations reads migration files from paths; directories contribute every
+// .json file they contain, in name order.
+func LoadMigrations(paths []string) ([]Migration, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+				names = append(names, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(names)
+		files = append(files, names...)
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	for _, path := range files {
+		var migration Migration
+		if err := fsutil.ReadJSON(path, &migration); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		migration.Path = path
+		if migration.Component == "" {
+			return nil, fmt.Errorf("%s: component is required", path)
+		}
+		if len(migration.Operations) == 0 {
+			return nil, fmt.Errorf("%s: no operations defined", path)
+		}
+		for i, op := range migration.Operations {
+			if err := op.validate(); err != nil {
+				return nil, fmt.Errorf("%s: operation %d: %w", path, i+1, err)
+			}
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+func getPath(m map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	current := m
+	for i, part := range parts {
+		value, ok := current[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return value, true
+		}
+		next, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return nil, false
+}
+
+func setPath(m map[string]any, path string, value any) {
+	parts := strings.Split(path, ".")
+	current := m
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+func deletePath(m map[string]any, path string) {
+	parts := strings.Split(path, ".")
+	current := m
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
@@ -0,0 +1,151 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOperationApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      Operation
+		blok    map[string]any
+		want    map[string]any
+		changed bool
+		wantErr bool
+	}{
+		{
+			name:    "rename",
+			op:      Operation{Op: OpRename, From: "headline", To: "title"},
+			blok:    map[string]any{"headline": "Hello"},
+			want:    map[string]any{"title": "Hello"},
+			changed: true,
+		},
+		{
+			name: "rename without source",
+			op:   Operation{Op: OpRename, From: "headline", To: "title"},
+			blok: map[string]any{"title": "Hello"},
+			want: map[string]any{"title": "Hello"},
+		},
+		{
+			name:    "rename onto an empty target",
+			op:      Operation{Op: OpRename, From: "headline", To: "title"},
+			blok:    map[string]any{"headline": "Hello", "title": ""},
+			want:    map[string]any{"title": "Hello"},
+			changed: true,
+		},
+		{
+			name:    "rename onto a value",
+			op:      Operation{Op: OpRename, From: "headline", To: "title"},
+			blok:    map[string]any{"headline": "Hello", "title": "Hi"},
+			want:    map[string]any{"headline": "Hello", "title": "Hi"},
+			wantErr: true,
+		},
+		{
+			name:    "move into a nested path",
+			op:      Operation{Op: OpMove, From: "cta_label", To: "cta.label"},
+			blok:    map[string]any{"cta_label": "Buy"},
+			want:    map[string]any{"cta": map[string]any{"label": "Buy"}},
+			changed: true,
+		},
+		{
+			name:    "move onto a nested value",
+			op:      Operation{Op: OpMove, From: "cta_label", To: "cta.label"},
+			blok:    map[string]any{"cta_label": "Buy", "cta": map[string]any{"label": "Order"}},
+			want:    map[string]any{"cta_label": "Buy", "cta": map[string]any{"label": "Order"}},
+			wantErr: true,
+		},
+		{
+			name:    "convert",
+			op:      Operation{Op: OpConvert, Field: "count", Converter: "to-number"},
+			blok:    map[string]any{"count": "42"},
+			want:    map[string]any{"count": float64(42)},
+			changed: true,
+		},
+		{
+			name:    "convert an invalid value",
+			op:      Operation{Op: OpConvert, Field: "count", Converter: "to-number"},
+			blok:    map[string]any{"count": "many"},
+			want:    map[string]any{"count": "many"},
+			wantErr: true,
+		},
+		{
+			name: "convert a missing field",
+			op:   Operation{Op: OpConvert, Field: "count", Converter: "to-number"},
+			blok: map[string]any{},
+			want: map[string]any{},
+		},
+		{
+			name:    "set default on an empty field",
+			op:      Operation{Op: OpSetDefault, Field: "theme", Value: "light"},
+			blok:    map[string]any{"theme": ""},
+			want:    map[string]any{"theme": "light"},
+			changed: true,
+		},
+		{
+			name: "set default keeps a value",
+			op:   Operation{Op: OpSetDefault, Field: "theme", Value: "light"},
+			blok: map[string]any{"theme": "dark"},
+			want: map[string]any{"theme": "dark"},
+		},
+		{
+			name: "set default keeps false",
+			op:   Operation{Op: OpSetDefault, Field: "visible", Value: true},
+			blok: map[string]any{"visible": false},
+			want: map[string]any{"visible": false},
+		},
+		{
+			name:    "delete",
+			op:      Operation{Op: OpDelete, Field: "legacy"},
+			blok:    map[string]any{"legacy": "x", "title": "Hello"},
+			want:    map[string]any{"title": "Hello"},
+			changed: true,
+		},
+		{
+			name: "delete a missing field",
+			op:   Operation{Op: OpDelete, Field: "legacy"},
+			blok: map[string]any{"title": "Hello"},
+			want: map[string]any{"title": "Hello"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := tt.op.apply(tt.blok)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.changed {
+				t.Errorf("apply() changed = %v, want %v", changed, tt.changed)
+			}
+			if !reflect.DeepEqual(tt.blok, tt.want) {
+				t.Errorf("blok = %v, want %v", tt.blok, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperationValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      Operation
+		wantErr bool
+	}{
+		{"rename", Operation{Op: OpRename, From: "a", To: "b"}, false},
+		{"rename without to", Operation{Op: OpRename, From: "a"}, true},
+		{"rename with a nested path", Operation{Op: OpRename, From: "a", To: "b.c"}, true},
+		{"move with a nested path", Operation{Op: OpMove, From: "a", To: "b.c"}, false},
+		{"convert", Operation{Op: OpConvert, Field: "a", Converter: "to-string"}, false},
+		{"convert with an unknown converter", Operation{Op: OpConvert, Field: "a", Converter: "to-date"}, true},
+		{"set default without value", Operation{Op: OpSetDefault, Field: "a"}, true},
+		{"delete without field", Operation{Op: OpDelete}, true},
+		{"unknown operation", Operation{Op: "copy", Field: "a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
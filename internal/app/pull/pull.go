@@ -3,21 +3,24 @@ package pull
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 
+	"sbx/internal/app/engine"
+	"sbx/internal/app/push"
 	"sbx/internal/fsutil"
-	"sbx/internal/infra/limiter"
 	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/state"
 	"sbx/internal/storyblok"
 )
 
 // Options collects configuration for pull operations.
 type Options struct {
+	engine.Env
 	Token     string
 	SpaceID   int
 	Names     []string
@@ -25,6 +28,8 @@ type Options struct {
 	All       bool
 	OutDir    string
 	DryRun    bool
+	// WithImages downloads preset screenshots next to the preset files.
+	WithImages bool
 }
 
 // Result captures a high-level summary for reporting/exit codes.
@@ -32,6 +37,7 @@ type Result struct {
 	ExitCode         int
 	ComponentsSynced int
 	PresetsSynced    int
+	ImagesSaved      int
 	Duration         time.Duration
 	RateLimitRetries int64
 	MissingSelectors []string
@@ -39,9 +45,7 @@ type Result struct {
 
 // Run executes the pull workflow.
 func Run(ctx context.Context, opts Options) (Result, error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
+	ctx = opts.Context(ctx)
 
 	result := Result{ExitCode: 0}
 
@@ -53,10 +57,8 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 		return result, fmt.Errorf("no component names provided; use --all to pull every component")
 	}
 
-	start := time.Now()
-
-	lim := limiter.NewSpaceLimiter(7, 7, 7)
-	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+	start := opts.Clock()()
+	client := opts.ClientFor(opts.Token)
 
 	counters := &storyblok.RetryCounters{}
 	ctx = storyblok.WithRetryCounters(ctx, counters)
@@ -92,7 +94,7 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 	})
 
 	if err := eg.Wait(); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to load data from Storyblok: %v\n", err)
+		report.Errorf(ctx, "failed to load data from Storyblok: %v", err)
 		result.ExitCode = 2
 		return result, err
 	}
@@ -128,24 +130,38 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 
 	selectedPresets := filterPresetsForComponents(presets, selectedComponents)
 
-	actions := buildPullActions(opts.SpaceID, opts.OutDir, selectedComponents, selectedPresets)
+	actions := buildPullActions(opts.Files(), opts.SpaceID, opts.OutDir, selectedComponents, selectedPresets)
 
 	if opts.DryRun {
-		printDryRun(actions, opts.SpaceID)
+		printDryRun(ctx, actions, opts.SpaceID)
+		if opts.WithImages {
+			result.ImagesSaved = printDryRunImages(ctx, opts, selectedPresets)
+		}
 	} else {
-		if err := executePull(actions); err != nil {
+		if err := executePull(ctx, opts.Files(), actions); err != nil {
 			result.ExitCode = 2
 			return result, err
 		}
+		if opts.WithImages {
+			saved, err := downloadPresetImages(ctx, client, opts, selectedPresets)
+			result.ImagesSaved = saved
+			if err != nil {
+				result.ExitCode = 2
+				return result, err
+			}
+		}
+		if err := recordState(opts, selectedComponents); err != nil {
+			report.Errorf(ctx, "failed to record sync state: %v", err)
+		}
 	}
 
-	dur := time.Since(start)
+	dur := opts.Since(start)
 	result.ComponentsSynced = len(selectedComponents)
 	result.PresetsSynced = len(selectedPresets)
 	result.Duration = dur
 	result.RateLimitRetries = counters.Status429.Load()
 
-	printSummary(result, opts)
+	printSummary(ctx, result, opts)
 
 	return result, nil
 }
@@ -183,12 +199,12 @@ type pullAction struct {
 	Payload    any
 }
 
-func buildPullActions(spaceID int, outDir string, components []storyblok.Component, presets []storyblok.ComponentPreset) []pullAction {
+func buildPullActions(fsys fsutil.FS, spaceID int, outDir string, components []storyblok.Component, presets []storyblok.ComponentPreset) []pullAction {
 	var actions []pullAction
 	for _, component := range components {
 		filename := fmt.Sprintf("%s-%d.json", component.Name, spaceID)
 		path := filepath.Join(outDir, filename)
-		overwrite, _ := fsutil.Exists(path)
+		overwrite, _ := fsutil.ExistsFS(fsys, path)
 		actions = append(actions, pullAction{
 			Kind:       "component",
 			Name:       component.Name,
@@ -198,9 +214,8 @@ func buildPullActions(spaceID int, outDir string, components []storyblok.Compone
 		})
 	}
 	for _, preset := range presets {
-		filename := fmt.Sprintf("%s-%d.json", preset.Name, spaceID)
-		path := filepath.Join(outDir, filename)
-		overwrite, _ := fsutil.Exists(path)
+		path := presetPath(outDir, spaceID, preset)
+		overwrite, _ := fsutil.ExistsFS(fsys, path)
 		actions = append(actions, pullAction{
 			Kind:       "preset",
 			Name:       preset.Name,
@@ -212,47 +227,112 @@ func buildPullActions(spaceID int, outDir string, components []storyblok.Compone
 	return actions
 }
 
-func printDryRun(actions []pullAction, spaceID int) {
-	fmt.Printf("Dry run: pulling from space %d\n", spaceID)
+func printDryRun(ctx context.Context, actions []pullAction, spaceID int) {
+	report.Printf(ctx, "Dry run: pulling from space %d", spaceID)
 	for _, action := range actions {
 		verb := "create"
 		if action.Overwrite {
 			verb = "overwrite"
 		}
-		fmt.Printf("  - %s %s -> %s (%s)\n", action.Kind, action.Name, action.OutputPath, verb)
+		report.Printf(ctx, "  - %s %s -> %s (%s)", action.Kind, action.Name, action.OutputPath, verb)
 	}
 }
 
-func executePull(actions []pullAction) error {
+func executePull(ctx context.Context, fsys fsutil.FS, actions []pullAction) error {
 	for _, action := range actions {
-		if err := fsutil.WriteJSON(action.OutputPath, action.Payload, 0); err != nil {
+		if err := fsutil.WriteJSONFS(fsys, action.OutputPath, action.Payload, 0); err != nil {
 			return err
 		}
-		fmt.Printf("Saved %s %s to %s\n", action.Kind, action.Name, action.OutputPath)
+		report.Printf(ctx, "Saved %s %s to %s", action.Kind, action.Name, action.OutputPath)
 	}
 	return nil
 }
 
-func printSummary(result Result, opts Options) {
+func presetPath(outDir string, spaceID int, preset storyblok.ComponentPreset) string {
+	return filepath.Join(outDir, fmt.Sprintf("%s-%d.json", preset.Name, spaceID))
+}
+
+func presetImage(preset storyblok.ComponentPreset) string {
+	image, _ := preset.Image.(string)
+	return strings.TrimSpace(image)
+}
+
+func printDryRunImages(ctx context.Context, opts Options, presets []storyblok.ComponentPreset) int {
+	count := 0
+	for _, preset := range presets {
+		image := presetImage(preset)
+		if image == "" {
+			continue
+		}
+		report.Printf(ctx, "  - image %s -> %s", preset.Name, push.PresetImagePath(presetPath(opts.OutDir, opts.SpaceID, preset), image))
+		count++
+	}
+	return count
+}
+
+// downloadPresetImages saves each preset's screenshot next to its JSON file so push can
+// upload it to another space without reading from the source space.
+func downloadPresetImages(ctx context.Context, client *storyblok.Client, opts Options, presets []storyblok.ComponentPreset) (int, error) {
+	saved := 0
+	for _, preset := range presets {
+		image := presetImage(preset)
+		if image == "" {
+			continue
+		}
+		data, err := client.Download(ctx, image)
+		if err != nil {
+			return saved, fmt.Errorf("download image of preset %s: %w", preset.Name, err)
+		}
+		path := push.PresetImagePath(presetPath(opts.OutDir, opts.SpaceID, preset), image)
+		if err := opts.Files().WriteFile(path, data, 0o644); err != nil {
+			return saved, err
+		}
+		report.Printf(ctx, "Saved image of preset %s to %s", preset.Name, path)
+		saved++
+	}
+	return saved, nil
+}
+
+// recordState stores fingerprints of the pulled components so later pushes back
+// into the same space can detect edits made outside sbx.
+func recordState(opts Options, components []storyblok.Component) error {
+	lock, err := state.LoadFS(opts.Files(), opts.OutDir)
+	if err != nil {
+		return err
+	}
+	lock.SetClock(opts.Now)
+	for _, component := range components {
+		lock.Record(opts.SpaceID, component)
+	}
+	return lock.Save()
+}
+
+func printSummary(ctx context.Context, result Result, opts Options) {
 	if opts.DryRun {
-		fmt.Println()
-		fmt.Printf("Dry run summary: %d components, %d presets (rate-limit retries: %d)\n",
+		report.Printf(ctx, "")
+		report.Printf(ctx, "Dry run summary: %d components, %d presets (rate-limit retries: %d)",
 			result.ComponentsSynced, result.PresetsSynced, result.RateLimitRetries)
+		if opts.WithImages {
+			report.Printf(ctx, "Preset images to download: %d", result.ImagesSaved)
+		}
 		if len(result.MissingSelectors) > 0 {
-			fmt.Fprintf(os.Stderr, "Missing components matching: %s\n", strings.Join(result.MissingSelectors, ", "))
+			report.Errorf(ctx, "Missing components matching: %s", strings.Join(result.MissingSelectors, ", "))
 		}
 		return
 	}
 
-	fmt.Println()
-	fmt.Printf("Pulled %d components and %d presets from space %d in %s (rate-limit retries: %d)\n",
+	report.Printf(ctx, "")
+	report.Printf(ctx, "Pulled %d components and %d presets from space %d in %s (rate-limit retries: %d)",
 		result.ComponentsSynced,
 		result.PresetsSynced,
 		opts.SpaceID,
 		result.Duration.Truncate(time.Millisecond),
 		result.RateLimitRetries,
 	)
+	if opts.WithImages {
+		report.Printf(ctx, "  Preset images: %d saved", result.ImagesSaved)
+	}
 	if len(result.MissingSelectors) > 0 {
-		fmt.Fprintf(os.Stderr, "Missing components matching: %s\n", strings.Join(result.MissingSelectors, ", "))
+		report.Errorf(ctx, "Missing components matching: %s", strings.Join(result.MissingSelectors, ", "))
 	}
 }
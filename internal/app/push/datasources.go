@@ -0,0 +1,137 @@
+package push
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"sbx/internal/app/datasources"
+	"sbx/internal/fsutil"
+	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// pushDatasources brings the datasources referenced by the pushed components up to date in
+// the target so that option fields never point at a missing or empty datasource.
+func pushDatasources(ctx context.Context, client *storyblok.Client, opts Options, refs datasourceRefs, result *Result) error {
+	files, missing, err := refs.resolve(ctx, opts.Files(), opts.Dir)
+	if err != nil {
+		return err
+	}
+	for _, slug := range missing {
+		report.Warnf(ctx, "Datasource %s referenced by %s has no local file in %s; run pull-datasources %s first",
+			slug, strings.Join(refs[slug], ", "), datasources.Dir(opts.Dir), slug)
+	}
+	result.MissingDatasources = missing
+	if len(files) == 0 {
+		return nil
+	}
+
+	if opts.DryRun {
+		report.Printf(ctx, "Dry run: datasources referenced by the pushed components")
+	} else {
+		report.Infof(ctx, "Syncing %d datasources referenced by the pushed components", len(files))
+	}
+	summary, err := datasources.Apply(ctx, client, opts.SpaceID, files, opts.DryRun)
+	result.Datasources = summary
+	return err
+}
+
+// ReferencedDatasources returns the sorted slugs of the datasources referenced by the
+// component files in dir that names select, or by every component file without names.
+func ReferencedDatasources(ctx context.Context, fsys fsutil.FS, dir string, names []string, mode string) ([]string, error) {
+	files, err := LoadComponentFilesFS(ctx, fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	selected, _, err := matcher.Filter(files, func(cf ComponentFile) string {
+		return cf.Component.Name
+	}, names, mode, len(names) == 0)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(datasourceRefs)
+	for _, file := range selected {
+		refs.add(file.Component)
+	}
+	return refs.slugs(), nil
+}
+
+// datasourceRefs records which components reference each datasource slug.
+type datasourceRefs map[string][]string
+
+// add records every datasource_slug in component's schema, including nested definitions.
+func (r datasourceRefs) add(component storyblok.Component) {
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, child := range v {
+				if slug, ok := child.(string); ok && key == "datasource_slug" && strings.TrimSpace(slug) != "" {
+					r.addSlug(slug, component.Name)
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(component.Schema)
+}
+
+func (r datasourceRefs) addSlug(slug, component string) {
+	key := strings.ToLower(strings.TrimSpace(slug))
+	for _, name := range r[key] {
+		if name == component {
+			return
+		}
+	}
+	r[key] = append(r[key], component)
+}
+
+// slugs returns the referenced slugs, lower-cased and sorted.
+func (r datasourceRefs) slugs() []string {
+	slugs := make([]string, 0, len(r))
+	for slug := range r {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	return slugs
+}
+
+// resolve returns the datasource files in fsys for the referenced slugs and the slugs
+// that have no local file.
+func (r datasourceRefs) resolve(ctx context.Context, fsys fsutil.FS, dir string) ([]datasources.File, []string, error) {
+	local, err := datasources.LoadFiles(ctx, fsys, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	bySlug := make(map[string]datasources.File, len(local))
+	for _, file := range local {
+		bySlug[strings.ToLower(file.Datasource.Slug)] = file.Datasource
+	}
+
+	var files []datasources.File
+	var missing []string
+	for _, slug := range r.slugs() {
+		file, ok := bySlug[slug]
+		if !ok {
+			missing = append(missing, slug)
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, missing, nil
+}
+
+func printDatasourceSummary(ctx context.Context, summary datasources.Summary, missing []string) {
+	report.Printf(ctx, "  Datasources: %d new, %d changed, %d unchanged; entries: %d new, %d changed",
+		len(summary.Created), len(summary.Updated), len(summary.Unchanged), summary.EntriesCreated, summary.EntriesUpdated)
+	if len(missing) > 0 {
+		report.Printf(ctx, "  Datasources without a local file: %s", strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,81 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sbx/internal/app/assets"
+	"sbx/internal/fsutil"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// PresetImagePath is where pull stores the screenshot of the preset saved at presetPath:
+// the same file name with the image's extension instead of .json.
+func PresetImagePath(presetPath, image string) string {
+	ext := strings.ToLower(filepath.Ext(storyblok.AssetFilename(image)))
+	if ext == "" || ext == ".json" {
+		ext = ".img"
+	}
+	return strings.TrimSuffix(presetPath, filepath.Ext(presetPath)) + ext
+}
+
+// presetImage returns the preset's screenshot URL, or "" when it has none.
+func presetImage(preset storyblok.ComponentPreset) string {
+	image, _ := preset.Image.(string)
+	return strings.TrimSpace(image)
+}
+
+// localPresetImages maps preset image URLs to screenshots pulled next to the preset files.
+func localPresetImages(fsys fsutil.FS, presets []PresetFile) map[string]string {
+	images := make(map[string]string)
+	for _, preset := range presets {
+		image := presetImage(preset.Preset)
+		if image == "" {
+			continue
+		}
+		path := PresetImagePath(preset.Path, image)
+		if ok, _ := fsutil.ExistsFS(fsys, path); ok {
+			images[image] = path
+		}
+	}
+	return images
+}
+
+// rewritePresetImages returns copies of presets whose screenshots point into the target
+// space, copying each image there first.
+func rewritePresetImages(ctx context.Context, transfer *assets.Transfer, presets []storyblok.ComponentPreset) ([]storyblok.ComponentPreset, error) {
+	out := make([]storyblok.ComponentPreset, len(presets))
+	for i, preset := range presets {
+		image := presetImage(preset)
+		if transfer.Needed(image) {
+			asset, err := transfer.Copy(ctx, image)
+			if err != nil {
+				return nil, fmt.Errorf("transfer image of preset %s: %w", preset.Name, err)
+			}
+			preset.Image = asset.URL
+		}
+		out[i] = preset
+	}
+	return out, nil
+}
+
+// logDryRunImages lists the preset screenshots a push would transfer.
+func logDryRunImages(ctx context.Context, presets []storyblok.ComponentPreset, local map[string]string, spaceID int) int {
+	count := 0
+	for _, preset := range presets {
+		image := presetImage(preset)
+		if image == "" || storyblok.IsSpaceAsset(image, spaceID) {
+			continue
+		}
+		source := "download " + storyblok.AssetURL(image)
+		if path, ok := local[image]; ok {
+			source = path
+		}
+		report.Printf(ctx, "  - would upload image of preset %s from %s", preset.Name, source)
+		count++
+	}
+	return count
+}
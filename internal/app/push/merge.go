@@ -0,0 +1,96 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/report"
+	"sbx/internal/schema"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// conflictSuffix marks files holding unresolved merge conflicts; discovery skips them.
+const conflictSuffix = ".conflict.json"
+
+// mergeWithTarget three-way merges the local schema with a drifted target schema,
+// using the schema recorded at the last sync as the base, or the target schema the
+// last conflicts were written against once they are resolved. Group whitelists are
+// merged by group name, since each space has its own group UUIDs.
+func mergeWithTarget(fsys fsutil.FS, lock *state.Lockfile, spaceID int, local ComponentFile, target storyblok.Component, groups schema.GroupNames) (schema.MergeResult, error) {
+	recorded, ok := lock.Get(spaceID, target.Name)
+	if !ok || recorded.Schema == nil {
+		return schema.MergeResult{}, fmt.Errorf("no base schema recorded for %s in %s; pull or push it once before merging", target.Name, lock.Path())
+	}
+	pending := conflictPath(local)
+	exists, err := fsutil.ExistsFS(fsys, pending)
+	if err != nil {
+		return schema.MergeResult{}, err
+	}
+	if exists {
+		return schema.MergeResult{}, fmt.Errorf("conflicts in %s are unresolved; resolve them and move the file over %s", pending, local.Path)
+	}
+	if schema.HasMarkers(local.Component.Schema) {
+		return schema.MergeResult{}, fmt.Errorf("%s still holds conflict markers", local.Path)
+	}
+	base := recorded.Schema
+	if recorded.MergeBase != nil {
+		base = recorded.MergeBase
+	}
+	return schema.Merge(groups.Schema(base), groups.Schema(local.Component.Schema), groups.Schema(target.Schema)), nil
+}
+
+func conflictPath(file ComponentFile) string {
+	return strings.TrimSuffix(file.Path, ".json") + conflictSuffix
+}
+
+// writeConflictFile stores the local component with conflict markers in place of
+// overlapping edits and records target as the base of the next merge. Resolving the
+// markers and moving the file over the original completes the merge on the next push.
+func writeConflictFile(fsys fsutil.FS, lock *state.Lockfile, spaceID int, file ComponentFile, target storyblok.Component, merged schema.MergeResult) (string, error) {
+	component := file.Component
+	component.Schema = merged.Marked()
+	path := conflictPath(file)
+	if err := fsutil.WriteJSONFS(fsys, path, component, 0); err != nil {
+		return "", err
+	}
+	lock.SetMergeBase(spaceID, target)
+	if err := lock.Save(); err != nil {
+		return "", fmt.Errorf("record merge base in %s: %w", lock.Path(), err)
+	}
+	return path, nil
+}
+
+// mergeWrite is the outcome of merging a drifted component, written to the local files
+// only after the push passed its gates.
+type mergeWrite struct {
+	file   ComponentFile
+	target storyblok.Component
+	merged schema.MergeResult
+	fields string
+}
+
+// write stores the merged schema in the local file, or the conflicts next to it. It
+// returns the path of the conflict file, if it wrote one.
+func (m mergeWrite) write(ctx context.Context, fsys fsutil.FS, lock *state.Lockfile, spaceID int) (string, error) {
+	if len(m.merged.Conflicts) > 0 {
+		return writeConflictFile(fsys, lock, spaceID, m.file, m.target, m.merged)
+	}
+	component := m.file.Component
+	component.Schema = m.merged.Schema
+	if err := fsutil.WriteJSONFS(fsys, m.file.Path, component, 0); err != nil {
+		return "", err
+	}
+	report.Infof(ctx, "Merged target changes into %s (%s)", m.file.Path, m.fields)
+	return "", nil
+}
+
+func conflictPaths(conflicts []schema.Conflict) string {
+	paths := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		paths = append(paths, conflict.Path())
+	}
+	return strings.Join(paths, ", ")
+}
@@ -0,0 +1,594 @@
+package push
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/config"
+	"sbx/internal/fsutil"
+	"sbx/internal/report"
+	"sbx/internal/schema"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// PlanVersion is the plan file format this build writes and applies.
+const PlanVersion = 1
+
+// Plan operation actions.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Plan operation kinds.
+const (
+	KindComponentGroup = "component_group"
+	KindInternalTag    = "internal_tag"
+	KindComponent      = "component"
+)
+
+// ErrStalePlan reports a plan whose target space changed after the plan was made.
+var ErrStalePlan = errors.New("target space changed since the plan was made")
+
+// Plan is the set of writes a push would make to a space, saved so it can be reviewed
+// and later applied exactly as planned.
+type Plan struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	SpaceID   int       `json:"space_id"`
+	Dir       string    `json:"dir"`
+	Revision  string    `json:"revision,omitempty"`
+	// Fingerprint hashes the target's components, groups, presets and tags as they were
+	// when the plan was made. Apply refuses to run once it no longer matches.
+	Fingerprint string      `json:"target_fingerprint"`
+	Operations  []Operation `json:"operations"`
+	Approval    *Approval   `json:"approval,omitempty"`
+}
+
+// Approval records that a plan was reviewed and approved. Digest covers the plan's space,
+// fingerprint and operations, so editing the plan afterwards voids the approval, and
+// Signature is the approver's Ed25519 signature of the approval.
+type Approval struct {
+	By        string    `json:"by"`
+	At        time.Time `json:"at"`
+	Digest    string    `json:"digest"`
+	Signature string    `json:"signature"`
+}
+
+// message is what the approver signs.
+func (a Approval) message() []byte {
+	return []byte(fmt.Sprintf("sbx plan approval\n%s\n%s\n%s", a.Digest, a.By, a.At.UTC().Format(time.RFC3339)))
+}
+
+// Operation is one write of a plan. Component groups and internal tags are created by
+// the component operations that reference them.
+type Operation struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	// ID is the target's ID of the component to update or delete.
+	ID        int                         `json:"id,omitempty"`
+	Component *storyblok.Component        `json:"component,omitempty"`
+	Presets   []storyblok.ComponentPreset `json:"presets,omitempty"`
+	Changes   []schema.Change             `json:"changes,omitempty"`
+	// DetachPreset unsets the target's default preset of the component to update.
+	DetachPreset bool `json:"detach_preset,omitempty"`
+}
+
+// PlanOptions configures MakePlan. The push options select what to plan; DryRun is
+// implied, and preset images and datasources are not part of plans.
+type PlanOptions struct {
+	Options
+	// Prune plans to delete the target's components that have no local file. It
+	// requires All.
+	Prune bool
+	// Source is where Dir was read from when that is not Dir itself, such as an archive.
+	// Plans record it as the place apply keeps the sync state.
+	Source string
+	// Output is the local path the plan is written to, outside the Env's file system so
+	// plans of archives and bundles stay out of them. Empty keeps the plan in memory.
+	Output string
+}
+
+// MakePlan computes the writes a push with opts would make, without making any.
+func MakePlan(ctx context.Context, opts PlanOptions) (Plan, Result, error) {
+	ctx = opts.Context(ctx)
+
+	plan := Plan{Version: PlanVersion, SpaceID: opts.SpaceID, Dir: opts.Dir, Revision: opts.Revision}
+	if opts.UploadImages || opts.WithDatasources {
+		return plan, Result{}, errors.New("plans cover components and presets only; push images and datasources separately")
+	}
+	if opts.Prune && !opts.All {
+		return plan, Result{}, errors.New("--prune requires --all")
+	}
+	if opts.Source != "" {
+		plan.Dir = opts.Source
+	}
+	plan.CreatedAt = opts.Clock()().UTC().Truncate(time.Second)
+
+	p := &planner{plan: &plan, prune: opts.Prune, seen: make(map[string]bool)}
+	result, err := run(ctx, opts.Options, p)
+	if err != nil {
+		return plan, result, err
+	}
+	plan.Operations = p.operations()
+
+	if opts.Output != "" {
+		if err := SavePlan(fsutil.OS, opts.Output, plan); err != nil {
+			result.ExitCode = 2
+			return plan, result, err
+		}
+	}
+	printPlanSummary(ctx, plan, opts.Output)
+	if opts.Protected {
+		if breaking := schema.AtLeast(result.Changes, schema.SeverityBreaking); len(breaking) > 0 {
+			report.Warnf(ctx, "Space %d is protected: applying the %d breaking changes of this plan requires --allow-destructive", plan.SpaceID, len(breaking))
+		}
+	}
+	return plan, result, nil
+}
+
+// SavePlan writes plan to path in fsys.
+func SavePlan(fsys fsutil.FS, path string, plan Plan) error {
+	return fsutil.WriteJSONFS(fsutil.Or(fsys), path, plan, 0)
+}
+
+// LoadPlan reads a plan written by SavePlan.
+func LoadPlan(fsys fsutil.FS, path string) (Plan, error) {
+	var plan Plan
+	if err := fsutil.ReadJSONFS(fsutil.Or(fsys), path, &plan); err != nil {
+		return plan, fmt.Errorf("read plan %s: %w", path, err)
+	}
+	if plan.Version != PlanVersion {
+		return plan, fmt.Errorf("plan %s has version %d; this build applies version %d", path, plan.Version, PlanVersion)
+	}
+	if plan.SpaceID == 0 || plan.Fingerprint == "" {
+		return plan, fmt.Errorf("plan %s has no target space or fingerprint", path)
+	}
+	return plan, nil
+}
+
+// Digest hashes what applying the plan does.
+func (p Plan) Digest() string {
+	return state.HashValue(struct {
+		SpaceID     int         `json:"space_id"`
+		Fingerprint string      `json:"target_fingerprint"`
+		Operations  []Operation `json:"operations"`
+	}{p.SpaceID, p.Fingerprint, p.Operations})
+}
+
+// VerifyApproval checks that the plan carries an approval of its current operations,
+// signed by the key of one of approvers, and that applier is not who approved it: a plan
+// needs a second person to reach a protected space.
+func (p Plan) VerifyApproval(approvers []config.Approver, applier string) error {
+	approval := p.Approval
+	if approval == nil {
+		return errors.New("the plan is not approved")
+	}
+	if approval.Digest != p.Digest() {
+		return errors.New("the plan changed after it was approved")
+	}
+	approver, ok := config.ProtectedConfig{Approvers: approvers}.Approver(approval.By)
+	if !ok {
+		return fmt.Errorf("%s is not a configured approver", approval.By)
+	}
+	key, err := approver.Key()
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(approval.Signature)
+	if err != nil || !ed25519.Verify(key, approval.message(), signature) {
+		return fmt.Errorf("the approval is not signed with the key of %s", approver.Name)
+	}
+	if applier != "" && strings.EqualFold(applier, approval.By) {
+		return fmt.Errorf("%s approved the plan and cannot also apply it", approval.By)
+	}
+	return nil
+}
+
+// ApproveOptions configures ApprovePlan.
+type ApproveOptions struct {
+	engine.Env
+	// Path is the plan file, read and rewritten in the Env's file system.
+	Path string
+	// By names who approves the plan.
+	By string
+	// KeyFile holds By's Ed25519 private key as a PKCS #8 PEM block, as openssl genpkey
+	// -algorithm ed25519 writes it. It is read from the Env's file system.
+	KeyFile string
+	// Approvers, when set, must list By with the public key of KeyFile.
+	Approvers []config.Approver
+}
+
+// ApprovePlan signs the plan file at Path as approved by By. Someone else can then apply
+// it to a protected space without further confirmation.
+func ApprovePlan(ctx context.Context, opts ApproveOptions) (Plan, error) {
+	ctx = opts.Context(ctx)
+
+	if strings.TrimSpace(opts.By) == "" {
+		return Plan{}, errors.New("approving a plan requires a name to record")
+	}
+	key, err := loadApprovalKey(opts.Files(), opts.KeyFile)
+	if err != nil {
+		return Plan{}, err
+	}
+	if len(opts.Approvers) > 0 {
+		approver, ok := config.ProtectedConfig{Approvers: opts.Approvers}.Approver(opts.By)
+		if !ok {
+			return Plan{}, fmt.Errorf("%s is not a configured approver", opts.By)
+		}
+		public, err := approver.Key()
+		if err != nil {
+			return Plan{}, err
+		}
+		if !public.Equal(key.Public()) {
+			return Plan{}, fmt.Errorf("%s is not the key of approver %s", opts.KeyFile, approver.Name)
+		}
+	}
+	plan, err := LoadPlan(opts.Files(), opts.Path)
+	if err != nil {
+		return plan, err
+	}
+	approval := Approval{By: opts.By, At: opts.Clock()().UTC().Truncate(time.Second), Digest: plan.Digest()}
+	approval.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, approval.message()))
+	plan.Approval = &approval
+	if err := SavePlan(opts.Files(), opts.Path, plan); err != nil {
+		return plan, err
+	}
+	report.Successf(ctx, "%s approved %s for space %d: %d components to create, %d to update, %d to delete (digest %s)",
+		opts.By,
+		opts.Path,
+		plan.SpaceID,
+		plan.Count(ActionCreate, KindComponent),
+		plan.Count(ActionUpdate, KindComponent),
+		plan.Count(ActionDelete, KindComponent),
+		shortHash(approval.Digest),
+	)
+	return plan, nil
+}
+
+// loadApprovalKey reads an Ed25519 private key from a PKCS #8 PEM file.
+func loadApprovalKey(fsys fsutil.FS, path string) (ed25519.PrivateKey, error) {
+	if path == "" {
+		return nil, errors.New("approving a plan requires the approver's private key")
+	}
+	data, err := fsutil.Or(fsys).ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read approval key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s holds no PEM block", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 private key", path)
+	}
+	return key, nil
+}
+
+// Count returns how many operations of plan take action on kind.
+func (p Plan) Count(action, kind string) int {
+	n := 0
+	for _, op := range p.Operations {
+		if op.Action == action && op.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// planner records what a dry run would write.
+type planner struct {
+	plan  *Plan
+	prune bool
+	// seen holds the groups and tags already planned, keyed by kind and name.
+	seen       map[string]bool
+	groups     []Operation
+	tags       []Operation
+	components []Operation
+	deletes    []Operation
+}
+
+func (p *planner) add(component, existing storyblok.Component, exists bool, presets []storyblok.ComponentPreset, detachPreset bool, changes []schema.Change, hasGroup, hasTag func(string) bool) {
+	if name := component.ComponentGroupName; name != "" && !hasGroup(name) && p.first(KindComponentGroup, name) {
+		p.groups = append(p.groups, Operation{Action: ActionCreate, Kind: KindComponentGroup, Name: name})
+	}
+	for _, tag := range component.InternalTagsList {
+		name := strings.TrimSpace(tag.Name)
+		if name != "" && !hasTag(name) && p.first(KindInternalTag, name) {
+			p.tags = append(p.tags, Operation{Action: ActionCreate, Kind: KindInternalTag, Name: name})
+		}
+	}
+
+	op := Operation{
+		Action:    ActionCreate,
+		Kind:      KindComponent,
+		Name:      component.Name,
+		Component: &component,
+		Presets:   presets,
+		Changes:   changes,
+	}
+	if exists {
+		op.Action = ActionUpdate
+		op.ID = existing.ID
+		op.DetachPreset = detachPreset && existing.PresetID != 0
+	}
+	p.components = append(p.components, op)
+}
+
+func (p *planner) first(kind, name string) bool {
+	key := kind + "/" + strings.ToLower(name)
+	if p.seen[key] {
+		return false
+	}
+	p.seen[key] = true
+	return true
+}
+
+// pruneMissing plans to delete the target components without a local file and returns
+// the resulting changes.
+func (p *planner) pruneMissing(ctx context.Context, targetComponents []storyblok.Component, local []ComponentFile) []schema.Change {
+	names := make(map[string]struct{}, len(local))
+	for _, file := range local {
+		names[strings.ToLower(strings.TrimSpace(file.Component.Name))] = struct{}{}
+	}
+	var changes []schema.Change
+	for _, component := range targetComponents {
+		if _, ok := names[strings.ToLower(strings.TrimSpace(component.Name))]; ok {
+			continue
+		}
+		change := schema.Change{Component: component.Name, Kind: schema.ComponentRemoved, Severity: schema.SeverityBreaking}
+		p.deletes = append(p.deletes, Operation{
+			Action:  ActionDelete,
+			Kind:    KindComponent,
+			Name:    component.Name,
+			ID:      component.ID,
+			Changes: []schema.Change{change},
+		})
+		changes = append(changes, change)
+		report.Printf(ctx, "Dry run: delete component %s in space %d (--prune)", component.Name, p.plan.SpaceID)
+	}
+	sort.Slice(p.deletes, func(i, j int) bool { return p.deletes[i].Name < p.deletes[j].Name })
+	return changes
+}
+
+// operations lists the planned writes in the order Apply makes them.
+func (p *planner) operations() []Operation {
+	ops := make([]Operation, 0, len(p.groups)+len(p.tags)+len(p.components)+len(p.deletes))
+	ops = append(ops, p.groups...)
+	ops = append(ops, p.tags...)
+	ops = append(ops, p.components...)
+	return append(ops, p.deletes...)
+}
+
+// fingerprint hashes the target's schema state independent of the order the API lists
+// it in.
+func (t target) fingerprint() string {
+	sorted := target{
+		Components: append([]storyblok.Component(nil), t.Components...),
+		Groups:     append([]storyblok.ComponentGroup(nil), t.Groups...),
+		Presets:    append([]storyblok.ComponentPreset(nil), t.Presets...),
+		Tags:       append([]storyblok.InternalTag(nil), t.Tags...),
+	}
+	sort.Slice(sorted.Components, func(i, j int) bool { return sorted.Components[i].ID < sorted.Components[j].ID })
+	sort.Slice(sorted.Groups, func(i, j int) bool { return sorted.Groups[i].UUID < sorted.Groups[j].UUID })
+	sort.Slice(sorted.Presets, func(i, j int) bool { return sorted.Presets[i].ID < sorted.Presets[j].ID })
+	sort.Slice(sorted.Tags, func(i, j int) bool { return sorted.Tags[i].ID < sorted.Tags[j].ID })
+	return state.HashValue(sorted)
+}
+
+// ApplyOptions configures Apply.
+type ApplyOptions struct {
+	engine.Env
+	Token string
+	Plan  Plan
+	// Dir holds the sbx.lock that records the applied components in the Env's file
+	// system; empty means the plan's Dir.
+	Dir string
+	// Protected and AllowDestructive work as for Run.
+	Protected        bool
+	AllowDestructive bool
+}
+
+// Apply makes the writes of a plan, and only those. It refuses to write anything when
+// the target space no longer matches the fingerprint the plan was made against.
+func Apply(ctx context.Context, opts ApplyOptions) (Result, error) {
+	ctx = opts.Context(ctx)
+
+	result := Result{ExitCode: 0}
+	plan := opts.Plan
+	dir := opts.Dir
+	if dir == "" {
+		dir = plan.Dir
+	}
+
+	var plans []componentPlan
+	var deletes []Operation
+	for _, op := range plan.Operations {
+		switch {
+		case op.Kind == KindComponentGroup || op.Kind == KindInternalTag:
+			// Created with the first component that references them.
+		case op.Kind == KindComponent && (op.Action == ActionCreate || op.Action == ActionUpdate):
+			if op.Component == nil {
+				return result, fmt.Errorf("plan operation %s %s has no component payload", op.Action, op.Name)
+			}
+			plans = append(plans, componentPlan{index: len(plans), component: *op.Component, presets: op.Presets, detachPreset: op.DetachPreset})
+		case op.Kind == KindComponent && op.Action == ActionDelete:
+			deletes = append(deletes, op)
+		default:
+			return result, fmt.Errorf("unsupported plan operation %s %s", op.Action, op.Kind)
+		}
+	}
+
+	start := opts.Clock()()
+	client := opts.ClientFor(opts.Token)
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	target, err := loadTarget(ctx, client, plan.SpaceID)
+	if err != nil {
+		report.Warnf(ctx, "failed to load target space metadata: %v", err)
+		result.ExitCode = 2
+		return result, err
+	}
+	if current := target.fingerprint(); current != plan.Fingerprint {
+		result.ExitCode = 1
+		return result, fmt.Errorf("%w (planned against %s, now %s); make a new plan", ErrStalePlan, shortHash(plan.Fingerprint), shortHash(current))
+	}
+	report.Infof(ctx, "Target space %d matches the plan fingerprint %s", plan.SpaceID, shortHash(plan.Fingerprint))
+
+	// The changes saved in the plan file are for review only: they are classified again
+	// against the live target, and every deletion is breaking, whatever the file says.
+	groupCache, componentCache, tagCache := target.caches()
+	planned := make([]storyblok.Component, 0, len(plans))
+	for _, job := range plans {
+		planned = append(planned, job.component)
+	}
+	groupNames := schema.NewGroupNames(target.Groups, planned...)
+	for i := range plans {
+		plans[i].existing, plans[i].exists = componentCache.Get(plans[i].component.Name)
+		result.Changes = append(result.Changes, analyzeChanges(plans[i].existing, plans[i].exists, plans[i].component, groupNames)...)
+	}
+	for i, op := range deletes {
+		existing, ok := componentCache.Get(op.Name)
+		if !ok {
+			result.ExitCode = 1
+			return result, fmt.Errorf("%w: component %s to delete is not in the target; make a new plan", ErrStalePlan, op.Name)
+		}
+		deletes[i].ID = existing.ID
+		result.Changes = append(result.Changes, schema.DiffComponents([]storyblok.Component{existing}, nil)...)
+	}
+	schema.SortChanges(result.Changes)
+
+	if opts.Protected && !opts.AllowDestructive {
+		if err := refuseDestructive(ctx, plan.SpaceID, result.Changes); err != nil {
+			result.ExitCode = 1
+			return result, err
+		}
+	}
+
+	lock, err := state.LoadFS(opts.Files(), dir)
+	if err != nil {
+		return result, err
+	}
+	lock.SetClock(opts.Now)
+
+	processor := componentProcessor{
+		client:        client,
+		spaceID:       plan.SpaceID,
+		groups:        groupCache,
+		tags:          tagCache,
+		components:    componentCache,
+		targetPresets: target.Presets,
+	}
+	outcomes, err := processor.processAll(ctx, plans)
+	for _, outcome := range outcomes {
+		if outcome.name == "" {
+			continue
+		}
+		lock.Record(plan.SpaceID, outcome.component)
+		if outcome.created {
+			result.CreatedComponents = append(result.CreatedComponents, outcome.name)
+		} else if outcome.updated {
+			result.UpdatedComponents = append(result.UpdatedComponents, outcome.name)
+		}
+		result.ComponentsSynced++
+		result.PresetsSynced += outcome.presets
+	}
+	if err == nil {
+		for _, op := range deletes {
+			if err = client.DeleteComponent(ctx, plan.SpaceID, op.ID); err != nil {
+				err = fmt.Errorf("delete component %s: %w", op.Name, err)
+				break
+			}
+			lock.Forget(plan.SpaceID, op.Name)
+			result.DeletedComponents = append(result.DeletedComponents, op.Name)
+			report.Successf(ctx, "Deleted component %s (id=%d)", op.Name, op.ID)
+		}
+	}
+	// Record what was applied even when a later write failed; the plan is stale then.
+	if saveErr := lock.Save(); saveErr != nil {
+		report.Warnf(ctx, "failed to record sync state in %s: %v", lock.Path(), saveErr)
+	}
+	if err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+
+	sort.Strings(result.CreatedComponents)
+	sort.Strings(result.UpdatedComponents)
+	result.RateLimitRetries = counters.Status429.Load()
+	result.ServerErrorRetries = counters.Status5xx.Load()
+	result.Duration = opts.Since(start)
+
+	printApplySummary(ctx, result, plan)
+	return result, nil
+}
+
+func printPlanSummary(ctx context.Context, plan Plan, output string) {
+	report.Printf(ctx, "")
+	report.Printf(ctx, "Plan for space %d: %d components to create, %d to update, %d to delete; %d groups and %d tags to create",
+		plan.SpaceID,
+		plan.Count(ActionCreate, KindComponent),
+		plan.Count(ActionUpdate, KindComponent),
+		plan.Count(ActionDelete, KindComponent),
+		plan.Count(ActionCreate, KindComponentGroup),
+		plan.Count(ActionCreate, KindInternalTag),
+	)
+	report.Printf(ctx, "  Target fingerprint: %s", shortHash(plan.Fingerprint))
+	if plan.Revision != "" {
+		report.Printf(ctx, "  Source: commit %s", plan.Revision)
+	}
+	if output != "" {
+		report.Successf(ctx, "Saved plan to %s; run sbx apply %s to make these changes", output, output)
+	}
+}
+
+func printApplySummary(ctx context.Context, result Result, plan Plan) {
+	report.Printf(ctx, "")
+	report.Printf(ctx, "Applied plan to space %d in %s: %d components and %d presets pushed, %d components deleted (rate-limit retries: %d, server retries: %d)",
+		plan.SpaceID,
+		result.Duration.Truncate(time.Millisecond),
+		result.ComponentsSynced,
+		result.PresetsSynced,
+		len(result.DeletedComponents),
+		result.RateLimitRetries,
+		result.ServerErrorRetries,
+	)
+	if plan.Revision != "" {
+		report.Printf(ctx, "  Source: commit %s", plan.Revision)
+	}
+	if len(result.CreatedComponents) > 0 {
+		report.Printf(ctx, "  Created: %s", strings.Join(result.CreatedComponents, ", "))
+	}
+	if len(result.UpdatedComponents) > 0 {
+		report.Printf(ctx, "  Updated: %s", strings.Join(result.UpdatedComponents, ", "))
+	}
+	if len(result.DeletedComponents) > 0 {
+		report.Printf(ctx, "  Deleted: %s", strings.Join(result.DeletedComponents, ", "))
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
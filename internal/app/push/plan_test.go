@@ -0,0 +1,302 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/fsutil"
+	"sbx/internal/report"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// fakeSpace serves the component endpoints of one Storyblok space from memory and
+// records every write it receives as "METHOD path".
+type fakeSpace struct {
+	mu         sync.Mutex
+	id         int
+	components []storyblok.Component
+	groups     []storyblok.ComponentGroup
+	presets    []storyblok.ComponentPreset
+	writes     []string
+	bodies     []map[string]any
+	nextID     int
+}
+
+func newFakeSpace(t *testing.T, id int, components ...storyblok.Component) (*fakeSpace, *storyblok.Client) {
+	t.Helper()
+	space := &fakeSpace{id: id, components: components, nextID: 1000}
+	server := httptest.NewServer(space)
+	t.Cleanup(server.Close)
+	return space, storyblok.NewClient("token", storyblok.WithBaseURL(server.URL))
+}
+
+func (s *fakeSpace) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := fmt.Sprintf("/spaces/%d/", s.id)
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	resource, rawID := path.Split(strings.TrimPrefix(r.URL.Path, prefix))
+	if resource == "" {
+		resource, rawID = rawID, ""
+	}
+	resource = strings.TrimSuffix(resource, "/")
+
+	if r.Method != http.MethodGet {
+		s.writes = append(s.writes, r.Method+" "+r.URL.Path)
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		s.bodies = append(s.bodies, body)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && resource == "components" && rawID == "":
+		s.reply(w, map[string]any{"components": s.components})
+	case r.Method == http.MethodGet && resource == "component_groups":
+		s.reply(w, map[string]any{"component_groups": s.groups})
+	case r.Method == http.MethodGet && resource == "presets":
+		s.reply(w, map[string]any{"presets": s.presets})
+	case r.Method == http.MethodGet && resource == "internal_tags":
+		s.reply(w, map[string]any{"internal_tags": []storyblok.InternalTag{}})
+	case resource == "components" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+		var payload struct {
+			Component storyblok.Component `json:"component"`
+		}
+		body := s.bodies[len(s.bodies)-1]
+		data, _ := json.Marshal(body)
+		if err := json.Unmarshal(data, &payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		component := payload.Component
+		if r.Method == http.MethodPost {
+			s.nextID++
+			component.ID = s.nextID
+			s.components = append(s.components, component)
+		} else {
+			component.ID, _ = strconv.Atoi(rawID)
+			for i := range s.components {
+				if s.components[i].ID == component.ID {
+					s.components[i] = component
+				}
+			}
+		}
+		s.reply(w, map[string]any{"component": component})
+	case resource == "components" && r.Method == http.MethodDelete:
+		id, _ := strconv.Atoi(rawID)
+		for i := range s.components {
+			if s.components[i].ID == id {
+				s.components = append(s.components[:i], s.components[i+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *fakeSpace) reply(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (s *fakeSpace) recorded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.writes...)
+}
+
+func writeJSON(t *testing.T, fsys *fsutil.MemFS, name string, value any) {
+	t.Helper()
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var quiet = report.ObserverFunc(func(report.Event) {})
+
+func TestApplyFingerprint(t *testing.T) {
+	remotePage := func() storyblok.Component {
+		return storyblok.Component{ID: 1, Name: "page", Schema: map[string]any{
+			"title": map[string]any{"type": "text", "pos": float64(0)},
+		}}
+	}
+	remoteHero := func() storyblok.Component {
+		return storyblok.Component{ID: 2, Name: "hero", Schema: map[string]any{}}
+	}
+
+	tests := []struct {
+		name string
+		// change edits the target space between planning and applying.
+		change func(s *fakeSpace)
+		stale  bool
+	}{
+		{
+			name:   "unchanged",
+			change: func(*fakeSpace) {},
+		},
+		{
+			name: "listed in another order",
+			change: func(s *fakeSpace) {
+				s.components[0], s.components[1] = s.components[1], s.components[0]
+			},
+		},
+		{
+			name: "component edited",
+			change: func(s *fakeSpace) {
+				s.components[0].Schema["teaser"] = map[string]any{"type": "textarea"}
+			},
+			stale: true,
+		},
+		{
+			name: "component added",
+			change: func(s *fakeSpace) {
+				s.components = append(s.components, storyblok.Component{ID: 3, Name: "footer", Schema: map[string]any{}})
+			},
+			stale: true,
+		},
+		{
+			name: "preset added",
+			change: func(s *fakeSpace) {
+				s.presets = append(s.presets, storyblok.ComponentPreset{ID: 9, Name: "Blue", ComponentID: 1, Preset: map[string]any{"component": "page"}})
+			},
+			stale: true,
+		},
+		{
+			name: "group added",
+			change: func(s *fakeSpace) {
+				s.groups = append(s.groups, storyblok.ComponentGroup{ID: 4, UUID: "layout-uuid", Name: "Layout"})
+			},
+			stale: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			space, client := newFakeSpace(t, 42, remotePage(), remoteHero())
+			fsys := fsutil.NewMemFS()
+			local := remotePage()
+			local.ID = 0
+			local.Schema["subtitle"] = map[string]any{"type": "text", "pos": float64(1)}
+			writeJSON(t, fsys, "schema/components/page.json", local)
+			env := engine.Env{Client: client, FS: fsys, Observer: quiet}
+
+			plan, _, err := MakePlan(context.Background(), PlanOptions{
+				Options: Options{Env: env, Token: "token", SpaceID: 42, Names: []string{"page"}, MatchMode: "exact", Dir: "schema"},
+			})
+			if err != nil {
+				t.Fatalf("MakePlan() error = %v", err)
+			}
+			if writes := space.recorded(); len(writes) > 0 {
+				t.Fatalf("MakePlan() wrote %v", writes)
+			}
+			if got := plan.Count(ActionUpdate, KindComponent); got != 1 {
+				t.Fatalf("plan updates %d components, want 1", got)
+			}
+
+			// Apply the plan as read back from its file.
+			if err := SavePlan(fsys, "page.plan.json", plan); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadPlan(fsys, "page.plan.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			space.mu.Lock()
+			tt.change(space)
+			space.mu.Unlock()
+
+			result, err := Apply(context.Background(), ApplyOptions{Env: env, Token: "token", Plan: loaded})
+			writes := space.recorded()
+			if tt.stale {
+				if !errors.Is(err, ErrStalePlan) {
+					t.Fatalf("Apply() error = %v, want ErrStalePlan", err)
+				}
+				if len(writes) > 0 {
+					t.Errorf("stale Apply() wrote %v", writes)
+				}
+				if _, err := fsys.ReadFile(path.Join("schema", state.LockFileName)); err == nil {
+					t.Error("stale Apply() wrote the lock file")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if want := []string{"PUT /spaces/42/components/1"}; !reflect.DeepEqual(writes, want) {
+				t.Errorf("Apply() wrote %v, want %v", writes, want)
+			}
+			if want := []string{"page"}; !reflect.DeepEqual(result.UpdatedComponents, want) {
+				t.Errorf("UpdatedComponents = %v, want %v", result.UpdatedComponents, want)
+			}
+			if _, err := fsys.ReadFile(path.Join("schema", state.LockFileName)); err != nil {
+				t.Errorf("Apply() did not record the sync state: %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyDetachesDanglingPreset(t *testing.T) {
+	remote := storyblok.Component{ID: 1, Name: "page", PresetID: 77, Schema: map[string]any{
+		"title": map[string]any{"type": "text"},
+	}}
+	space, client := newFakeSpace(t, 42, remote)
+	fsys := fsutil.NewMemFS()
+	local := remote
+	local.ID = 0
+	writeJSON(t, fsys, "schema/components/page.json", local)
+	env := engine.Env{Client: client, FS: fsys, Observer: quiet}
+
+	plan, _, err := MakePlan(context.Background(), PlanOptions{
+		Options: Options{Env: env, Token: "token", SpaceID: 42, Names: []string{"page"}, MatchMode: "exact", Dir: "schema", PresetPolicy: "detach"},
+	})
+	if err != nil {
+		t.Fatalf("MakePlan() error = %v", err)
+	}
+	if err := SavePlan(fsys, "page.plan.json", plan); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPlan(fsys, "page.plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Operations) != 1 || !loaded.Operations[0].DetachPreset {
+		t.Fatalf("plan operations = %+v, want one that detaches the default preset", loaded.Operations)
+	}
+
+	if _, err := Apply(context.Background(), ApplyOptions{Env: env, Token: "token", Plan: loaded}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	space.mu.Lock()
+	defer space.mu.Unlock()
+	if len(space.bodies) != 1 {
+		t.Fatalf("Apply() wrote %v, want one component update", space.writes)
+	}
+	sent := space.bodies[0]["component"].(map[string]any)
+	if value, ok := sent["preset_id"]; !ok || value != nil {
+		t.Errorf("preset_id sent as %v (present %v), want null", value, ok)
+	}
+}
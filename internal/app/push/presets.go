@@ -0,0 +1,127 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sbx/internal/diag"
+	"sbx/internal/fsutil"
+	"sbx/internal/report"
+	"sbx/internal/storyblok"
+)
+
+// Preset policies decide how push treats orphan presets and unresolved default presets.
+// detach unsets unresolved defaults in the target and skips orphans as warn does.
+const (
+	PresetPolicyWarn   = "warn"
+	PresetPolicyFail   = "fail"
+	PresetPolicyDetach = "detach"
+)
+
+// presetIssues collects the preset problems found before a push.
+type presetIssues struct {
+	orphans []diag.Diagnostic
+	// dangling maps lower-cased component names to their unresolved preset_id diagnostic.
+	dangling   map[string]diag.Diagnostic
+	unselected int
+}
+
+func (p presetIssues) count() int {
+	return len(p.orphans) + len(p.dangling)
+}
+
+func validatePresetPolicy(policy string) error {
+	switch policy {
+	case PresetPolicyWarn, PresetPolicyFail, PresetPolicyDetach:
+		return nil
+	}
+	return fmt.Errorf("invalid preset policy %q (expected warn, fail, or detach)", policy)
+}
+
+// findPresetIssues reports orphan presets, presets of components not selected for this
+// push, and selected components whose preset_id matches none of their local presets.
+func findPresetIssues(fsys fsutil.FS, components, selected []ComponentFile, presets []PresetFile, presetMap map[string][]storyblok.ComponentPreset) presetIssues {
+	issues := presetIssues{
+		orphans:  orphanPresets(fsys, components, presets),
+		dangling: make(map[string]diag.Diagnostic),
+	}
+
+	chosen := make(map[string]struct{}, len(selected))
+	for _, file := range selected {
+		chosen[strings.ToLower(file.Component.Name)] = struct{}{}
+	}
+	for _, file := range components {
+		key := strings.ToLower(file.Component.Name)
+		if _, ok := chosen[key]; !ok {
+			issues.unselected += len(presetMap[key])
+		}
+	}
+
+	for _, file := range selected {
+		component := file.Component
+		if component.PresetID == 0 || defaultPresetName(component, presetsForComponent(component, presetMap)) != "" {
+			continue
+		}
+		data, _ := fsys.ReadFile(file.Path)
+		issues.dangling[strings.ToLower(component.Name)] = diag.At(file.Path, data, diag.SeverityError, "dangling-default-preset", []string{"preset_id"},
+			"default preset_id %d of component %q matches none of its local presets", component.PresetID, component.Name)
+	}
+	return issues
+}
+
+// reportPresetIssues prints what happens to each orphan preset and unresolved default
+// under policy. It returns an error when the fail policy blocks the push.
+func reportPresetIssues(ctx context.Context, issues presetIssues, policy string, dryRun bool) error {
+	if issues.unselected > 0 {
+		report.Infof(ctx, "Ignoring %d presets of components not selected for this push", issues.unselected)
+	}
+	if issues.count() == 0 {
+		return nil
+	}
+
+	prefix := ""
+	if dryRun {
+		prefix = "Dry run: would "
+	}
+	// An orphan has no component to attach to or detach from, so only fail changes how
+	// orphans are treated.
+	for _, orphan := range issues.orphans {
+		if policy == PresetPolicyFail {
+			report.Errorf(ctx, "%s", orphan)
+		} else {
+			report.Warnf(ctx, "%s %s (%s)", sentence(prefix, "skip orphan preset"), orphan.File, orphan.Message)
+		}
+	}
+	for _, dangling := range sortedDiagnostics(issues.dangling) {
+		switch policy {
+		case PresetPolicyFail:
+			report.Errorf(ctx, "%s", dangling)
+		case PresetPolicyDetach:
+			report.Infof(ctx, "%s: %s", sentence(prefix, "clear the default preset"), dangling.Message)
+		default:
+			report.Warnf(ctx, "%s: %s", sentence(prefix, "keep the target's default preset"), dangling.Message)
+		}
+	}
+
+	if policy == PresetPolicyFail {
+		return fmt.Errorf("push blocked by %d orphan presets and %d unresolved default presets (--preset-policy fail)", len(issues.orphans), len(issues.dangling))
+	}
+	return nil
+}
+
+func sentence(prefix, action string) string {
+	if prefix == "" {
+		return strings.ToUpper(action[:1]) + action[1:]
+	}
+	return prefix + action
+}
+
+func sortedDiagnostics(byKey map[string]diag.Diagnostic) []diag.Diagnostic {
+	list := make([]diag.Diagnostic, 0, len(byKey))
+	for _, d := range byKey {
+		list = append(list, d)
+	}
+	diag.Sort(list)
+	return list
+}
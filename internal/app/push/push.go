@@ -2,9 +2,10 @@ package push
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -14,21 +15,47 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/singleflight"
 
+	"sbx/internal/app/assets"
+	"sbx/internal/app/datasources"
+	"sbx/internal/app/drift"
+	"sbx/internal/app/engine"
+	"sbx/internal/diag"
 	"sbx/internal/fsutil"
-	"sbx/internal/infra/limiter"
 	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/schema"
+	"sbx/internal/state"
 	"sbx/internal/storyblok"
 )
 
 // Options defines configuration for pushing components to a target space.
 type Options struct {
+	engine.Env
 	Token     string
 	SpaceID   int
 	Names     []string
 	MatchMode string
 	All       bool
 	Dir       string
-	DryRun    bool
+	// Revision is the commit Dir was read from, if it was not the working tree. Reports
+	// name it.
+	Revision string
+	DryRun   bool
+	Force    bool
+	Merge    bool
+	FailOn   string
+	Strict   bool
+	// PresetPolicy is warn, fail or detach; empty means warn.
+	PresetPolicy string
+	// UploadImages copies preset screenshots into the target space's asset library.
+	UploadImages bool
+	// WithDatasources creates or updates the datasources referenced by datasource_slug in
+	// the pushed components before the components themselves.
+	WithDatasources bool
+	// Protected refuses deletions, renames and other breaking changes, as for spaces the
+	// project configuration protects, unless AllowDestructive is set.
+	Protected        bool
+	AllowDestructive bool
 }
 
 // Result summarises the outcome of the push operation.
@@ -42,20 +69,23 @@ type Result struct {
 	MissingSelectors   []string
 	CreatedComponents  []string
 	UpdatedComponents  []string
+	DeletedComponents  []string
+	DriftedComponents  []drift.ComponentDrift
+	MergedComponents   []string
+	ConflictFiles      []string
+	Changes            []schema.Change
+	OrphanPresets      []string
+	DanglingDefaults   []string
+	ImagesUploaded     int
+	ImagesReused       int
+	Datasources        datasources.Summary
+	MissingDatasources []string
 }
 
 var (
 	errNoComponents = errors.New("no matching component files found")
 )
 
-var (
-	colorInfo    = "\033[36m"
-	colorWarn    = "\033[33m"
-	colorSuccess = "\033[32m"
-	colorReset   = "\033[0m"
-	useColor     = enableColor()
-)
-
 type groupCache struct {
 	mu     sync.RWMutex
 	data   map[string]string
@@ -153,6 +183,8 @@ type componentPlan struct {
 	existing  storyblok.Component
 	exists    bool
 	presets   []storyblok.ComponentPreset
+	// detachPreset unsets the target's default preset, which no local preset resolves.
+	detachPreset bool
 }
 
 type componentOutcome struct {
@@ -162,18 +194,19 @@ type componentOutcome struct {
 	presets     int
 	created     bool
 	updated     bool
+	component   storyblok.Component
 }
 
-func logSyncOutcome(outcome componentOutcome) {
+func logSyncOutcome(ctx context.Context, outcome componentOutcome) {
 	if outcome.name == "" {
 		return
 	}
 	if outcome.created {
-		successf("Created component %s (id=%d)", outcome.name, outcome.componentID)
+		report.Successf(ctx, "Created component %s (id=%d)", outcome.name, outcome.componentID)
 		return
 	}
 	if outcome.updated {
-		successf("Updated component %s (id=%d)", outcome.name, outcome.componentID)
+		report.Successf(ctx, "Updated component %s (id=%d)", outcome.name, outcome.componentID)
 	}
 }
 
@@ -184,12 +217,13 @@ type componentProcessor struct {
 	tags          *tagCache
 	components    *componentCache
 	targetPresets []storyblok.ComponentPreset
+	images        *assets.Transfer
 }
 
 func (p *componentProcessor) Process(ctx context.Context, plan componentPlan) (componentOutcome, error) {
 	component := plan.component
-	infof("Syncing component %s", component.Name)
-	infof("Component %s has %d preset candidates", component.Name, len(plan.presets))
+	report.Infof(ctx, "Syncing component %s", component.Name)
+	report.Infof(ctx, "Component %s has %d preset candidates", component.Name, len(plan.presets))
 	if plan.component.ComponentGroupName != "" {
 		uuid, err := ensureComponentGroup(ctx, p.client, p.spaceID, p.groups, plan.component.ComponentGroupName)
 		if err != nil {
@@ -209,6 +243,14 @@ func (p *componentProcessor) Process(ctx context.Context, plan componentPlan) (c
 	}
 	component.InternalTagIDs = storyblok.IntSlice(tagIDs)
 
+	presets := plan.presets
+	if p.images != nil {
+		presets, err = rewritePresetImages(ctx, p.images, presets)
+		if err != nil {
+			return componentOutcome{}, err
+		}
+	}
+
 	outcome := componentOutcome{
 		index:   plan.index,
 		name:    component.Name,
@@ -216,26 +258,28 @@ func (p *componentProcessor) Process(ctx context.Context, plan componentPlan) (c
 	}
 
 	if plan.exists {
-		updatedComp, err := updateComponent(ctx, p.client, p.spaceID, plan.existing, component, plan.presets, p.targetPresets)
+		updatedComp, err := updateComponent(ctx, p.client, p.spaceID, plan.existing, component, presets, p.targetPresets, plan.detachPreset)
 		if err != nil {
 			return outcome, err
 		}
 		outcome.updated = true
 		outcome.name = updatedComp.Name
 		outcome.componentID = updatedComp.ID
+		outcome.component = updatedComp
 		if !strings.EqualFold(plan.existing.Name, updatedComp.Name) {
 			p.components.Replace(plan.existing.Name, updatedComp.Name, updatedComp)
 		} else {
 			p.components.Set(updatedComp.Name, updatedComp)
 		}
 	} else {
-		createdComp, err := createComponent(ctx, p.client, p.spaceID, component, plan.presets)
+		createdComp, err := createComponent(ctx, p.client, p.spaceID, component, presets)
 		if err != nil {
 			return outcome, err
 		}
 		outcome.created = true
 		outcome.name = createdComp.Name
 		outcome.componentID = createdComp.ID
+		outcome.component = createdComp
 		p.components.Set(createdComp.Name, createdComp)
 	}
 
@@ -263,10 +307,154 @@ func (c *componentCache) Replace(oldName, newName string, component storyblok.Co
 	c.mu.Unlock()
 }
 
+// processAll runs Process for every plan on a small worker pool. Outcomes keep the
+// order of plans.
+func (p *componentProcessor) processAll(ctx context.Context, plans []componentPlan) ([]componentOutcome, error) {
+	workerCount := 4
+	if len(plans) < workerCount {
+		workerCount = len(plans)
+	}
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	jobs := make(chan componentPlan)
+	outcomes := make([]componentOutcome, len(plans))
+	var outcomeMu sync.Mutex
+	egWorkers, egCtx := errgroup.WithContext(ctx)
+
+	for i := 0; i < workerCount; i++ {
+		egWorkers.Go(func() error {
+			for {
+				select {
+				case <-egCtx.Done():
+					return egCtx.Err()
+				case job, ok := <-jobs:
+					if !ok {
+						return nil
+					}
+					outcome, err := p.Process(egCtx, job)
+					if err != nil {
+						return err
+					}
+					outcomeMu.Lock()
+					outcomes[job.index] = outcome
+					outcomeMu.Unlock()
+					logSyncOutcome(ctx, outcome)
+				}
+			}
+		})
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, job := range plans {
+			select {
+			case <-egCtx.Done():
+				return
+			case jobs <- job:
+			}
+		}
+	}()
+
+	err := egWorkers.Wait()
+	return outcomes, err
+}
+
+// target is the schema state of the space a push writes to.
+type target struct {
+	Components []storyblok.Component
+	Groups     []storyblok.ComponentGroup
+	Presets    []storyblok.ComponentPreset
+	Tags       []storyblok.InternalTag
+}
+
+func loadTarget(ctx context.Context, client *storyblok.Client, spaceID int) (target, error) {
+	var t target
+	eg, egCtx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		list, err := client.ListComponents(egCtx, spaceID)
+		if err != nil {
+			return err
+		}
+		t.Components = list
+		return nil
+	})
+
+	eg.Go(func() error {
+		list, err := client.ListComponentGroups(egCtx, spaceID)
+		if err != nil {
+			return err
+		}
+		t.Groups = list
+		return nil
+	})
+
+	eg.Go(func() error {
+		list, err := client.ListPresets(egCtx, spaceID)
+		if err != nil {
+			return err
+		}
+		t.Presets = list
+		return nil
+	})
+
+	eg.Go(func() error {
+		list, err := client.ListInternalTags(egCtx, spaceID)
+		if err != nil {
+			return err
+		}
+		t.Tags = list
+		return nil
+	})
+
+	err := eg.Wait()
+	return t, err
+}
+
+func (t target) caches() (*groupCache, *componentCache, *tagCache) {
+	groups := newGroupCache()
+	for _, g := range t.Groups {
+		if g.Name != "" && g.UUID != "" {
+			groups.Set(g.Name, g.UUID)
+		}
+	}
+
+	components := newComponentCache()
+	for _, comp := range t.Components {
+		components.Set(comp.Name, comp)
+	}
+
+	tags := newTagCache()
+	for _, tag := range t.Tags {
+		if tag.Name != "" && tag.ID > 0 {
+			tags.Set(tag.Name, tag.ID)
+		}
+	}
+	return groups, components, tags
+}
+
+// groupNames resolves the group UUIDs of the target and of the local component files.
+func (t target) groupNames(local []ComponentFile) schema.GroupNames {
+	components := make([]storyblok.Component, 0, len(local))
+	for _, file := range local {
+		components = append(components, file.Component)
+	}
+	return schema.NewGroupNames(t.Groups, components...)
+}
+
 // Run executes the push workflow.
 func Run(ctx context.Context, opts Options) (Result, error) {
-	if ctx == nil {
-		ctx = context.Background()
+	return run(ctx, opts, nil)
+}
+
+// run executes the push workflow. With a planner, it runs as a dry run and records
+// every write it would make in the planner's plan instead.
+func run(ctx context.Context, opts Options, planner *planner) (Result, error) {
+	ctx = opts.Context(ctx)
+	if planner != nil {
+		opts.DryRun = true
 	}
 
 	result := Result{ExitCode: 0}
@@ -279,10 +467,24 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 		return result, fmt.Errorf("no component names provided; use --all to push every component")
 	}
 
-	start := time.Now()
+	var failOn *schema.Severity
+	if opts.FailOn != "" {
+		threshold, err := schema.ParseSeverity(opts.FailOn)
+		if err != nil {
+			return result, err
+		}
+		failOn = &threshold
+	}
+
+	if opts.PresetPolicy == "" {
+		opts.PresetPolicy = PresetPolicyWarn
+	}
+	if err := validatePresetPolicy(opts.PresetPolicy); err != nil {
+		return result, err
+	}
 
-	lim := limiter.NewSpaceLimiter(7, 7, 7)
-	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+	start := opts.Clock()()
+	client := opts.ClientFor(opts.Token)
 
 	counters := &storyblok.RetryCounters{}
 	ctx = storyblok.WithRetryCounters(ctx, counters)
@@ -295,14 +497,14 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 		return result, errNoComponents
 	}
 
-	components, err := loadComponents(componentFiles)
+	components, skipped, err := loadComponents(ctx, opts.Files(), componentFiles)
 	if err != nil {
 		return result, err
 	}
-	if len(components) == 0 {
+	if len(components) == 0 && !opts.Strict {
 		return result, errNoComponents
 	}
-	infof("Loaded %d component files from %s", len(components), opts.Dir)
+	report.Infof(ctx, "Loaded %d component files from %s%s", len(components), opts.Dir, atRevision(opts.Revision))
 
 	selectedComponents, missing, err := matcher.Filter(components, func(cf ComponentFile) string {
 		return cf.Component.Name
@@ -315,95 +517,142 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 	if len(missing) > 0 {
 		result.ExitCode = 1
 	}
-	infof("Selected %d components (missing: %d)", len(selectedComponents), len(missing))
+	report.Infof(ctx, "Selected %d components (missing: %d)", len(selectedComponents), len(missing))
 
-	presetFiles, err := discoverPresetFiles(opts)
+	presetFiles, skippedPresets, err := discoverPresetFiles(ctx, opts)
 	if err != nil {
 		return result, err
 	}
-	infof("Discovered %d preset files", len(presetFiles))
-
-	presetMap := buildPresetMap(presetFiles)
-
-	eg, egCtx := errgroup.WithContext(ctx)
-
-	var targetComponents []storyblok.Component
-	eg.Go(func() error {
-		list, err := client.ListComponents(egCtx, opts.SpaceID)
-		if err != nil {
-			return err
-		}
-		targetComponents = list
-		return nil
-	})
-
-	var targetGroups []storyblok.ComponentGroup
-	eg.Go(func() error {
-		list, err := client.ListComponentGroups(egCtx, opts.SpaceID)
-		if err != nil {
-			return err
-		}
-		targetGroups = list
-		return nil
-	})
-
-	var targetPresets []storyblok.ComponentPreset
-	eg.Go(func() error {
-		list, err := client.ListPresets(egCtx, opts.SpaceID)
-		if err != nil {
-			return err
+	report.Infof(ctx, "Discovered %d preset files", len(presetFiles))
+
+	if opts.Strict {
+		var problems []diag.Diagnostic
+		problems = append(problems, skipped...)
+		problems = append(problems, skippedPresets...)
+		problems = append(problems, orphanPresets(opts.Files(), components, presetFiles)...)
+		problems = append(problems, missingSelectorDiagnostics(opts.Dir, missing)...)
+		diag.Sort(problems)
+		problems = diag.Unique(problems)
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				report.Errorf(ctx, "%s", problem)
+			}
+			result.ExitCode = 1
+			return result, fmt.Errorf("strict mode: %d problems in local schema files", len(problems))
 		}
-		targetPresets = list
-		return nil
-	})
+	}
+	if len(components) == 0 {
+		return result, errNoComponents
+	}
 
-	var targetTags []storyblok.InternalTag
-	eg.Go(func() error {
-		list, err := client.ListInternalTags(egCtx, opts.SpaceID)
-		if err != nil {
-			return err
-		}
-		targetTags = list
-		return nil
-	})
+	presetMap := buildPresetMap(presetFiles)
+	var localImages map[string]string
+	if opts.UploadImages {
+		localImages = localPresetImages(opts.Files(), presetFiles)
+	}
 
-	if err := eg.Wait(); err != nil {
-		warnf("failed to load target space metadata: %v", err)
-		result.ExitCode = 2
+	presetIssues := findPresetIssues(opts.Files(), components, selectedComponents, presetFiles, presetMap)
+	for _, orphan := range presetIssues.orphans {
+		result.OrphanPresets = append(result.OrphanPresets, orphan.File)
+	}
+	for _, dangling := range sortedDiagnostics(presetIssues.dangling) {
+		result.DanglingDefaults = append(result.DanglingDefaults, dangling.File)
+	}
+	if err := reportPresetIssues(ctx, presetIssues, opts.PresetPolicy, opts.DryRun); err != nil {
+		result.ExitCode = 1
 		return result, err
 	}
-	infof("Target space has %d components, %d groups, %d presets, %d tags", len(targetComponents), len(targetGroups), len(targetPresets), len(targetTags))
 
-	groupCache := newGroupCache()
-	for _, g := range targetGroups {
-		if g.Name != "" && g.UUID != "" {
-			groupCache.Set(g.Name, g.UUID)
-		}
+	target, err := loadTarget(ctx, client, opts.SpaceID)
+	if err != nil {
+		report.Warnf(ctx, "failed to load target space metadata: %v", err)
+		result.ExitCode = 2
+		return result, err
 	}
-
-	componentCache := newComponentCache()
-	for _, comp := range targetComponents {
-		componentCache.Set(comp.Name, comp)
+	report.Infof(ctx, "Target space has %d components, %d groups, %d presets, %d tags", len(target.Components), len(target.Groups), len(target.Presets), len(target.Tags))
+	groupCache, componentCache, tagCache := target.caches()
+	groupNames := target.groupNames(components)
+	if planner != nil {
+		planner.plan.Fingerprint = target.fingerprint()
 	}
 
-	tagCache := newTagCache()
-	for _, tag := range targetTags {
-		if tag.Name != "" && tag.ID > 0 {
-			tagCache.Set(tag.Name, tag.ID)
-		}
+	lock, err := state.LoadFS(opts.Files(), opts.Dir)
+	if err != nil {
+		return result, err
 	}
+	lock.SetClock(opts.Now)
 
 	var plans []componentPlan
 	plans = make([]componentPlan, 0, len(selectedComponents))
+	refs := make(datasourceRefs)
+	// Merged files and conflicts are only written once the push passed its gates.
+	var merges []mergeWrite
 
 	for _, plan := range selectedComponents {
 		component := plan.Component
+		_, dangling := presetIssues.dangling[strings.ToLower(component.Name)]
+		detach := dangling && opts.PresetPolicy == PresetPolicyDetach
+		if detach {
+			component.PresetID = 0
+		}
 
 		existing, exists := componentCache.Get(component.Name)
 		componentPresets := presetsForComponent(component, presetMap)
 
+		if exists {
+			if drifted, ok := drift.Detect(lock, opts.SpaceID, existing); ok {
+				switch {
+				case opts.Merge:
+					merged, err := mergeWithTarget(opts.Files(), lock, opts.SpaceID, plan, existing, groupNames)
+					if err != nil {
+						report.Warnf(ctx, "Cannot merge %s: %v", component.Name, err)
+						result.DriftedComponents = append(result.DriftedComponents, drifted)
+						continue
+					}
+					if len(merged.Conflicts) > 0 {
+						report.Warnf(ctx, "Merge conflicts in %s: %s", component.Name, conflictPaths(merged.Conflicts))
+						if opts.DryRun {
+							report.Printf(ctx, "Dry run: would write conflicts for %s to %s", component.Name, conflictPath(plan))
+						} else {
+							merges = append(merges, mergeWrite{file: plan, target: existing, merged: merged, fields: drifted.Fields()})
+						}
+						result.DriftedComponents = append(result.DriftedComponents, drifted)
+						continue
+					}
+					component.Schema = merged.Schema
+					if opts.DryRun {
+						report.Printf(ctx, "Dry run: would merge target changes into %s (%s)", component.Name, drifted.Fields())
+					} else {
+						merges = append(merges, mergeWrite{file: plan, target: existing, merged: merged, fields: drifted.Fields()})
+					}
+					result.MergedComponents = append(result.MergedComponents, component.Name)
+				case opts.Force:
+					report.Warnf(ctx, "Overwriting drifted component %s (--force): %s", component.Name, drifted.Fields())
+				default:
+					report.Warnf(ctx, "Refusing to overwrite %s: target changed outside sbx since the last sync: %s", component.Name, drifted.Fields())
+					result.DriftedComponents = append(result.DriftedComponents, drifted)
+					continue
+				}
+			}
+		}
+
+		// Lint suppressions and other local-only properties are never sent to Storyblok.
+		component = schema.StripLocal(component)
+		changes := analyzeChanges(existing, exists, component, groupNames)
+		result.Changes = append(result.Changes, changes...)
+		refs.add(component)
+
 		if opts.DryRun {
-			logDryRun(component, exists, opts.SpaceID, len(componentPresets), groupCache.Has, tagCache.Has)
+			logDryRun(ctx, component, exists, opts.SpaceID, len(componentPresets), groupCache.Has, tagCache.Has)
+			for _, change := range changes {
+				report.Printf(ctx, "  - %s", change)
+			}
+			if opts.UploadImages {
+				result.ImagesUploaded += logDryRunImages(ctx, componentPresets, localImages, opts.SpaceID)
+			}
+			if planner != nil {
+				planner.add(component, existing, exists, componentPresets, detach, changes, groupCache.Has, tagCache.Has)
+			}
 			result.ComponentsSynced++
 			result.PresetsSynced += len(componentPresets)
 			continue
@@ -416,9 +665,58 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 			existing:  existing,
 			exists:    exists,
 			presets:   componentPresets,
+
+			detachPreset: detach,
 		})
 	}
 
+	if planner != nil && planner.prune {
+		result.Changes = append(result.Changes, planner.pruneMissing(ctx, target.Components, components)...)
+	}
+
+	if failOn != nil {
+		if blocking := schema.AtLeast(result.Changes, *failOn); len(blocking) > 0 {
+			for _, change := range blocking {
+				report.Warnf(ctx, "%s", change)
+			}
+			result.ExitCode = 1
+			return result, fmt.Errorf("push blocked by %d schema changes at or above %s (--fail-on %s)", len(blocking), *failOn, *failOn)
+		}
+	}
+
+	if opts.Protected && !opts.AllowDestructive {
+		switch {
+		case !opts.DryRun:
+			if err := refuseDestructive(ctx, opts.SpaceID, result.Changes); err != nil {
+				result.ExitCode = 1
+				return result, err
+			}
+		case planner == nil:
+			// A dry run writes nothing; MakePlan warns about the plans it makes itself.
+			if breaking := schema.AtLeast(result.Changes, schema.SeverityBreaking); len(breaking) > 0 {
+				report.Warnf(ctx, "Space %d is protected: pushing these %d breaking changes requires --allow-destructive", opts.SpaceID, len(breaking))
+			}
+		}
+	}
+
+	for _, merge := range merges {
+		path, err := merge.write(ctx, opts.Files(), lock, opts.SpaceID)
+		if err != nil {
+			result.ExitCode = 2
+			return result, err
+		}
+		if path != "" {
+			result.ConflictFiles = append(result.ConflictFiles, path)
+		}
+	}
+
+	if opts.WithDatasources && len(refs) > 0 {
+		if err := pushDatasources(ctx, client, opts, refs, &result); err != nil {
+			result.ExitCode = 2
+			return result, err
+		}
+	}
+
 	var created, updated []string
 
 	if !opts.DryRun && len(plans) > 0 {
@@ -428,65 +726,27 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 			groups:        groupCache,
 			tags:          tagCache,
 			components:    componentCache,
-			targetPresets: targetPresets,
-		}
-
-		workerCount := 4
-		if len(plans) < workerCount {
-			workerCount = len(plans)
-		}
-		if workerCount < 1 {
-			workerCount = 1
+			targetPresets: target.Presets,
 		}
-
-		jobs := make(chan componentPlan)
-		outcomes := make([]componentOutcome, len(plans))
-		var outcomeMu sync.Mutex
-		egWorkers, egCtx := errgroup.WithContext(ctx)
-
-		for i := 0; i < workerCount; i++ {
-			egWorkers.Go(func() error {
-				for {
-					select {
-					case <-egCtx.Done():
-						return egCtx.Err()
-					case job, ok := <-jobs:
-						if !ok {
-							return nil
-						}
-						outcome, err := processor.Process(egCtx, job)
-						if err != nil {
-							return err
-						}
-						outcomeMu.Lock()
-						outcomes[job.index] = outcome
-						outcomeMu.Unlock()
-						logSyncOutcome(outcome)
-					}
-				}
-			})
-		}
-
-		go func() {
-			defer close(jobs)
-			for _, job := range plans {
-				select {
-				case <-egCtx.Done():
-					return
-				case jobs <- job:
-				}
+		if opts.UploadImages {
+			processor.images = assets.NewTransfer(client, opts.SpaceID, lock)
+			processor.images.Local = localImages
+			processor.images.FS = opts.Files()
+			if err := processor.images.UseMapping(opts.Dir); err != nil {
+				result.ExitCode = 1
+				return result, err
 			}
-		}()
-
-		if err := egWorkers.Wait(); err != nil {
-			result.ExitCode = 2
-			return result, err
 		}
 
+		outcomes, err := processor.processAll(ctx, plans)
+		if processor.images != nil {
+			result.ImagesUploaded, result.ImagesReused = processor.images.Counts()
+		}
 		for _, outcome := range outcomes {
 			if outcome.name == "" {
 				continue
 			}
+			lock.Record(opts.SpaceID, outcome.component)
 			if outcome.created {
 				created = append(created, outcome.name)
 			} else if outcome.updated {
@@ -495,6 +755,20 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 			result.ComponentsSynced++
 			result.PresetsSynced += outcome.presets
 		}
+
+		// Record what was pushed and uploaded even when a later write failed, so the next
+		// push neither reports those components as drifted nor uploads the images again.
+		if saveErr := lock.Save(); saveErr != nil {
+			report.Warnf(ctx, "failed to record sync state in %s: %v", lock.Path(), saveErr)
+		}
+		if err != nil {
+			result.ExitCode = 2
+			return result, err
+		}
+	}
+
+	if len(result.DriftedComponents) > 0 {
+		result.ExitCode = 1
 	}
 
 	sort.Strings(created)
@@ -504,9 +778,9 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 	result.UpdatedComponents = updated
 	result.RateLimitRetries = counters.Status429.Load()
 	result.ServerErrorRetries = counters.Status5xx.Load()
-	result.Duration = time.Since(start)
+	result.Duration = opts.Since(start)
 
-	printPushSummary(result, opts)
+	printPushSummary(ctx, result, opts)
 
 	return result, nil
 }
@@ -523,13 +797,61 @@ type PresetFile struct {
 	Preset storyblok.ComponentPreset
 }
 
+// LoadComponentFiles discovers and parses the component files under dir.
+func LoadComponentFiles(ctx context.Context, dir string) ([]ComponentFile, error) {
+	return LoadComponentFilesFS(ctx, fsutil.OS, dir)
+}
+
+// LoadComponentFilesFS discovers and parses the component files under dir in fsys.
+func LoadComponentFilesFS(ctx context.Context, fsys fsutil.FS, dir string) ([]ComponentFile, error) {
+	files, err := discoverComponentFiles(Options{Env: engine.Env{FS: fsys}, Dir: dir})
+	if err != nil {
+		return nil, err
+	}
+	components, _, err := loadComponents(ctx, fsys, files)
+	return components, err
+}
+
+// LoadPresetFiles discovers and parses the preset files under dir.
+func LoadPresetFiles(ctx context.Context, dir string) ([]PresetFile, error) {
+	presets, _, err := discoverPresetFiles(ctx, Options{Dir: dir})
+	return presets, err
+}
+
+// analyzeChanges classifies how pushing component affects the target's current version.
+func analyzeChanges(existing storyblok.Component, exists bool, component storyblok.Component, groups schema.GroupNames) []schema.Change {
+	if !exists {
+		return schema.DiffComponents(nil, []storyblok.Component{component})
+	}
+	return schema.Diff(groups.Component(existing), groups.Component(component))
+}
+
+// ComponentPaths lists the candidate component files under dir without parsing them.
+func ComponentPaths(dir string) ([]string, error) {
+	return discoverComponentFiles(Options{Dir: dir})
+}
+
+// PresetPaths lists the candidate preset files under dir without parsing them.
+func PresetPaths(dir string) ([]string, error) {
+	return presetPaths(fsutil.OS, dir)
+}
+
+func presetPaths(fsys fsutil.FS, dir string) ([]string, error) {
+	return listJSONFiles(fsys, candidateDirs(fsys, dir, "presets"))
+}
+
 func discoverComponentFiles(opts Options) ([]string, error) {
-	dirs := candidateDirs(opts.Dir, "components")
+	fsys := opts.Files()
+	return listJSONFiles(fsys, candidateDirs(fsys, opts.Dir, "components"))
+}
+
+// listJSONFiles returns the sorted JSON files directly inside dirs, skipping conflict files.
+func listJSONFiles(fsys fsutil.FS, dirs []string) ([]string, error) {
 	set := make(map[string]struct{})
 	for _, dir := range dirs {
-		entries, err := os.ReadDir(dir)
+		entries, err := fsys.ReadDir(dir)
 		if err != nil {
-			if os.IsNotExist(err) {
+			if errors.Is(err, fs.ErrNotExist) {
 				continue
 			}
 			return nil, err
@@ -539,7 +861,7 @@ func discoverComponentFiles(opts Options) ([]string, error) {
 				continue
 			}
 			name := entry.Name()
-			if !strings.HasSuffix(name, ".json") {
+			if !strings.HasSuffix(name, ".json") || strings.HasSuffix(name, conflictSuffix) {
 				continue
 			}
 			path := filepath.Join(dir, name)
@@ -554,113 +876,105 @@ func discoverComponentFiles(opts Options) ([]string, error) {
 	return files, nil
 }
 
-func discoverPresetFiles(opts Options) ([]PresetFile, error) {
-	dirs := candidateDirs(opts.Dir, "presets")
+// discoverPresetFiles parses the preset files under opts.Dir. Files that cannot be used
+// are returned as diagnostics; component files sharing the base directory are ignored.
+func discoverPresetFiles(ctx context.Context, opts Options) ([]PresetFile, []diag.Diagnostic, error) {
+	fsys := opts.Files()
+	paths, err := presetPaths(fsys, opts.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
 	var presets []PresetFile
+	var skipped []diag.Diagnostic
 	var parseErrors []string
 	var missingFields []string
-	for _, dir := range dirs {
-		entries, err := os.ReadDir(dir)
+	for _, path := range paths {
+		data, err := fsys.ReadFile(path)
 		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return nil, err
+			return nil, nil, err
 		}
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
-			}
-			name := entry.Name()
-			if !strings.HasSuffix(name, ".json") {
-				continue
-			}
-			path := filepath.Join(dir, name)
-			var preset storyblok.ComponentPreset
-			if err := fsutil.ReadJSON(path, &preset); err != nil {
-				parseErrors = append(parseErrors, fmt.Sprintf("%s (%v)", filepath.Base(path), err))
-				continue
-			}
-			if preset.Name == "" || preset.Preset == nil {
-				missingFields = append(missingFields, filepath.Base(path))
+		var preset storyblok.ComponentPreset
+		if err := json.Unmarshal(data, &preset); err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("%s (%v)", filepath.Base(path), err))
+			skipped = append(skipped, diag.FromJSONError(path, data, err))
+			continue
+		}
+		if preset.Name == "" || preset.Preset == nil {
+			if hasKey(data, "schema") && !hasKey(data, "preset") {
 				continue
 			}
-			presets = append(presets, PresetFile{Path: path, Preset: preset})
+			missingFields = append(missingFields, filepath.Base(path))
+			skipped = append(skipped, diag.At(path, data, diag.SeverityError, "missing-fields", nil, "preset file is missing name or preset"))
+			continue
 		}
+		presets = append(presets, PresetFile{Path: path, Preset: preset})
 	}
-	sort.Slice(presets, func(i, j int) bool { return presets[i].Path < presets[j].Path })
 	if len(parseErrors) > 0 {
-		warnf("Skipped %d preset files with parse errors: %s", len(parseErrors), summarizeList(parseErrors, 3))
+		report.Warnf(ctx, "Skipped %d preset files with parse errors: %s", len(parseErrors), summarizeList(parseErrors, 3))
 	}
 	if len(missingFields) > 0 {
-		warnf("Skipped %d preset files missing name/preset: %s", len(missingFields), summarizeList(missingFields, 3))
+		report.Warnf(ctx, "Skipped %d preset files missing name/preset: %s", len(missingFields), summarizeList(missingFields, 3))
 	}
-	return presets, nil
+	return presets, skipped, nil
 }
 
-func loadComponents(files []string) ([]ComponentFile, error) {
+// loadComponents parses component files. Files that cannot be used are returned as
+// diagnostics; preset files sharing the base directory are ignored.
+func loadComponents(ctx context.Context, fsys fsutil.FS, files []string) ([]ComponentFile, []diag.Diagnostic, error) {
 	components := make([]ComponentFile, 0, len(files))
+	var skipped []diag.Diagnostic
 	var parseErrors []string
 	var missingFields []string
 	for _, path := range files {
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
 		var comp storyblok.Component
-		if err := fsutil.ReadJSON(path, &comp); err != nil {
+		if err := json.Unmarshal(data, &comp); err != nil {
 			parseErrors = append(parseErrors, fmt.Sprintf("%s (%v)", filepath.Base(path), err))
+			skipped = append(skipped, diag.FromJSONError(path, data, err))
 			continue
 		}
 		if comp.Name == "" || comp.Schema == nil {
+			if hasKey(data, "preset") && !hasKey(data, "schema") {
+				continue
+			}
 			missingFields = append(missingFields, filepath.Base(path))
+			skipped = append(skipped, diag.At(path, data, diag.SeverityError, "missing-fields", nil, "component file is missing name or schema"))
 			continue
 		}
 		components = append(components, ComponentFile{Path: path, Component: comp})
 	}
 	if len(parseErrors) > 0 {
-		warnf("Skipped %d component files with parse errors: %s", len(parseErrors), summarizeList(parseErrors, 3))
+		report.Warnf(ctx, "Skipped %d component files with parse errors: %s", len(parseErrors), summarizeList(parseErrors, 3))
 	}
 	if len(missingFields) > 0 {
-		warnf("Skipped %d component files missing name/schema: %s", len(missingFields), summarizeList(missingFields, 3))
+		report.Warnf(ctx, "Skipped %d component files missing name/schema: %s", len(missingFields), summarizeList(missingFields, 3))
 	}
-	return components, nil
+	return components, skipped, nil
 }
 
-func candidateDirs(base, sub string) []string {
+// hasKey reports whether the JSON object in data has key at the top level.
+func hasKey(data []byte, key string) bool {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return false
+	}
+	_, ok := raw[key]
+	return ok
+}
+
+func candidateDirs(fsys fsutil.FS, base, sub string) []string {
 	dirs := make([]string, 0, 2)
 	subPath := filepath.Join(base, sub)
-	if info, err := os.Stat(subPath); err == nil && info.IsDir() {
+	if info, err := fsys.Stat(subPath); err == nil && info.IsDir() {
 		dirs = append(dirs, subPath)
 	}
 	dirs = append(dirs, base)
 	return dirs
 }
 
-func enableColor() bool {
-	if _, ok := os.LookupEnv("NO_COLOR"); ok {
-		return false
-	}
-	return true
-}
-
-func infof(format string, args ...any) {
-	logWithColor(colorInfo, format, args...)
-}
-
-func warnf(format string, args ...any) {
-	logWithColor(colorWarn, format, args...)
-}
-
-func successf(format string, args ...any) {
-	logWithColor(colorSuccess, format, args...)
-}
-
-func logWithColor(color, format string, args ...any) {
-	message := fmt.Sprintf(format, args...)
-	if useColor {
-		fmt.Fprintf(os.Stderr, "%s%s%s\n", color, message, colorReset)
-		return
-	}
-	fmt.Fprintf(os.Stderr, "%s\n", message)
-}
-
 func buildPresetMap(presets []PresetFile) map[string][]storyblok.ComponentPreset {
 	presetsByComponent := make(map[string][]storyblok.ComponentPreset)
 	for _, preset := range presets {
@@ -674,6 +988,41 @@ func buildPresetMap(presets []PresetFile) map[string][]storyblok.ComponentPreset
 	return presetsByComponent
 }
 
+// orphanPresets reports presets that belong to no local component and would be dropped.
+func orphanPresets(fsys fsutil.FS, components []ComponentFile, presets []PresetFile) []diag.Diagnostic {
+	known := make(map[string]struct{}, len(components))
+	for _, component := range components {
+		known[strings.ToLower(component.Component.Name)] = struct{}{}
+	}
+	var orphans []diag.Diagnostic
+	for _, preset := range presets {
+		// The file was read moments ago; positions fall back to 1:1 if it vanished since.
+		data, _ := fsys.ReadFile(preset.Path)
+		compName, _ := preset.Preset.Preset["component"].(string)
+		if compName == "" {
+			orphans = append(orphans, diag.At(preset.Path, data, diag.SeverityError, "orphan-preset", []string{"preset"}, "preset %q has no preset.component", preset.Preset.Name))
+			continue
+		}
+		if _, ok := known[strings.ToLower(compName)]; !ok {
+			orphans = append(orphans, diag.At(preset.Path, data, diag.SeverityError, "orphan-preset", []string{"preset", "component"}, "preset %q belongs to component %q, which has no local file", preset.Preset.Name, compName))
+		}
+	}
+	return orphans
+}
+
+func missingSelectorDiagnostics(dir string, missing []string) []diag.Diagnostic {
+	diagnostics := make([]diag.Diagnostic, 0, len(missing))
+	for _, selector := range missing {
+		diagnostics = append(diagnostics, diag.Diagnostic{
+			File:     dir,
+			Severity: diag.SeverityError,
+			Code:     "missing-selector",
+			Message:  fmt.Sprintf("no component file matches %q", selector),
+		})
+	}
+	return diagnostics
+}
+
 func presetsForComponent(component storyblok.Component, presetMap map[string][]storyblok.ComponentPreset) []storyblok.ComponentPreset {
 	name := strings.ToLower(component.Name)
 	return presetMap[name]
@@ -780,16 +1129,16 @@ func ensureInternalTags(ctx context.Context, client *storyblok.Client, spaceID i
 	return ids, nil
 }
 
-func logDryRun(component storyblok.Component, exists bool, spaceID int, presetCount int, hasGroup func(string) bool, hasTag func(string) bool) {
+func logDryRun(ctx context.Context, component storyblok.Component, exists bool, spaceID int, presetCount int, hasGroup func(string) bool, hasTag func(string) bool) {
 	action := "create"
 	if exists {
 		action = "update"
 	}
-	fmt.Printf("Dry run: %s component %s in space %d (%d presets)\n", action, component.Name, spaceID, presetCount)
+	report.Printf(ctx, "Dry run: %s component %s in space %d (%d presets)", action, component.Name, spaceID, presetCount)
 
 	if component.ComponentGroupName != "" {
 		if !hasGroup(component.ComponentGroupName) {
-			fmt.Printf("  - would create component group %q\n", component.ComponentGroupName)
+			report.Printf(ctx, "  - would create component group %q", component.ComponentGroupName)
 		}
 	}
 
@@ -805,7 +1154,7 @@ func logDryRun(component storyblok.Component, exists bool, spaceID int, presetCo
 			}
 		}
 		if len(missing) > 0 {
-			fmt.Printf("  - would create internal tags: %s\n", strings.Join(missing, ", "))
+			report.Printf(ctx, "  - would create internal tags: %s", strings.Join(missing, ", "))
 		}
 	}
 }
@@ -870,10 +1219,11 @@ func findPresetByName(presets []storyblok.ComponentPreset, name string) (storybl
 	return storyblok.ComponentPreset{}, false
 }
 
-func updateComponent(ctx context.Context, client *storyblok.Client, spaceID int, existing storyblok.Component, updated storyblok.Component, presets []storyblok.ComponentPreset, targetPresets []storyblok.ComponentPreset) (storyblok.Component, error) {
+func updateComponent(ctx context.Context, client *storyblok.Client, spaceID int, existing storyblok.Component, updated storyblok.Component, presets []storyblok.ComponentPreset, targetPresets []storyblok.ComponentPreset, detachPreset bool) (storyblok.Component, error) {
 	defaultName := defaultPresetName(updated, presets)
 	updated.ID = existing.ID
 	updated.PresetID = 0
+	updated.ClearPreset = detachPreset && existing.PresetID != 0
 
 	resultComponent, err := client.UpdateComponent(ctx, spaceID, existing.ID, updated)
 	if err != nil {
@@ -923,23 +1273,33 @@ func updateComponent(ctx context.Context, client *storyblok.Client, spaceID int,
 	return resultComponent, nil
 }
 
-func printPushSummary(result Result, opts Options) {
+func printPushSummary(ctx context.Context, result Result, opts Options) {
 	if opts.DryRun {
-		fmt.Println()
-		fmt.Printf("Dry run summary: %d components, %d presets (rate-limit retries: %d, server retries: %d)\n",
+		report.Printf(ctx, "")
+		report.Printf(ctx, "Dry run summary: %d components, %d presets (rate-limit retries: %d, server retries: %d)",
 			result.ComponentsSynced,
 			result.PresetsSynced,
 			result.RateLimitRetries,
 			result.ServerErrorRetries,
 		)
+		if opts.Revision != "" {
+			report.Printf(ctx, "Source: commit %s", opts.Revision)
+		}
+		if opts.UploadImages {
+			report.Printf(ctx, "Preset images to transfer: %d", result.ImagesUploaded)
+		}
+		if opts.WithDatasources {
+			printDatasourceSummary(ctx, result.Datasources, result.MissingDatasources)
+		}
+		printDriftSummary(ctx, result.DriftedComponents)
 		if len(result.MissingSelectors) > 0 {
-			fmt.Fprintf(os.Stderr, "Missing components matching: %s\n", strings.Join(result.MissingSelectors, ", "))
+			report.Errorf(ctx, "Missing components matching: %s", strings.Join(result.MissingSelectors, ", "))
 		}
 		return
 	}
 
-	fmt.Println()
-	fmt.Printf("Pushed %d components and %d presets to space %d in %s (rate-limit retries: %d, server retries: %d)\n",
+	report.Printf(ctx, "")
+	report.Printf(ctx, "Pushed %d components and %d presets to space %d in %s (rate-limit retries: %d, server retries: %d)",
 		result.ComponentsSynced,
 		result.PresetsSynced,
 		opts.SpaceID,
@@ -947,14 +1307,61 @@ func printPushSummary(result Result, opts Options) {
 		result.RateLimitRetries,
 		result.ServerErrorRetries,
 	)
+	if opts.Revision != "" {
+		report.Printf(ctx, "  Source: commit %s", opts.Revision)
+	}
 	if len(result.CreatedComponents) > 0 {
-		fmt.Printf("  Created: %s\n", strings.Join(result.CreatedComponents, ", "))
+		report.Printf(ctx, "  Created: %s", strings.Join(result.CreatedComponents, ", "))
 	}
 	if len(result.UpdatedComponents) > 0 {
-		fmt.Printf("  Updated: %s\n", strings.Join(result.UpdatedComponents, ", "))
+		report.Printf(ctx, "  Updated: %s", strings.Join(result.UpdatedComponents, ", "))
+	}
+	if len(result.MergedComponents) > 0 {
+		report.Printf(ctx, "  Merged: %s", strings.Join(result.MergedComponents, ", "))
+	}
+	if opts.UploadImages {
+		report.Printf(ctx, "  Preset images: %d uploaded, %d reused", result.ImagesUploaded, result.ImagesReused)
+	}
+	if opts.WithDatasources {
+		printDatasourceSummary(ctx, result.Datasources, result.MissingDatasources)
+	}
+	printDriftSummary(ctx, result.DriftedComponents)
+	for _, path := range result.ConflictFiles {
+		report.Errorf(ctx, "  Resolve conflicts in %s", path)
 	}
 	if len(result.MissingSelectors) > 0 {
-		fmt.Fprintf(os.Stderr, "Missing components matching: %s\n", strings.Join(result.MissingSelectors, ", "))
+		report.Errorf(ctx, "Missing components matching: %s", strings.Join(result.MissingSelectors, ", "))
+	}
+}
+
+// refuseDestructive reports the breaking changes to a protected space, which include
+// deleted components and renamed fields, and returns an error when there are any.
+func refuseDestructive(ctx context.Context, spaceID int, changes []schema.Change) error {
+	destructive := schema.AtLeast(changes, schema.SeverityBreaking)
+	if len(destructive) == 0 {
+		return nil
+	}
+	for _, change := range destructive {
+		report.Warnf(ctx, "%s", change)
+	}
+	return fmt.Errorf("space %d is protected: refusing %d breaking changes without --allow-destructive", spaceID, len(destructive))
+}
+
+// atRevision describes the commit files were read from, for appending to their path.
+func atRevision(revision string) string {
+	if revision == "" {
+		return ""
+	}
+	return " at commit " + revision
+}
+
+func printDriftSummary(ctx context.Context, drifted []drift.ComponentDrift) {
+	if len(drifted) == 0 {
+		return
+	}
+	report.Errorf(ctx, "Skipped %d components changed in the target since the last sync (use --merge or --force):", len(drifted))
+	for _, entry := range drifted {
+		report.Errorf(ctx, "  - %s: %s", entry.Name, entry.Fields())
 	}
 }
 
@@ -0,0 +1,185 @@
+package stories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/storyblok"
+)
+
+// PullOptions configures a story export.
+type PullOptions struct {
+	Token   string
+	SpaceID int
+	Filter  Filter
+	OutDir  string
+	DryRun  bool
+}
+
+// PullResult summarises a story export.
+type PullResult struct {
+	ExitCode         int
+	Stories          int
+	Folders          int
+	Duration         time.Duration
+	RateLimitRetries int64
+}
+
+// Pull exports the matching stories with their content into OutDir/stories, together with
+// the folders above them so the hierarchy can be recreated elsewhere.
+func Pull(ctx context.Context, opts PullOptions) (PullResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := PullResult{ExitCode: 0}
+	start := time.Now()
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	var listed, folders []storyblok.Story
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		list, err := client.ListStories(egCtx, opts.SpaceID, storyblok.StoryFilter{
+			ContainComponent: opts.Filter.ContentType,
+			StartsWith:       strings.TrimPrefix(opts.Filter.StartsWith, "/"),
+			WithTag:          opts.Filter.Tag,
+		})
+		if err != nil {
+			return err
+		}
+		listed = list
+		return nil
+	})
+	eg.Go(func() error {
+		list, err := client.ListStories(egCtx, opts.SpaceID, storyblok.StoryFilter{FolderOnly: true})
+		if err != nil {
+			return err
+		}
+		folders = list
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to list stories from Storyblok: %v\n", err)
+		result.ExitCode = 2
+		return result, err
+	}
+
+	// Listings omit content, so each story is loaded to apply the content type filter and
+	// to export it in full.
+	candidates := make([]storyblok.Story, 0, len(listed))
+	for _, story := range listed {
+		if !story.IsFolder {
+			candidates = append(candidates, story)
+		}
+	}
+	loaded := make([]storyblok.Story, len(candidates))
+	eg, egCtx = errgroup.WithContext(ctx)
+	eg.SetLimit(4)
+	for i, story := range candidates {
+		eg.Go(func() error {
+			full, err := client.GetStory(egCtx, opts.SpaceID, story.ID)
+			if err != nil {
+				return fmt.Errorf("load %s: %w", story.FullSlug, err)
+			}
+			loaded[i] = full
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+
+	var selected []storyblok.Story
+	for _, story := range loaded {
+		if opts.Filter.matches(story) {
+			selected = append(selected, story)
+		}
+	}
+	selectedFolders := ancestorFolders(folders, selected, opts.Filter)
+
+	dir := Dir(opts.OutDir)
+	if opts.DryRun {
+		fmt.Printf("Dry run: exporting stories from space %d\n", opts.SpaceID)
+	}
+	for _, story := range append(selectedFolders, selected...) {
+		path := storyPath(dir, story)
+		kind := "story"
+		if story.IsFolder {
+			kind = "folder"
+		}
+		if opts.DryRun {
+			verb := "create"
+			if exists, _ := fsutil.Exists(path); exists {
+				verb = "overwrite"
+			}
+			fmt.Printf("  - %s %s -> %s (%s)\n", kind, story.FullSlug, path, verb)
+			continue
+		}
+		if err := fsutil.WriteJSON(path, story, 0); err != nil {
+			result.ExitCode = 2
+			return result, err
+		}
+		fmt.Printf("Saved %s %s to %s\n", kind, story.FullSlug, path)
+	}
+
+	result.Stories = len(selected)
+	result.Folders = len(selectedFolders)
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+
+	fmt.Println()
+	if opts.DryRun {
+		fmt.Printf("Dry run summary: %d stories, %d folders (rate-limit retries: %d)\n",
+			result.Stories, result.Folders, result.RateLimitRetries)
+	} else {
+		fmt.Printf("Pulled %d stories and %d folders from space %d in %s (rate-limit retries: %d)\n",
+			result.Stories,
+			result.Folders,
+			opts.SpaceID,
+			result.Duration.Truncate(time.Millisecond),
+			result.RateLimitRetries,
+		)
+	}
+	return result, nil
+}
+
+// ancestorFolders keeps the folders containing a selected story. Without a content type
+// or tag filter, every folder below the starts_with path is kept as well, so empty
+// folders survive a round trip.
+func ancestorFolders(folders, selected []storyblok.Story, filter Filter) []storyblok.Story {
+	needed := make(map[string]struct{})
+	for _, story := range selected {
+		for slug := parentSlug(story); slug != ""; {
+			needed[folderKey(slug)] = struct{}{}
+			i := strings.LastIndex(slug, "/")
+			if i < 0 {
+				break
+			}
+			slug = slug[:i]
+		}
+	}
+	keepAll := filter.StartsWith != "" && filter.ContentType == "" && filter.Tag == ""
+
+	var out []storyblok.Story
+	for _, folder := range folders {
+		folder.IsFolder = true
+		_, ok := needed[key(folder)]
+		if ok || (keepAll && filter.matches(folder)) {
+			out = append(out, folder)
+		}
+	}
+	return out
+}
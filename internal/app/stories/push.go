@@ -0,0 +1,400 @@
+package stories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"sbx/internal/app/assets"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// PushOptions configures a story import.
+type PushOptions struct {
+	Token   string
+	SpaceID int
+	Dir     string
+	Filter  Filter
+	// Publish publishes imported stories that were published in the export.
+	Publish bool
+	DryRun  bool
+}
+
+// PushResult summarises a story import.
+type PushResult struct {
+	ExitCode           int
+	FoldersCreated     int
+	StoriesCreated     int
+	StoriesUpdated     int
+	StoriesPublished   int
+	LinksRemapped      int
+	AssetsUploaded     int
+	AssetsReused       int
+	Duration           time.Duration
+	RateLimitRetries   int64
+	ServerErrorRetries int64
+}
+
+// readOnlyFields are story attributes the API computes; they are not sent back on import.
+var readOnlyFields = []string{
+	"alternates", "breadcrumbs", "content_type", "created_at", "deleted_at",
+	"first_published_at", "group_id", "last_author", "last_author_id", "parent",
+	"published_at", "unpublished_changes", "updated_at",
+}
+
+// importer carries the ID and UUID mappings between the exported space and the target.
+type importer struct {
+	client   *storyblok.Client
+	opts     PushOptions
+	transfer *assets.Transfer
+	target   map[string]storyblok.Story
+	ids      map[int]int
+	uuids    map[string]string
+	exported map[string]struct{}
+	// pending keeps the content saved by the first pass for stories whose references must
+	// be fixed in the second, so their assets are not copied again.
+	pending map[string]map[string]any
+	result  *PushResult
+}
+
+// Push imports exported stories into the target space. Folders are matched or created by
+// full_slug first; stories are then created or updated with parent folder IDs, story
+// UUID references and asset references rewritten for the target.
+func Push(ctx context.Context, opts PushOptions) (PushResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result := PushResult{ExitCode: 0}
+	start := time.Now()
+
+	tree, err := LoadTree(opts.Dir)
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+	var folders, selected []LocalStory
+	for _, local := range tree {
+		if local.Story.IsFolder {
+			folders = append(folders, local)
+		} else if opts.Filter.matches(local.Story) {
+			selected = append(selected, local)
+		}
+	}
+	if len(selected) == 0 {
+		result.ExitCode = 1
+		return result, fmt.Errorf("no exported stories in %s match the filter", Dir(opts.Dir))
+	}
+
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	client := storyblok.NewClient(opts.Token, storyblok.WithLimiter(lim))
+
+	counters := &storyblok.RetryCounters{}
+	ctx = storyblok.WithRetryCounters(ctx, counters)
+
+	existing, err := client.ListStories(ctx, opts.SpaceID, storyblok.StoryFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to list target stories: %v\n", err)
+		result.ExitCode = 2
+		return result, err
+	}
+
+	lock, err := state.Load(opts.Dir)
+	if err != nil {
+		return result, err
+	}
+
+	transfer := assets.NewTransfer(client, opts.SpaceID, lock)
+	if err := transfer.UseMapping(opts.Dir); err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+
+	imp := &importer{
+		client:   client,
+		opts:     opts,
+		transfer: transfer,
+		target:   make(map[string]storyblok.Story, len(existing)),
+		ids:      make(map[int]int),
+		uuids:    make(map[string]string),
+		exported: make(map[string]struct{}),
+		pending:  make(map[string]map[string]any),
+		result:   &result,
+	}
+	for _, story := range existing {
+		imp.target[key(story)] = story
+	}
+	for _, local := range tree {
+		if local.Story.UUID != "" {
+			imp.exported[local.Story.UUID] = struct{}{}
+		}
+	}
+
+	if opts.DryRun {
+		fmt.Printf("Dry run: importing stories into space %d\n", opts.SpaceID)
+	}
+	err = imp.run(ctx, neededFolders(folders, selected), selected)
+
+	result.AssetsUploaded, result.AssetsReused = imp.transfer.Counts()
+	if result.AssetsUploaded > 0 {
+		if saveErr := lock.Save(); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "failed to record uploaded assets in %s: %v\n", lock.Path(), saveErr)
+		}
+	}
+	result.Duration = time.Since(start)
+	result.RateLimitRetries = counters.Status429.Load()
+	result.ServerErrorRetries = counters.Status5xx.Load()
+	if err != nil {
+		result.ExitCode = 2
+		return result, err
+	}
+
+	printPushSummary(result, opts)
+	return result, nil
+}
+
+func (imp *importer) run(ctx context.Context, folders, stories []LocalStory) error {
+	for _, local := range folders {
+		if err := imp.folder(ctx, local); err != nil {
+			return err
+		}
+	}
+
+	// Stories are saved in two passes: the first creates them so that every exported UUID
+	// has a counterpart, the second fixes references to stories created later in the run.
+	var pending []LocalStory
+	for _, local := range stories {
+		unresolved, err := imp.story(ctx, local, false)
+		if err != nil {
+			return err
+		}
+		if unresolved {
+			pending = append(pending, local)
+		}
+	}
+	for _, local := range pending {
+		if _, err := imp.story(ctx, local, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *importer) folder(ctx context.Context, local LocalStory) error {
+	source := local.Story
+	if existing, ok := imp.target[key(source)]; ok {
+		imp.mapStory(source, existing)
+		return nil
+	}
+
+	parentID, err := imp.parentID(source)
+	if err != nil {
+		return err
+	}
+	imp.result.FoldersCreated++
+	if imp.opts.DryRun {
+		fmt.Printf("  - create folder %s\n", source.FullSlug)
+		return nil
+	}
+
+	folder := prepare(source, parentID)
+	folder.IsFolder = true
+	created, err := imp.client.CreateStory(ctx, imp.opts.SpaceID, folder, false)
+	if err != nil {
+		return fmt.Errorf("create folder %s: %w", source.FullSlug, err)
+	}
+	imp.mapStory(source, created)
+	imp.target[key(source)] = created
+	fmt.Printf("Created folder %s (id=%d)\n", source.FullSlug, created.ID)
+	return nil
+}
+
+// story creates or updates one story and reports whether it still references exported
+// stories that have no counterpart in the target yet. The second pass only rewrites those
+// references in stories saved by the first.
+func (imp *importer) story(ctx context.Context, local LocalStory, second bool) (bool, error) {
+	source := local.Story
+	existing, exists := imp.target[key(source)]
+	parentID, err := imp.parentID(source)
+	if err != nil {
+		return false, err
+	}
+
+	story := prepare(source, parentID)
+	if second {
+		story.Content = imp.pending[key(source)]
+	} else {
+		story.Content = cloneContent(source.Content)
+	}
+	replaced, links, unresolved := remapUUIDs(story.Content, imp.exported, imp.uuids)
+	story.Content, _ = replaced.(map[string]any)
+	publish := imp.opts.Publish && source.Published
+
+	if imp.opts.DryRun {
+		verb := "create"
+		if exists {
+			verb = "update"
+		}
+		details := []string{}
+		if urls := assetURLs(story.Content, imp.transfer.Needed); len(urls) > 0 {
+			details = append(details, fmt.Sprintf("%d assets to copy", len(urls)))
+		}
+		if publish {
+			details = append(details, "publish")
+		}
+		suffix := ""
+		if len(details) > 0 {
+			suffix = " (" + strings.Join(details, ", ") + ")"
+		}
+		fmt.Printf("  - %s story %s%s\n", verb, source.FullSlug, suffix)
+		imp.count(exists, publish)
+		return false, nil
+	}
+
+	if !second {
+		if _, err := remapAssets(ctx, imp.transfer, story.Content); err != nil {
+			return false, fmt.Errorf("copy assets of %s: %w", source.FullSlug, err)
+		}
+	}
+
+	var saved storyblok.Story
+	if exists {
+		story.ID = existing.ID
+		saved, err = imp.client.UpdateStory(ctx, imp.opts.SpaceID, story, publish)
+		if err != nil {
+			return false, fmt.Errorf("update %s: %w", source.FullSlug, err)
+		}
+		if saved.ID == 0 {
+			saved = existing
+		}
+	} else {
+		saved, err = imp.client.CreateStory(ctx, imp.opts.SpaceID, story, publish)
+		if err != nil {
+			return false, fmt.Errorf("create %s: %w", source.FullSlug, err)
+		}
+		imp.target[key(source)] = saved
+	}
+
+	imp.mapStory(source, saved)
+	imp.result.LinksRemapped += links
+	if second {
+		fmt.Printf("Remapped references in %s (id=%d)\n", source.FullSlug, saved.ID)
+		return false, nil
+	}
+	if unresolved {
+		imp.pending[key(source)] = story.Content
+	}
+	imp.count(exists, publish)
+	verb := "Created"
+	if exists {
+		verb = "Updated"
+	}
+	fmt.Printf("%s story %s (id=%d)\n", verb, source.FullSlug, saved.ID)
+	return unresolved, nil
+}
+
+func (imp *importer) count(updated, published bool) {
+	if updated {
+		imp.result.StoriesUpdated++
+	} else {
+		imp.result.StoriesCreated++
+	}
+	if published {
+		imp.result.StoriesPublished++
+	}
+}
+
+func (imp *importer) mapStory(source, target storyblok.Story) {
+	if source.ID != 0 && target.ID != 0 {
+		imp.ids[source.ID] = target.ID
+	}
+	if source.UUID != "" && target.UUID != "" {
+		imp.uuids[source.UUID] = target.UUID
+	}
+}
+
+// parentID finds the target folder of a story, by the exported parent_id when that folder
+// was imported and by the parent's full_slug otherwise.
+func (imp *importer) parentID(story storyblok.Story) (int, error) {
+	if id, ok := imp.ids[story.ParentID]; ok {
+		return id, nil
+	}
+	slug := parentSlug(story)
+	if slug == "" {
+		return 0, nil
+	}
+	if folder, ok := imp.target[folderKey(slug)]; ok {
+		return folder.ID, nil
+	}
+	if imp.opts.DryRun {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%s: folder %s exists neither in the export nor in the target", story.FullSlug, slug)
+}
+
+// prepare strips IDs and computed attributes so the story can be written to the target.
+func prepare(source storyblok.Story, parentID int) storyblok.Story {
+	story := source
+	story.ID = 0
+	story.UUID = ""
+	story.FullSlug = ""
+	story.ParentID = parentID
+	story.Extras = make(map[string]any, len(source.Extras))
+	for k, v := range source.Extras {
+		story.Extras[k] = v
+	}
+	for _, field := range readOnlyFields {
+		delete(story.Extras, field)
+	}
+	if parentID == 0 {
+		// parent_id is omitted when zero; send null so root stories stay at the root.
+		story.Extras["parent_id"] = nil
+	}
+	return story
+}
+
+// neededFolders keeps the exported folders that contain at least one selected story.
+func neededFolders(folders, selected []LocalStory) []LocalStory {
+	needed := make(map[string]struct{})
+	for _, local := range selected {
+		for slug := parentSlug(local.Story); slug != ""; {
+			needed[folderKey(slug)] = struct{}{}
+			i := strings.LastIndex(slug, "/")
+			if i < 0 {
+				break
+			}
+			slug = slug[:i]
+		}
+	}
+	var out []LocalStory
+	for _, local := range folders {
+		if _, ok := needed[key(local.Story)]; ok {
+			out = append(out, local)
+		}
+	}
+	return out
+}
+
+func printPushSummary(result PushResult, opts PushOptions) {
+	fmt.Println()
+	if opts.DryRun {
+		fmt.Printf("Dry run summary: %d folders and %d stories to create, %d stories to update, %d to publish (rate-limit retries: %d)\n",
+			result.FoldersCreated, result.StoriesCreated, result.StoriesUpdated, result.StoriesPublished, result.RateLimitRetries)
+		return
+	}
+	fmt.Printf("Imported %d stories into space %d in %s (rate-limit retries: %d, server retries: %d)\n",
+		result.StoriesCreated+result.StoriesUpdated,
+		opts.SpaceID,
+		result.Duration.Truncate(time.Millisecond),
+		result.RateLimitRetries,
+		result.ServerErrorRetries,
+	)
+	fmt.Printf("  Created: %d folders, %d stories; updated: %d stories; published: %d\n",
+		result.FoldersCreated, result.StoriesCreated, result.StoriesUpdated, result.StoriesPublished)
+	fmt.Printf("  Remapped %d story references; assets: %d uploaded, %d reused\n",
+		result.LinksRemapped, result.AssetsUploaded, result.AssetsReused)
+}
@@ -0,0 +1,121 @@
+package stories
+
+import (
+	"context"
+
+	"sbx/internal/app/assets"
+)
+
+// remapUUIDs replaces every string in value that is the UUID of an exported story with the
+// UUID of its copy in the target. This covers story links, multi-option story references
+// and single-story fields, which all store the referenced story's UUID. It reports how many
+// references were rewritten and whether any exported UUID is still unmapped.
+func remapUUIDs(value any, exported map[string]struct{}, mapped map[string]string) (any, int, bool) {
+	switch v := value.(type) {
+	case string:
+		if _, ok := exported[v]; !ok {
+			return v, 0, false
+		}
+		if target, ok := mapped[v]; ok {
+			return target, 1, false
+		}
+		return v, 0, true
+	case map[string]any:
+		count, pending := 0, false
+		for key, child := range v {
+			if key == "_uid" {
+				continue
+			}
+			replaced, n, p := remapUUIDs(child, exported, mapped)
+			v[key] = replaced
+			count += n
+			pending = pending || p
+		}
+		return v, count, pending
+	case []any:
+		count, pending := 0, false
+		for i, child := range v {
+			replaced, n, p := remapUUIDs(child, exported, mapped)
+			v[i] = replaced
+			count += n
+			pending = pending || p
+		}
+		return v, count, pending
+	}
+	return value, 0, false
+}
+
+// assetRef is an object in story content that points at an asset URL under urlKey and
+// may carry the asset's ID under "id".
+type assetRef struct {
+	node   map[string]any
+	urlKey string
+}
+
+// collectAssets finds asset fields ({"fieldtype": "asset", "filename": ...}), including
+// those inside multi-asset lists, and image nodes in richtext.
+func collectAssets(value any, refs *[]assetRef) {
+	switch v := value.(type) {
+	case map[string]any:
+		if fieldType, _ := v["fieldtype"].(string); fieldType == "asset" {
+			if filename, _ := v["filename"].(string); filename != "" {
+				*refs = append(*refs, assetRef{node: v, urlKey: "filename"})
+			}
+		}
+		if nodeType, _ := v["type"].(string); nodeType == "image" {
+			if attrs, ok := v["attrs"].(map[string]any); ok {
+				if src, _ := attrs["src"].(string); src != "" {
+					*refs = append(*refs, assetRef{node: attrs, urlKey: "src"})
+				}
+			}
+		}
+		for _, child := range v {
+			collectAssets(child, refs)
+		}
+	case []any:
+		for _, child := range v {
+			collectAssets(child, refs)
+		}
+	}
+}
+
+// assetURLs lists the asset URLs in content that are not yet in the target space.
+func assetURLs(content map[string]any, transfer func(string) bool) []string {
+	var refs []assetRef
+	collectAssets(content, &refs)
+	var urls []string
+	for _, ref := range refs {
+		url, _ := ref.node[ref.urlKey].(string)
+		if transfer(url) {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// remapAssets copies every asset referenced by content into the target space and points
+// the reference at the copy. It returns how many references were rewritten.
+func remapAssets(ctx context.Context, transfer *assets.Transfer, content map[string]any) (int, error) {
+	var refs []assetRef
+	collectAssets(content, &refs)
+	count := 0
+	for _, ref := range refs {
+		url, _ := ref.node[ref.urlKey].(string)
+		if !transfer.Needed(url) {
+			continue
+		}
+		asset, err := transfer.Copy(ctx, url)
+		if err != nil {
+			return count, err
+		}
+		ref.node[ref.urlKey] = asset.URL
+		if asset.ID != 0 {
+			ref.node["id"] = asset.ID
+		} else if _, ok := ref.node["id"]; ok {
+			// The source ID means nothing in the target.
+			ref.node["id"] = nil
+		}
+		count++
+	}
+	return count, nil
+}
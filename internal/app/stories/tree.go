@@ -0,0 +1,179 @@
+package stories
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/storyblok"
+)
+
+// SubDir is the directory below the schema directory that holds exported stories.
+const SubDir = "stories"
+
+// Folders are stored as <full_slug>/FolderFile and folder start pages as
+// <full_slug>/StartpageFile; every other story is stored as <full_slug>.json.
+const (
+	FolderFile    = "_folder.json"
+	StartpageFile = "_index.json"
+)
+
+// Filter narrows the stories a command works on. Zero values match everything.
+type Filter struct {
+	StartsWith  string
+	ContentType string
+	Tag         string
+}
+
+// matches reports whether a story with loaded content passes the filter.
+func (f Filter) matches(story storyblok.Story) bool {
+	if f.StartsWith != "" && !strings.HasPrefix(strings.ToLower(story.FullSlug), strings.ToLower(strings.TrimPrefix(f.StartsWith, "/"))) {
+		return false
+	}
+	if f.ContentType != "" {
+		component, _ := story.Content["component"].(string)
+		if !strings.EqualFold(component, f.ContentType) {
+			return false
+		}
+	}
+	if f.Tag != "" {
+		found := false
+		for _, tag := range story.TagList {
+			if strings.EqualFold(tag, f.Tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// LocalStory couples an exported story or folder with its file.
+type LocalStory struct {
+	Path  string
+	Story storyblok.Story
+}
+
+// Dir returns the story directory below the schema directory base.
+func Dir(base string) string {
+	return filepath.Join(base, SubDir)
+}
+
+// storyPath maps a story to its file below dir, mirroring the folder hierarchy.
+func storyPath(dir string, story storyblok.Story) string {
+	slug := filepath.FromSlash(strings.Trim(story.FullSlug, "/"))
+	switch {
+	case story.IsFolder:
+		return filepath.Join(dir, slug, FolderFile)
+	case strings.HasSuffix(story.FullSlug, "/") || isStartpage(story):
+		return filepath.Join(dir, slug, StartpageFile)
+	}
+	return filepath.Join(dir, slug+".json")
+}
+
+func isStartpage(story storyblok.Story) bool {
+	startpage, _ := story.Extras["is_startpage"].(bool)
+	return startpage
+}
+
+// LoadTree reads every exported folder and story below base, folders first and parents
+// before children.
+func LoadTree(base string) ([]LocalStory, error) {
+	dir := Dir(base)
+	if exists, err := fsutil.Exists(dir); err != nil || !exists {
+		return nil, err
+	}
+
+	var stories []LocalStory
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			return nil
+		}
+		var story storyblok.Story
+		if err := fsutil.ReadJSON(path, &story); err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		if story.FullSlug == "" {
+			return fmt.Errorf("%s: story has no full_slug", path)
+		}
+		if entry.Name() == FolderFile {
+			story.IsFolder = true
+		}
+		stories = append(stories, LocalStory{Path: path, Story: story})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(stories, func(i, j int) bool {
+		a, b := stories[i].Story, stories[j].Story
+		if a.IsFolder != b.IsFolder {
+			return a.IsFolder
+		}
+		if da, db := depth(a), depth(b); da != db {
+			return da < db
+		}
+		return a.FullSlug < b.FullSlug
+	})
+	return stories, nil
+}
+
+func depth(story storyblok.Story) int {
+	return strings.Count(strings.Trim(story.FullSlug, "/"), "/")
+}
+
+// parentSlug returns the full_slug of the folder containing story, or "" at the root.
+func parentSlug(story storyblok.Story) string {
+	slug := strings.Trim(story.FullSlug, "/")
+	if strings.HasSuffix(story.FullSlug, "/") && !story.IsFolder {
+		// A start page lives inside the folder sharing its slug.
+		return slug
+	}
+	if i := strings.LastIndex(slug, "/"); i >= 0 {
+		return slug[:i]
+	}
+	return ""
+}
+
+// key identifies a story or folder by full_slug; folders and start pages share a slug.
+func key(story storyblok.Story) string {
+	slug := strings.ToLower(strings.Trim(story.FullSlug, "/"))
+	if story.IsFolder {
+		return "folder:" + slug
+	}
+	if strings.HasSuffix(story.FullSlug, "/") {
+		return "start:" + slug
+	}
+	return "story:" + slug
+}
+
+func folderKey(slug string) string {
+	return "folder:" + strings.ToLower(strings.Trim(slug, "/"))
+}
+
+// cloneContent deep-copies story content so remapping never touches the loaded file.
+func cloneContent(src map[string]any) map[string]any {
+	if src == nil {
+		return nil
+	}
+	data, err := json.Marshal(src)
+	if err != nil {
+		return src
+	}
+	var dst map[string]any
+	if err := json.Unmarshal(data, &dst); err != nil {
+		return src
+	}
+	return dst
+}
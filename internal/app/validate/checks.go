@@ -0,0 +1,186 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sbx/internal/diag"
+	"sbx/internal/schema"
+)
+
+// Check runs the structural and semantic checks on a loaded tree.
+func Check(tree Tree) []diag.Diagnostic {
+	diagnostics := append([]diag.Diagnostic{}, tree.Diagnostics...)
+
+	names := make(map[string]*File, len(tree.Components))
+	groups := make(map[string]struct{})
+	for _, file := range tree.Components {
+		component := file.Component
+		key := strings.ToLower(component.Name)
+		if first, ok := names[key]; ok {
+			code, message := "duplicate-component", fmt.Sprintf("component %q is also defined in %s", component.Name, first.Path)
+			if first.Component.Name != component.Name {
+				code, message = "case-collision", fmt.Sprintf("component %q differs only in case from %q in %s", component.Name, first.Component.Name, first.Path)
+			}
+			diagnostics = append(diagnostics, diag.At(file.Path, file.Data, diag.SeverityError, code, []string{"name"}, "%s", message))
+		} else {
+			names[key] = file
+		}
+		if component.ComponentGroupUUID != "" {
+			groups[strings.ToLower(component.ComponentGroupUUID)] = struct{}{}
+		}
+		if component.ComponentGroupName != "" {
+			groups[strings.ToLower(component.ComponentGroupName)] = struct{}{}
+		}
+	}
+
+	for _, file := range tree.Components {
+		diagnostics = append(diagnostics, checkFields(file, names, groups)...)
+	}
+	diagnostics = append(diagnostics, checkPresets(tree, names)...)
+
+	diag.Sort(diagnostics)
+	return diagnostics
+}
+
+// checkFields validates each schema field of a component.
+func checkFields(file *File, components map[string]*File, groups map[string]struct{}) []diag.Diagnostic {
+	var diagnostics []diag.Diagnostic
+	report := func(severity diag.Severity, code string, path []string, format string, args ...any) {
+		diagnostics = append(diagnostics, diag.At(file.Path, file.Data, severity, code, path, format, args...))
+	}
+
+	fieldNames := make([]string, 0, len(file.Component.Schema))
+	for name := range file.Component.Schema {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+
+	positions := make(map[int]string)
+	for _, name := range fieldNames {
+		def, ok := file.Component.Schema[name].(map[string]any)
+		if !ok {
+			report(diag.SeverityError, "invalid-field", []string{"schema", name}, "field %q must be an object", name)
+			continue
+		}
+
+		fieldType, _ := def["type"].(string)
+		if fieldType == "" {
+			report(diag.SeverityError, "missing-field-type", []string{"schema", name}, "field %q has no type", name)
+		} else if !schema.KnownFieldType(fieldType) {
+			report(diag.SeverityError, "unknown-field-type", []string{"schema", name, "type"}, "field %q has unknown type %q", name, fieldType)
+		}
+
+		if pos, ok := fieldPos(def["pos"]); ok {
+			if other, dup := positions[pos]; dup {
+				report(diag.SeverityError, "duplicate-pos", []string{"schema", name, "pos"}, "field %q has the same pos %d as %q", name, pos, other)
+			} else {
+				positions[pos] = name
+			}
+		}
+
+		if pattern, _ := def["regex"].(string); pattern != "" {
+			if _, err := syntax.Parse(pattern, syntax.Perl); err != nil {
+				var syntaxErr *syntax.Error
+				if errors.As(err, &syntaxErr) && (syntaxErr.Code == syntax.ErrInvalidPerlOp || syntaxErr.Code == syntax.ErrInvalidEscape) {
+					// Lookarounds and backreferences are valid in the editor's JavaScript regexes.
+					report(diag.SeverityWarning, "unverified-regex", []string{"schema", name, "regex"}, "regex of field %q uses syntax that cannot be checked: %v", name, err)
+				} else {
+					report(diag.SeverityError, "invalid-regex", []string{"schema", name, "regex"}, "regex of field %q is invalid: %v", name, err)
+				}
+			}
+		}
+
+		for i, entry := range listValues(def["component_whitelist"]) {
+			if entry == "" {
+				continue
+			}
+			if _, ok := components[strings.ToLower(entry)]; !ok {
+				report(diag.SeverityError, "unknown-whitelist-component", []string{"schema", name, "component_whitelist", strconv.Itoa(i)}, "field %q whitelists unknown component %q", name, entry)
+			}
+		}
+		for i, entry := range listValues(def["component_group_whitelist"]) {
+			if entry == "" {
+				continue
+			}
+			if _, ok := groups[strings.ToLower(entry)]; !ok {
+				report(diag.SeverityError, "unknown-whitelist-group", []string{"schema", name, "component_group_whitelist", strconv.Itoa(i)}, "field %q whitelists component group %q that no local component belongs to", name, entry)
+			}
+		}
+	}
+	return diagnostics
+}
+
+// checkPresets validates preset ownership, name uniqueness and default preset references.
+func checkPresets(tree Tree, components map[string]*File) []diag.Diagnostic {
+	var diagnostics []diag.Diagnostic
+
+	byID := make(map[int]*File)
+	seen := make(map[string]*File)
+	for _, file := range tree.Presets {
+		preset := file.Preset
+		if preset.ID != 0 {
+			byID[preset.ID] = file
+		}
+		componentName, _ := preset.Preset["component"].(string)
+		if componentName == "" {
+			diagnostics = append(diagnostics, diag.At(file.Path, file.Data, diag.SeverityError, "preset-missing-component", []string{"preset"}, "preset %q has no preset.component", preset.Name))
+			continue
+		}
+		if _, ok := components[strings.ToLower(componentName)]; !ok {
+			diagnostics = append(diagnostics, diag.At(file.Path, file.Data, diag.SeverityError, "preset-unknown-component", []string{"preset", "component"}, "preset %q belongs to unknown component %q", preset.Name, componentName))
+			continue
+		}
+		key := strings.ToLower(componentName) + "\x00" + strings.ToLower(preset.Name)
+		if first, ok := seen[key]; ok {
+			diagnostics = append(diagnostics, diag.At(file.Path, file.Data, diag.SeverityError, "duplicate-preset", []string{"name"}, "preset %q of component %q is also defined in %s", preset.Name, componentName, first.Path))
+			continue
+		}
+		seen[key] = file
+	}
+
+	for _, file := range tree.Components {
+		id := file.Component.PresetID
+		if id == 0 {
+			continue
+		}
+		preset, ok := byID[id]
+		if !ok {
+			diagnostics = append(diagnostics, diag.At(file.Path, file.Data, diag.SeverityError, "unknown-preset-id", []string{"preset_id"}, "preset_id %d does not match any local preset", id))
+			continue
+		}
+		owner, _ := preset.Preset.Preset["component"].(string)
+		if !strings.EqualFold(owner, file.Component.Name) {
+			diagnostics = append(diagnostics, diag.At(file.Path, file.Data, diag.SeverityError, "foreign-preset-id", []string{"preset_id"}, "preset_id %d refers to preset %q of component %q", id, preset.Preset.Name, owner))
+		}
+	}
+	return diagnostics
+}
+
+func fieldPos(value any) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func listValues(value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		s, _ := item.(string)
+		values = append(values, s)
+	}
+	return values
+}
@@ -0,0 +1,157 @@
+package validate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"sbx/internal/app/push"
+	"sbx/internal/diag"
+	"sbx/internal/storyblok"
+)
+
+// Kinds of schema files.
+const (
+	KindComponent = "component"
+	KindPreset    = "preset"
+)
+
+// File is a schema file with its raw bytes kept for positioning diagnostics.
+type File struct {
+	Path      string
+	Kind      string
+	Data      []byte
+	Component storyblok.Component
+	Preset    storyblok.ComponentPreset
+}
+
+// Tree is the parsed local schema directory. Files that could not be classified or
+// parsed are reported in Diagnostics and left out of Components and Presets.
+type Tree struct {
+	Dir         string
+	Components  []*File
+	Presets     []*File
+	Diagnostics []diag.Diagnostic
+}
+
+// LoadTree reads every component and preset file under dir. Files in the components/ and
+// presets/ subdirectories have a fixed kind; files directly in dir are classified by their
+// "schema" or "preset" key, as pull writes both kinds side by side.
+func LoadTree(dir string) (Tree, error) {
+	tree := Tree{Dir: dir}
+
+	componentPaths, err := push.ComponentPaths(dir)
+	if err != nil {
+		return tree, err
+	}
+	presetPaths, err := push.PresetPaths(dir)
+	if err != nil {
+		return tree, err
+	}
+
+	kinds := make(map[string]string)
+	for _, path := range componentPaths {
+		kinds[path] = kindFromDir(dir, path, KindComponent)
+	}
+	for _, path := range presetPaths {
+		if _, ok := kinds[path]; ok {
+			continue
+		}
+		kinds[path] = kindFromDir(dir, path, KindPreset)
+	}
+
+	paths := make([]string, 0, len(kinds))
+	for path := range kinds {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		file, diagnostics, err := loadFile(path, kinds[path])
+		if err != nil {
+			return tree, err
+		}
+		tree.Diagnostics = append(tree.Diagnostics, diagnostics...)
+		if file == nil {
+			continue
+		}
+		switch file.Kind {
+		case KindComponent:
+			tree.Components = append(tree.Components, file)
+		case KindPreset:
+			tree.Presets = append(tree.Presets, file)
+		}
+	}
+	return tree, nil
+}
+
+// kindFromDir returns kind for files inside its dedicated subdirectory and "" for files
+// in the shared base directory.
+func kindFromDir(base, path, kind string) string {
+	if filepath.Clean(filepath.Dir(path)) == filepath.Clean(base) {
+		return ""
+	}
+	return kind
+}
+
+func loadFile(path, kind string) (*File, []diag.Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, []diag.Diagnostic{diag.FromJSONError(path, data, err)}, nil
+	}
+
+	if kind == "" {
+		_, hasSchema := raw["schema"]
+		_, hasPreset := raw["preset"]
+		switch {
+		case hasSchema:
+			kind = KindComponent
+		case hasPreset:
+			kind = KindPreset
+		default:
+			return nil, []diag.Diagnostic{{
+				File:     path,
+				Line:     1,
+				Column:   1,
+				Severity: diag.SeverityError,
+				Code:     "unrecognized-file",
+				Message:  "file is neither a component (name, schema) nor a preset (name, preset)",
+			}}, nil
+		}
+	}
+
+	file := &File{Path: path, Kind: kind, Data: data}
+	var diagnostics []diag.Diagnostic
+	switch kind {
+	case KindComponent:
+		if err := json.Unmarshal(data, &file.Component); err != nil {
+			return nil, []diag.Diagnostic{diag.FromJSONError(path, data, err)}, nil
+		}
+		if file.Component.Name == "" {
+			diagnostics = append(diagnostics, diag.At(path, data, diag.SeverityError, "missing-name", []string{"name"}, "component has no name"))
+		}
+		if file.Component.Schema == nil {
+			diagnostics = append(diagnostics, diag.At(path, data, diag.SeverityError, "missing-schema", []string{"schema"}, "component has no schema object"))
+		}
+	case KindPreset:
+		if err := json.Unmarshal(data, &file.Preset); err != nil {
+			return nil, []diag.Diagnostic{diag.FromJSONError(path, data, err)}, nil
+		}
+		if file.Preset.Name == "" {
+			diagnostics = append(diagnostics, diag.At(path, data, diag.SeverityError, "missing-name", []string{"name"}, "preset has no name"))
+		}
+		if file.Preset.Preset == nil {
+			diagnostics = append(diagnostics, diag.At(path, data, diag.SeverityError, "missing-preset", []string{"preset"}, "preset has no preset object"))
+		}
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnostics, nil
+	}
+	return file, nil, nil
+}
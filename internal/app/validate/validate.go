@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"sbx/internal/diag"
+)
+
+// Output formats for validation reports.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures a validation run over the local schema directory.
+type Options struct {
+	Dir    string
+	Format string
+}
+
+// Result summarises a validation run.
+type Result struct {
+	ExitCode    int
+	Components  int
+	Presets     int
+	Errors      int
+	Warnings    int
+	Diagnostics []diag.Diagnostic
+}
+
+// Run loads the schema tree under opts.Dir, checks it and prints the diagnostics. Any
+// error-level diagnostic sets exit code 1.
+func Run(opts Options) (Result, error) {
+	result := Result{ExitCode: 0}
+
+	if opts.Format == "" {
+		opts.Format = FormatText
+	}
+	if opts.Format != FormatText && opts.Format != FormatJSON {
+		result.ExitCode = 1
+		return result, fmt.Errorf("invalid format %q (expected text or json)", opts.Format)
+	}
+
+	tree, err := LoadTree(opts.Dir)
+	if err != nil {
+		result.ExitCode = 1
+		return result, err
+	}
+
+	result.Components = len(tree.Components)
+	result.Presets = len(tree.Presets)
+	result.Diagnostics = Check(tree)
+	result.Errors, result.Warnings = diag.Count(result.Diagnostics)
+	if result.Errors > 0 {
+		result.ExitCode = 1
+	}
+
+	if err := printReport(result, opts); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func printReport(result Result, opts Options) error {
+	if opts.Format == FormatJSON {
+		payload := struct {
+			Dir         string            `json:"dir"`
+			Components  int               `json:"components"`
+			Presets     int               `json:"presets"`
+			Errors      int               `json:"errors"`
+			Warnings    int               `json:"warnings"`
+			Diagnostics []diag.Diagnostic `json:"diagnostics"`
+		}{
+			Dir:         opts.Dir,
+			Components:  result.Components,
+			Presets:     result.Presets,
+			Errors:      result.Errors,
+			Warnings:    result.Warnings,
+			Diagnostics: result.Diagnostics,
+		}
+		if payload.Diagnostics == nil {
+			payload.Diagnostics = []diag.Diagnostic{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(payload)
+	}
+
+	for _, d := range result.Diagnostics {
+		fmt.Println(d)
+	}
+	if len(result.Diagnostics) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("Validated %d components and %d presets in %s: %d errors, %d warnings\n",
+		result.Components, result.Presets, opts.Dir, result.Errors, result.Warnings)
+	return nil
+}
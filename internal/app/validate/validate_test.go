@@ -0,0 +1,110 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "valid tree",
+			files: map[string]string{
+				"components/page.json": `{
+  "name": "page",
+  "schema": {
+    "title": {"type": "text", "pos": 0},
+    "body": {"type": "bloks", "pos": 1, "component_whitelist": ["hero"]}
+  }
+}`,
+				"components/hero.json": `{"name": "hero", "schema": {}}`,
+			},
+		},
+		{
+			name: "field problems",
+			files: map[string]string{
+				"components/page.json": `{
+  "name": "page",
+  "schema": {
+    "title": {"type": "txt", "pos": 0},
+    "teaser": {"type": "text", "pos": 0},
+    "slug": {"type": "text", "pos": 1, "regex": "[a-z"},
+    "body": {"type": "bloks", "pos": 2, "component_whitelist": ["hero"]}
+  }
+}`,
+			},
+			want: []string{
+				`components/page.json:4:15: error: field "title" has unknown type "txt" (unknown-field-type)`,
+				`components/page.json:4:30: error: field "title" has the same pos 0 as "teaser" (duplicate-pos)`,
+				`components/page.json:6:40: error: regex of field "slug" is invalid: error parsing regexp: missing closing ]: ` + "`[a-z`" + ` (invalid-regex)`,
+				`components/page.json:7:65: error: field "body" whitelists unknown component "hero" (unknown-whitelist-component)`,
+			},
+		},
+		{
+			name: "case collision",
+			files: map[string]string{
+				"components/hero.json":      `{"name": "hero", "schema": {}}`,
+				"components/hero_copy.json": `{"name": "Hero", "schema": {}}`,
+			},
+			want: []string{
+				`components/hero_copy.json:1:2: error: component "Hero" differs only in case from "hero" in components/hero.json (case-collision)`,
+			},
+		},
+		{
+			name: "invalid JSON",
+			files: map[string]string{
+				"components/page.json": "{\n  \"name\": \"page\",\n  \"schema\": {\n}",
+			},
+			want: []string{
+				`components/page.json:4:2: error: unexpected end of JSON input (parse-error)`,
+			},
+		},
+		{
+			name: "presets",
+			files: map[string]string{
+				"components/page.json":   `{"name": "page", "preset_id": 7, "schema": {}}`,
+				"presets/page-blue.json": `{"id": 3, "name": "Blue", "preset": {"component": "page"}}`,
+				"presets/orphan.json":    `{"id": 4, "name": "Orphan", "preset": {"component": "teaser"}}`,
+			},
+			want: []string{
+				`components/page.json:1:18: error: preset_id 7 does not match any local preset (unknown-preset-id)`,
+				`presets/orphan.json:1:40: error: preset "Orphan" belongs to unknown component "teaser" (preset-unknown-component)`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tree, err := LoadTree(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, diagnostic := range Check(tree) {
+				// Report paths relative to the tree, including those quoted in messages.
+				got = append(got, filepath.ToSlash(strings.ReplaceAll(diagnostic.String(), dir+string(filepath.Separator), "")))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package auditlog keeps a durable JSONL record of the writes sbx makes to Storyblok
+// spaces.
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sbx/internal/storyblok"
+)
+
+// DefaultPath is where the audit log is kept unless configured otherwise.
+const DefaultPath = ".sbx/audit.jsonl"
+
+// Off disables the audit log when given as its path.
+const Off = "off"
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	SpaceID    int       `json:"space_id"`
+	Entity     string    `json:"entity,omitempty"`
+	BeforeHash string    `json:"before_hash,omitempty"`
+	AfterHash  string    `json:"after_hash,omitempty"`
+	Status     int       `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	// GitSHA is the commit checked out in the working directory, if any.
+	GitSHA string `json:"git_sha,omitempty"`
+	// Actor is the CI job or the user that ran sbx.
+	Actor   string `json:"actor,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// Log appends the writes a storyblok client reports to a JSONL file. It implements
+// storyblok.Auditor.
+type Log struct {
+	path    string
+	command string
+
+	once   sync.Once
+	gitSHA string
+	actor  string
+
+	mu  sync.Mutex
+	err error
+}
+
+// Open returns a log appending to path, tagging entries with command. The file is
+// created on the first write.
+func Open(path, command string) *Log {
+	return &Log{path: path, command: command}
+}
+
+// Path returns the file the log appends to.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Audit appends record to the log. Failures are kept for Err rather than failing the
+// write that was already made.
+func (l *Log) Audit(record storyblok.AuditRecord) {
+	l.once.Do(func() {
+		ctx := context.Background()
+		l.gitSHA = GitSHA(ctx)
+		l.actor = Actor(ctx)
+	})
+	entry := Entry{
+		Time:       record.Time,
+		Method:     record.Method,
+		Path:       record.Path,
+		SpaceID:    record.SpaceID,
+		Entity:     record.Entity,
+		BeforeHash: record.BeforeHash,
+		AfterHash:  record.AfterHash,
+		Status:     record.Status,
+		GitSHA:     l.gitSHA,
+		Actor:      l.actor,
+		Command:    l.command,
+	}
+	if record.Err != nil {
+		entry.Error = record.Err.Error()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		l.fail(err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		l.failLocked(err)
+		return
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		l.failLocked(err)
+		return
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		l.failLocked(err)
+	}
+	if err := file.Close(); err != nil {
+		l.failLocked(err)
+	}
+}
+
+// Err returns the first error appending to the log.
+func (l *Log) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (l *Log) fail(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failLocked(err)
+}
+
+func (l *Log) failLocked(err error) {
+	if l.err == nil {
+		l.err = fmt.Errorf("append to audit log %s: %w", l.path, err)
+	}
+}
+
+// Filter selects entries of the log. Zero fields select everything.
+type Filter struct {
+	SpaceID int
+	// Entity is a case-insensitive glob matched against entry names.
+	Entity string
+	Since  time.Time
+	Until  time.Time
+}
+
+// Match reports whether entry passes the filter.
+func (f Filter) Match(entry Entry) bool {
+	if f.SpaceID != 0 && entry.SpaceID != f.SpaceID {
+		return false
+	}
+	if f.Entity != "" {
+		ok, err := filepath.Match(strings.ToLower(f.Entity), strings.ToLower(entry.Entity))
+		if err != nil || !ok {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Read returns the entries of the log at path that pass filter, oldest first. A missing
+// log has no entries.
+func Read(path string, filter Filter) ([]Entry, error) {
+	if _, err := filepath.Match(filter.Entity, ""); err != nil {
+		return nil, fmt.Errorf("invalid entity pattern %q: %w", filter.Entity, err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(text, &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Concurrent writes may append slightly out of order.
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// GitSHA returns the commit checked out in the working directory, or "" outside a git
+// repository.
+func GitSHA(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Actor names who runs sbx: the CI job when a known CI system runs it, otherwise the
+// user.
+func Actor(ctx context.Context) string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return fmt.Sprintf("github-actions:%s/actions/runs/%s (%s)", os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"), os.Getenv("GITHUB_ACTOR"))
+	case os.Getenv("GITLAB_CI") == "true":
+		return fmt.Sprintf("gitlab-ci:%s (%s)", os.Getenv("CI_JOB_URL"), os.Getenv("GITLAB_USER_LOGIN"))
+	case os.Getenv("CI") != "":
+		if job := os.Getenv("BUILD_URL"); job != "" {
+			return "ci:" + job
+		}
+		return "ci"
+	}
+	return User(ctx)
+}
+
+// User names the person running sbx: the git user's email, or else the login name.
+func User(ctx context.Context) string {
+	if out, err := exec.CommandContext(ctx, "git", "config", "user.email").Output(); err == nil {
+		if email := strings.TrimSpace(string(out)); email != "" {
+			return email
+		}
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
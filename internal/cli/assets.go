@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/assets"
+)
+
+type assetFlags struct {
+	spaceID int
+	dryRun  bool
+	dir     string
+	protect protectFlags
+}
+
+func newPullAssetsCommand() *cobra.Command {
+	flags := assetFlags{spaceID: globalOpts.SourceSpaceID}
+
+	cmd := &cobra.Command{
+		Use:   "pull-assets",
+		Short: "Download the asset library of a Storyblok space",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.SourceSpaceID
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or SOURCE_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := assets.PullOptions{
+				Token:   globalOpts.Token,
+				SpaceID: flags.spaceID,
+				OutDir:  globalOpts.OutDir,
+				DryRun:  flags.dryRun,
+			}
+
+			result, err := assets.Pull(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to download from (defaults to SOURCE_SPACE_ID)")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing files")
+
+	return cmd
+}
+
+func newPushAssetsCommand() *cobra.Command {
+	flags := assetFlags{
+		spaceID: globalOpts.TargetSpaceID,
+		dir:     globalOpts.OutDir,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "push-assets",
+		Short: "Upload a downloaded asset library into a Storyblok space",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := flags.protect.check(cmd, flags.spaceID, flags.dryRun); err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := assets.PushOptions{
+				Token:   globalOpts.Token,
+				SpaceID: flags.spaceID,
+				Dir:     flags.dir,
+				DryRun:  flags.dryRun,
+			}
+
+			result, err := assets.Push(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to upload into (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Schema directory whose assets/ folder holds the download")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing to Storyblok")
+	flags.protect.register(cmd, false)
+
+	return cmd
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/audit"
+	"sbx/internal/auditlog"
+	"sbx/internal/storyblok"
+)
+
+// auditLog records the writes of the running command; nil when the log is off.
+var auditLog *auditlog.Log
+
+// startAudit makes the writes of every command go to the audit log.
+func startAudit(cmd *cobra.Command, args []string) error {
+	path, err := auditLogPath(cmd)
+	if err != nil {
+		SetExitCode(ExitCodeInvalid)
+		return err
+	}
+	if path == auditlog.Off {
+		return nil
+	}
+	auditLog = auditlog.Open(path, cmd.CommandPath())
+	cmd.SetContext(storyblok.WithAuditor(cmd.Context(), auditLog))
+	return nil
+}
+
+// auditLogPath resolves the audit log from --audit-log or SBX_AUDIT_LOG, then the
+// configuration, then the default.
+func auditLogPath(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("audit-log") || strings.TrimSpace(os.Getenv("SBX_AUDIT_LOG")) != "" {
+		return globalOpts.AuditLog, nil
+	}
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Audit.Path != "" {
+		return cfg.Audit.Path, nil
+	}
+	return auditlog.DefaultPath, nil
+}
+
+type auditFlags struct {
+	spaceID int
+	entity  string
+	since   string
+	until   string
+	format  string
+}
+
+func newAuditCommand() *cobra.Command {
+	flags := auditFlags{format: audit.FormatText}
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "List the writes sbx made to Storyblok spaces",
+		Long: `List the entries of the audit log: every write request sbx sent to Storyblok with its
+space, entity, before and after hashes, status, git commit and the user or CI job that
+ran it. Filter by space, entity name and time range.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := auditLogPath(cmd)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			if path == auditlog.Off {
+				path = auditlog.DefaultPath
+			}
+			now := time.Now()
+			since, err := audit.ParseTime(flags.since, now)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			until, err := audit.ParseTime(flags.until, now)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := audit.Options{
+				Path:    path,
+				SpaceID: flags.spaceID,
+				Entity:  flags.entity,
+				Since:   since,
+				Until:   until,
+				Format:  flags.format,
+			}
+
+			result, err := audit.Run(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeInvalid
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", 0, "Only list writes to this space ID")
+	cmd.Flags().StringVar(&flags.entity, "entity", "", "Only list writes to entities whose name matches this glob (case-insensitive)")
+	cmd.Flags().StringVar(&flags.since, "since", "", "Only list writes at or after this time: RFC 3339, YYYY-MM-DD, or a duration such as 24h or 7d ago")
+	cmd.Flags().StringVar(&flags.until, "until", "", "Only list writes before this time, in the same forms as --since")
+	cmd.Flags().StringVar(&flags.format, "format", flags.format, "Output format: text or json")
+
+	return cmd
+}
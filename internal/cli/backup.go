@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/backup"
+)
+
+type backupFlags struct {
+	spaceID     int
+	output      string
+	withStories bool
+	withAssets  bool
+	dryRun      bool
+	protect     protectFlags
+}
+
+func newBackupCommand() *cobra.Command {
+	flags := backupFlags{spaceID: globalOpts.SourceSpaceID}
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Write a verifiable backup of a Storyblok space",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.SourceSpaceID
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or SOURCE_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := backup.Options{
+				Token:       globalOpts.Token,
+				SpaceID:     flags.spaceID,
+				Output:      flags.output,
+				WithStories: flags.withStories,
+				WithAssets:  flags.withAssets,
+			}
+
+			result, err := backup.Run(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to back up (defaults to SOURCE_SPACE_ID)")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Backup directory or .tar.gz archive (defaults to sbx-backup-<space>-<time>.tar.gz)")
+	cmd.Flags().BoolVar(&flags.withStories, "with-stories", false, "Include stories and story folders")
+	cmd.Flags().BoolVar(&flags.withAssets, "with-assets", false, "Include the asset library")
+
+	return cmd
+}
+
+func newRestoreSpaceCommand() *cobra.Command {
+	flags := backupFlags{spaceID: globalOpts.TargetSpaceID}
+
+	cmd := &cobra.Command{
+		Use:   "restore-space <backup>",
+		Short: "Rebuild a backup into a Storyblok space",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			protected, err := flags.protect.check(cmd, flags.spaceID, flags.dryRun)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := backup.RestoreOptions{
+				Token:            globalOpts.Token,
+				SpaceID:          flags.spaceID,
+				Source:           args[0],
+				DryRun:           flags.dryRun,
+				Protected:        protected,
+				AllowDestructive: flags.protect.allowDestructive,
+			}
+
+			result, err := backup.Restore(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to restore into (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Verify the backup and print planned actions without writing to Storyblok")
+	flags.protect.register(cmd, true)
+
+	return cmd
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/datasources"
+)
+
+type datasourceFlags struct {
+	spaceID   int
+	matchMode string
+	all       bool
+	dryRun    bool
+	dir       string
+	protect   protectFlags
+}
+
+func newPullDatasourcesCommand() *cobra.Command {
+	flags := datasourceFlags{
+		spaceID:   globalOpts.SourceSpaceID,
+		matchMode: "exact",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "pull-datasources [slug...]",
+		Short: "Download datasources and their entries from a Storyblok space",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !flags.all {
+				return fmt.Errorf("either provide datasource slugs or use --all")
+			}
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.SourceSpaceID
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or SOURCE_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openTarget(globalOpts.OutDir)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := datasources.PullOptions{
+				Env:       store.env,
+				Token:     globalOpts.Token,
+				SpaceID:   flags.spaceID,
+				Names:     args,
+				MatchMode: flags.matchMode,
+				All:       flags.all,
+				OutDir:    store.dir,
+				DryRun:    flags.dryRun,
+			}
+
+			result, err := datasources.Pull(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return store.close(err)
+			}
+
+			SetExitCode(result.ExitCode)
+			return store.close(nil)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to pull from (defaults to SOURCE_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Datasource slug matching mode: exact, prefix, glob")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Pull all datasources")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing files")
+
+	return cmd
+}
+
+func newPushDatasourcesCommand() *cobra.Command {
+	flags := datasourceFlags{
+		spaceID:   globalOpts.TargetSpaceID,
+		matchMode: "exact",
+		dir:       globalOpts.OutDir,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "push-datasources [slug...]",
+		Short: "Create or update datasources and their entries in a Storyblok space",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !flags.all {
+				return fmt.Errorf("either provide datasource slugs or use --all")
+			}
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := flags.protect.check(cmd, flags.spaceID, flags.dryRun); err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			store, err := openSource(cmd.Context(), flags.dir, "")
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := datasources.PushOptions{
+				Env:       store.env,
+				Token:     globalOpts.Token,
+				SpaceID:   flags.spaceID,
+				Names:     args,
+				MatchMode: flags.matchMode,
+				All:       flags.all,
+				Dir:       store.dir,
+				DryRun:    flags.dryRun,
+			}
+
+			result, err := datasources.Push(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return store.close(err)
+			}
+
+			SetExitCode(result.ExitCode)
+			return store.close(nil)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to push to (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Datasource slug matching mode: exact, prefix, glob")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Push all datasources found in the directory")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing to Storyblok")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Schema directory, archive or - for a bundle on stdin whose datasources/ folder holds the files to push")
+	flags.protect.register(cmd, false)
+
+	return cmd
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/diff"
+	"sbx/internal/gitref"
+)
+
+type diffFlags struct {
+	spaceID   int
+	matchMode string
+	dir       string
+	format    string
+	failOn    string
+	fromRef   string
+	gitRange  string
+}
+
+func newDiffCommand() *cobra.Command {
+	flags := diffFlags{
+		spaceID:   globalOpts.TargetSpaceID,
+		matchMode: "exact",
+		dir:       globalOpts.OutDir,
+		format:    diff.FormatText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "diff [name...]",
+		Short: "Classify schema changes between local files and a Storyblok space, or between git commits",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			if flags.gitRange != "" {
+				// Both sides come from git, so no space is involved.
+				if flags.fromRef != "" {
+					return fmt.Errorf("--git and --from-ref cannot be combined")
+				}
+				if !cmd.Flags().Changed("format") {
+					flags.format = diff.FormatMarkdown
+				}
+				return nil
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.gitRange != "" {
+				return runGitDiff(cmd, flags, args)
+			}
+			store, err := openSource(cmd.Context(), flags.dir, flags.fromRef)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := diff.Options{
+				Env:       store.env,
+				Token:     globalOpts.Token,
+				SpaceID:   flags.spaceID,
+				Dir:       store.dir,
+				Revision:  store.revision,
+				Names:     args,
+				MatchMode: flags.matchMode,
+				Format:    flags.format,
+				FailOn:    flags.failOn,
+			}
+
+			result, err := diff.Run(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return store.close(err)
+			}
+
+			SetExitCode(result.ExitCode)
+			return store.close(nil)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to compare against (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Component name matching mode: exact, prefix, glob")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory, archive (.tar, .tar.gz, .tgz, .zip) or - for a bundle on stdin containing component schemas")
+	cmd.Flags().StringVar(&flags.fromRef, "from-ref", "", "Read --dir as it is in this git commit, branch or tag instead of the working tree")
+	cmd.Flags().StringVar(&flags.gitRange, "git", "", "Compare --dir between two git commits (base..head or base...head) instead of against a space")
+	cmd.Flags().StringVar(&flags.format, "format", flags.format, "Report format: text, json, markdown (default for --git)")
+	cmd.Flags().StringVar(&flags.failOn, "fail-on", "", "Exit with code 1 when a change is at least this severe: safe, risky, breaking")
+
+	return cmd
+}
+
+func runGitDiff(cmd *cobra.Command, flags diffFlags, args []string) error {
+	r, err := gitref.ParseRange(flags.gitRange)
+	if err != nil {
+		SetExitCode(ExitCodeInvalid)
+		return err
+	}
+	base, head, err := gitref.OpenRange(cmd.Context(), r, flags.dir)
+	if err != nil {
+		SetExitCode(ExitCodeInvalid)
+		return err
+	}
+
+	options := diff.RevisionsOptions{
+		Dir:       flags.dir,
+		Base:      diff.Revision{Name: base.Ref, SHA: base.SHA, FS: base.FS},
+		Head:      diff.Revision{Name: head.Ref, SHA: head.SHA, FS: head.FS},
+		Names:     args,
+		MatchMode: flags.matchMode,
+		Format:    flags.format,
+		FailOn:    flags.failOn,
+	}
+
+	result, err := diff.Revisions(cmd.Context(), options)
+	if err != nil {
+		code := result.ExitCode
+		if code == 0 {
+			code = ExitCodeExecution
+		}
+		SetExitCode(code)
+		return err
+	}
+
+	SetExitCode(result.ExitCode)
+	return nil
+}
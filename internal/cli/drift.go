@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/drift"
+)
+
+type driftFlags struct {
+	spaceID   int
+	matchMode string
+	dir       string
+}
+
+func newDriftCommand() *cobra.Command {
+	flags := driftFlags{
+		spaceID:   globalOpts.TargetSpaceID,
+		matchMode: "exact",
+		dir:       globalOpts.OutDir,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "drift [name...]",
+		Short: "Report components changed in a space since sbx last synced them",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := drift.Options{
+				Token:     globalOpts.Token,
+				SpaceID:   flags.spaceID,
+				Dir:       flags.dir,
+				Names:     args,
+				MatchMode: flags.matchMode,
+			}
+
+			result, err := drift.Run(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to check (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Component name matching mode: exact, prefix, glob")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory containing the sbx.lock sync state")
+
+	return cmd
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/generate"
+)
+
+type generateFlags struct {
+	spaceID int
+	dir     string
+	out     string
+}
+
+func newGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate code from component schemas",
+	}
+	cmd.AddCommand(newGenerateTypesCommand())
+	cmd.AddCommand(newGenerateJSONSchemaCommand())
+	return cmd
+}
+
+func newGenerateTypesCommand() *cobra.Command {
+	flags := generateFlags{
+		dir: globalOpts.OutDir,
+	}
+	lang := generate.LangTypeScript
+	pkg := "storyblok"
+
+	cmd := &cobra.Command{
+		Use:   "types",
+		Short: "Generate typed models for every component",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return flags.resolve(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := generate.Options{
+				Token:   globalOpts.Token,
+				SpaceID: flags.spaceID,
+				Dir:     flags.dir,
+				Lang:    lang,
+				Out:     flags.out,
+				Package: pkg,
+			}
+
+			result, err := generate.Types(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().StringVar(&lang, "lang", lang, "Target language: ts, go")
+	cmd.Flags().StringVar(&pkg, "package", pkg, "Package name for generated Go code")
+
+	return cmd
+}
+
+func newGenerateJSONSchemaCommand() *cobra.Command {
+	flags := generateFlags{
+		dir: globalOpts.OutDir,
+	}
+	var split bool
+
+	cmd := &cobra.Command{
+		Use:   "jsonschema",
+		Short: "Generate JSON Schema (draft 2020-12) documents for content validation",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := flags.resolve(cmd); err != nil {
+				return err
+			}
+			if split && (flags.out == "" || flags.out == "-") {
+				return fmt.Errorf("--split requires --output to name a directory")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := generate.Options{
+				Token:   globalOpts.Token,
+				SpaceID: flags.spaceID,
+				Dir:     flags.dir,
+				Out:     flags.out,
+				Split:   split,
+			}
+
+			result, err := generate.JSONSchema(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().BoolVar(&split, "split", false, "Write one file per component into the --output directory")
+
+	return cmd
+}
+
+// resolve applies global defaults and validates the schema source.
+func (f *generateFlags) resolve(cmd *cobra.Command) error {
+	if !cmd.Flags().Changed("dir") {
+		f.dir = globalOpts.OutDir
+	}
+	if f.spaceID < 0 {
+		return fmt.Errorf("invalid space ID %d", f.spaceID)
+	}
+	if f.spaceID > 0 && globalOpts.Token == "" {
+		return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+	}
+	return nil
+}
+
+func (f *generateFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.dir, "dir", f.dir, "Directory containing component schemas")
+	cmd.Flags().IntVar(&f.spaceID, "space", 0, "Read schemas from this space instead of --dir")
+	cmd.Flags().StringVarP(&f.out, "output", "o", "", "Output file (defaults to stdout)")
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/impact"
+)
+
+type impactFlags struct {
+	spaceID   int
+	matchMode string
+	dir       string
+	format    string
+}
+
+func newImpactCommand() *cobra.Command {
+	flags := impactFlags{
+		spaceID:   globalOpts.TargetSpaceID,
+		matchMode: "exact",
+		dir:       globalOpts.OutDir,
+		format:    impact.FormatText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "impact [name...]",
+		Short: "Count stories affected by pending schema changes",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := impact.Options{
+				Token:     globalOpts.Token,
+				SpaceID:   flags.spaceID,
+				Dir:       flags.dir,
+				Names:     args,
+				MatchMode: flags.matchMode,
+				Format:    flags.format,
+			}
+
+			result, err := impact.Run(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID whose stories are scanned (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Component name matching mode: exact, prefix, glob")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory containing component schemas")
+	cmd.Flags().StringVar(&flags.format, "format", flags.format, "Report format: text, json")
+
+	return cmd
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/lint"
+)
+
+type lintFlags struct {
+	dir       string
+	format    string
+	listRules bool
+}
+
+func newLintCommand() *cobra.Command {
+	flags := lintFlags{
+		dir:    globalOpts.OutDir,
+		format: lint.FormatText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check local component schemas against naming and schema conventions",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.listRules {
+				lint.PrintRules()
+				return nil
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+
+			options := lint.Options{
+				Dir:    flags.dir,
+				Format: flags.format,
+				Config: cfg.Lint,
+			}
+
+			result, err := lint.Run(options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory containing component schemas")
+	cmd.Flags().StringVar(&flags.format, "format", flags.format, "Output format: text, json, sarif")
+	cmd.Flags().BoolVar(&flags.listRules, "list-rules", false, "List built-in rules and their defaults")
+
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/migrate"
+)
+
+type migrateFlags struct {
+	spaceID   int
+	dryRun    bool
+	publish   string
+	backupDir string
+	protect   protectFlags
+}
+
+func newMigrateCommand() *cobra.Command {
+	flags := migrateFlags{
+		spaceID: globalOpts.TargetSpaceID,
+		publish: migrate.PublishNone,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "migrate <file|dir>...",
+		Short: "Run declarative content migrations over the stories of a Storyblok space",
+		Args:  cobra.MinimumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			protected, err := flags.protect.check(cmd, flags.spaceID, flags.dryRun)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := migrate.Options{
+				Token:            globalOpts.Token,
+				SpaceID:          flags.spaceID,
+				Files:            args,
+				Dir:              globalOpts.OutDir,
+				BackupDir:        flags.backupDir,
+				Publish:          flags.publish,
+				DryRun:           flags.dryRun,
+				Protected:        protected,
+				AllowDestructive: flags.protect.allowDestructive,
+			}
+
+			result, err := migrate.Run(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID whose stories are migrated (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print per-story diffs without saving stories")
+	cmd.Flags().StringVar(&flags.publish, "publish", flags.publish, "Publish migrated stories: none (keep draft), published (only live stories), all")
+	cmd.Flags().StringVar(&flags.backupDir, "backup-dir", "", "Directory for per-story backups (defaults to <out>/backups/stories-<space>-<timestamp>)")
+	flags.protect.register(cmd, true)
+
+	return cmd
+}
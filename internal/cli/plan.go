@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/push"
+	"sbx/internal/auditlog"
+	"sbx/internal/fsutil"
+)
+
+type planFlags struct {
+	spaceID      int
+	matchMode    string
+	all          bool
+	prune        bool
+	dir          string
+	output       string
+	force        bool
+	merge        bool
+	failOn       string
+	strict       bool
+	presetPolicy string
+	fromRef      string
+}
+
+func newPlanCommand() *cobra.Command {
+	flags := planFlags{
+		spaceID:   globalOpts.TargetSpaceID,
+		matchMode: "exact",
+		dir:       globalOpts.OutDir,
+		output:    "sbx-plan.json",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "plan [name...]",
+		Short: "Save the changes push-components would make to a space as a plan file",
+		Long: `Compute every component, component group and internal tag that push-components would
+create, update or, with --prune, delete in the target space, and save them to a plan
+file together with a fingerprint of the target's current state. Nothing is written to
+Storyblok; review the plan and make exactly these changes with sbx apply.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !flags.all {
+				return fmt.Errorf("either provide component names or use --all")
+			}
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			if flags.force && flags.merge {
+				return fmt.Errorf("--force and --merge cannot be combined")
+			}
+			if flags.prune && !flags.all {
+				return fmt.Errorf("--prune requires --all")
+			}
+			if flags.output == "" || flags.output == bundleStream {
+				return fmt.Errorf("--output must name a plan file")
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Planning writes nothing, so it needs no confirmation.
+			protected, err := protectFlags{}.check(cmd, flags.spaceID, true)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			store, err := openSource(cmd.Context(), flags.dir, flags.fromRef)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := push.PlanOptions{
+				Options: push.Options{
+					Env:          store.env,
+					Token:        globalOpts.Token,
+					SpaceID:      flags.spaceID,
+					Names:        args,
+					MatchMode:    flags.matchMode,
+					All:          flags.all,
+					Dir:          store.dir,
+					Revision:     store.revision,
+					Force:        flags.force,
+					Merge:        flags.merge,
+					FailOn:       flags.failOn,
+					Strict:       flags.strict,
+					PresetPolicy: flags.presetPolicy,
+					Protected:    protected,
+				},
+				Prune:  flags.prune,
+				Source: flags.dir,
+				Output: flags.output,
+			}
+
+			_, result, err := push.MakePlan(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return store.close(err)
+			}
+
+			SetExitCode(result.ExitCode)
+			return store.close(nil)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to plan against (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Component name matching mode: exact, prefix, glob")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Plan all components found in the directory")
+	cmd.Flags().BoolVar(&flags.prune, "prune", false, "Plan to delete components of the target space that have no local file (requires --all)")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory, archive (.tar, .tar.gz, .tgz, .zip) or - for a bundle on stdin containing component schemas to plan")
+	cmd.Flags().StringVar(&flags.fromRef, "from-ref", "", "Read --dir as it is in this git commit, branch or tag instead of the working tree")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", flags.output, "Plan file to write")
+	cmd.Flags().BoolVar(&flags.force, "force", false, "Plan to overwrite components that were changed in the target since the last sync")
+	cmd.Flags().BoolVar(&flags.merge, "merge", false, "Plan three-way merges of schema changes made in the target since the last sync")
+	cmd.Flags().StringVar(&flags.failOn, "fail-on", "", "Refuse to plan when a schema change is at least this severe: safe, risky, breaking")
+	cmd.Flags().BoolVar(&flags.strict, "strict", false, "Fail on skipped or unparseable files, orphan presets and unmatched names")
+	cmd.Flags().StringVar(&flags.presetPolicy, "preset-policy", push.PresetPolicyWarn, "Handling of orphan presets and unresolved default presets: warn, fail, detach")
+
+	return cmd
+}
+
+func newApplyCommand() *cobra.Command {
+	var dir string
+	var protect protectFlags
+
+	cmd := &cobra.Command{
+		Use:   "apply <plan>",
+		Short: "Make the changes saved in a plan file",
+		Long: `Make exactly the changes that sbx plan saved to a plan file in the space it was made
+for. apply refuses to write anything when the target space changed since the plan was
+made; make a new plan then.`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plan, err := push.LoadPlan(fsutil.OS, args[0])
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			var approvalErr error
+			if plan.Approval != nil {
+				approvalErr = plan.VerifyApproval(cfg.Protected.Approvers, auditlog.User(cmd.Context()))
+			}
+			protected, err := protect.check(cmd, plan.SpaceID, plan.Approval != nil && approvalErr == nil)
+			if err != nil {
+				if approvalErr != nil {
+					err = fmt.Errorf("%w; the approval of %s does not count: %v", err, args[0], approvalErr)
+				}
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			if dir == "" {
+				dir = plan.Dir
+			}
+			if dir == bundleStream {
+				SetExitCode(ExitCodeInvalid)
+				return fmt.Errorf("%s was made from a bundle stream; use --dir to name where the sync state is recorded", args[0])
+			}
+			store, err := openSource(cmd.Context(), dir, "")
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := push.ApplyOptions{
+				Env:              store.env,
+				Token:            globalOpts.Token,
+				Plan:             plan,
+				Dir:              store.dir,
+				Protected:        protected,
+				AllowDestructive: protect.allowDestructive,
+			}
+
+			result, err := push.Apply(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return store.close(err)
+			}
+
+			SetExitCode(result.ExitCode)
+			return store.close(nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "", "Directory or archive whose sbx.lock records the applied components (defaults to the plan's --dir)")
+	protect.register(cmd, true)
+
+	return cmd
+}
+
+func newApproveCommand() *cobra.Command {
+	var by, keyFile string
+
+	cmd := &cobra.Command{
+		Use:   "approve <plan>",
+		Short: "Sign a plan file so someone else can apply it to a protected space",
+		Long: `Sign a plan file as approved with the approver's Ed25519 private key. sbx apply makes the
+changes of a plan approved by one of the approvers listed under protected.approvers in
+the configuration in a protected space without --confirm-space, unless the approver
+applies it themselves. Changing the plan afterwards voids the approval.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			if len(cfg.Protected.Approvers) == 0 {
+				SetExitCode(ExitCodeInvalid)
+				return fmt.Errorf("no approvers are configured under protected.approvers in %s", cfg.Path())
+			}
+			if by == "" {
+				by = auditlog.User(cmd.Context())
+			}
+			if keyFile == "" {
+				keyFile = os.Getenv("SBX_APPROVAL_KEY")
+			}
+			options := push.ApproveOptions{
+				Path:      args[0],
+				By:        by,
+				KeyFile:   keyFile,
+				Approvers: cfg.Protected.Approvers,
+			}
+			if _, err := push.ApprovePlan(cmd.Context(), options); err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&by, "by", "", "Approver to sign as, as named in the configuration (defaults to git user.email, then the login name)")
+	cmd.Flags().StringVar(&keyFile, "key", "", "The approver's Ed25519 private key in PEM format (defaults to SBX_APPROVAL_KEY)")
+
+	return cmd
+}
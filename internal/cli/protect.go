@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// protectFlags confirm writes to spaces the project configuration protects.
+type protectFlags struct {
+	confirmSpace     int
+	allowDestructive bool
+}
+
+// register adds --confirm-space and, for commands that can delete or rename components
+// or make other breaking changes, --allow-destructive.
+func (f *protectFlags) register(cmd *cobra.Command, destructive bool) {
+	cmd.Flags().IntVar(&f.confirmSpace, "confirm-space", 0, "Confirm writing to a protected space by repeating its ID")
+	if destructive {
+		cmd.Flags().BoolVar(&f.allowDestructive, "allow-destructive", false, "Allow deletions, renames and other breaking changes in a protected space")
+	}
+}
+
+// check refuses a write to a protected space that is not confirmed by --confirm-space,
+// by confirmed (a dry run, or a plan someone else approved) or by the CI marker of the
+// configuration.
+// It reports whether spaceID is protected.
+func (f protectFlags) check(cmd *cobra.Command, spaceID int, confirmed bool) (bool, error) {
+	if f.confirmSpace != 0 && f.confirmSpace != spaceID {
+		return false, fmt.Errorf("--confirm-space %d does not match the target space %d", f.confirmSpace, spaceID)
+	}
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return false, err
+	}
+	protected := cfg.Protected
+	if !protected.IsProtected(spaceID) {
+		return false, nil
+	}
+	if confirmed || f.confirmSpace == spaceID || protected.InCI() {
+		return true, nil
+	}
+	return true, fmt.Errorf("space %d is protected by %s: confirm with --confirm-space %d, apply a plan someone else approved, or run from CI", spaceID, cfg.Path(), spaceID)
+}
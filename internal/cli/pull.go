@@ -9,10 +9,11 @@ import (
 )
 
 type pullFlags struct {
-	spaceID   int
-	matchMode string
-	all       bool
-	dryRun    bool
+	spaceID    int
+	matchMode  string
+	all        bool
+	dryRun     bool
+	withImages bool
 }
 
 func newPullCommand() *cobra.Command {
@@ -43,14 +44,21 @@ func newPullCommand() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openTarget(globalOpts.OutDir)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
 			options := pull.Options{
-				Token:     globalOpts.Token,
-				SpaceID:   flags.spaceID,
-				Names:     args,
-				MatchMode: flags.matchMode,
-				All:       flags.all,
-				OutDir:    globalOpts.OutDir,
-				DryRun:    flags.dryRun,
+				Env:        store.env,
+				Token:      globalOpts.Token,
+				SpaceID:    flags.spaceID,
+				Names:      args,
+				MatchMode:  flags.matchMode,
+				All:        flags.all,
+				OutDir:     store.dir,
+				DryRun:     flags.dryRun,
+				WithImages: flags.withImages,
 			}
 
 			result, err := pull.Run(cmd.Context(), options)
@@ -60,11 +68,11 @@ func newPullCommand() *cobra.Command {
 					code = ExitCodeExecution
 				}
 				SetExitCode(code)
-				return err
+				return store.close(err)
 			}
 
 			SetExitCode(result.ExitCode)
-			return nil
+			return store.close(nil)
 		},
 	}
 
@@ -72,6 +80,7 @@ func newPullCommand() *cobra.Command {
 	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Component name matching mode: exact, prefix, glob")
 	cmd.Flags().BoolVar(&flags.all, "all", false, "Pull all components")
 	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing files")
+	cmd.Flags().BoolVar(&flags.withImages, "with-images", false, "Download preset screenshots next to the preset files")
 
 	return cmd
 }
@@ -9,11 +9,20 @@ import (
 )
 
 type pushFlags struct {
-	spaceID   int
-	matchMode string
-	all       bool
-	dryRun    bool
-	dir       string
+	spaceID      int
+	matchMode    string
+	all          bool
+	dryRun       bool
+	dir          string
+	force        bool
+	merge        bool
+	failOn       string
+	strict       bool
+	presetPolicy string
+	uploadImages bool
+	datasources  bool
+	fromRef      string
+	protect      protectFlags
 }
 
 func newPushCommand() *cobra.Command {
@@ -39,6 +48,12 @@ func newPushCommand() *cobra.Command {
 			if !cmd.Flags().Changed("dir") {
 				flags.dir = globalOpts.OutDir
 			}
+			if flags.force && flags.merge {
+				return fmt.Errorf("--force and --merge cannot be combined")
+			}
+			if flags.fromRef != "" && flags.merge {
+				return fmt.Errorf("--merge writes merged files into --dir and cannot be combined with --from-ref")
+			}
 			if globalOpts.Token == "" {
 				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
 			}
@@ -48,14 +63,35 @@ func newPushCommand() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			protected, err := flags.protect.check(cmd, flags.spaceID, flags.dryRun)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			store, err := openSource(cmd.Context(), flags.dir, flags.fromRef)
+			if err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
 			options := push.Options{
-				Token:     globalOpts.Token,
-				SpaceID:   flags.spaceID,
-				Names:     args,
-				MatchMode: flags.matchMode,
-				All:       flags.all,
-				Dir:       flags.dir,
-				DryRun:    flags.dryRun,
+				Env:              store.env,
+				Token:            globalOpts.Token,
+				SpaceID:          flags.spaceID,
+				Names:            args,
+				MatchMode:        flags.matchMode,
+				All:              flags.all,
+				Dir:              store.dir,
+				Revision:         store.revision,
+				DryRun:           flags.dryRun,
+				Force:            flags.force,
+				Merge:            flags.merge,
+				FailOn:           flags.failOn,
+				Strict:           flags.strict,
+				PresetPolicy:     flags.presetPolicy,
+				UploadImages:     flags.uploadImages,
+				WithDatasources:  flags.datasources,
+				Protected:        protected,
+				AllowDestructive: flags.protect.allowDestructive,
 			}
 
 			result, err := push.Run(cmd.Context(), options)
@@ -65,11 +101,11 @@ func newPushCommand() *cobra.Command {
 					code = ExitCodeExecution
 				}
 				SetExitCode(code)
-				return err
+				return store.close(err)
 			}
 
 			SetExitCode(result.ExitCode)
-			return nil
+			return store.close(nil)
 		},
 	}
 
@@ -77,7 +113,16 @@ func newPushCommand() *cobra.Command {
 	cmd.Flags().StringVar(&flags.matchMode, "match", flags.matchMode, "Component name matching mode: exact, prefix, glob")
 	cmd.Flags().BoolVar(&flags.all, "all", false, "Push all components found in the directory")
 	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing to Storyblok")
-	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory containing component schemas to push")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory, archive (.tar, .tar.gz, .tgz, .zip) or - for a bundle on stdin containing component schemas to push")
+	cmd.Flags().StringVar(&flags.fromRef, "from-ref", "", "Read --dir as it is in this git commit, branch or tag instead of the working tree")
+	cmd.Flags().BoolVar(&flags.force, "force", false, "Overwrite components that were changed in the target since the last sync")
+	cmd.Flags().BoolVar(&flags.merge, "merge", false, "Three-way merge schema changes made in the target since the last sync")
+	cmd.Flags().StringVar(&flags.failOn, "fail-on", "", "Refuse to push when a schema change is at least this severe: safe, risky, breaking")
+	cmd.Flags().BoolVar(&flags.strict, "strict", false, "Fail on skipped or unparseable files, orphan presets and unmatched names")
+	cmd.Flags().StringVar(&flags.presetPolicy, "preset-policy", push.PresetPolicyWarn, "Handling of orphan presets and unresolved default presets: warn, fail, detach")
+	cmd.Flags().BoolVar(&flags.uploadImages, "upload-images", false, "Upload preset screenshots to the target space and point presets at the new assets")
+	cmd.Flags().BoolVar(&flags.datasources, "with-datasources", false, "Create or update datasources referenced by the pushed components before pushing them")
+	flags.protect.register(cmd, true)
 
 	return cmd
 }
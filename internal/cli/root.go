@@ -7,6 +7,9 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"sbx/internal/auditlog"
+	"sbx/internal/config"
 )
 
 const (
@@ -22,10 +25,11 @@ const (
 
 var (
 	rootCmd = &cobra.Command{
-		Use:           "sbx",
-		Short:         "Storyblok component sync utility",
-		SilenceUsage:  true,
-		SilenceErrors: true,
+		Use:               "sbx",
+		Short:             "Storyblok component sync utility",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PersistentPreRunE: startAudit,
 	}
 
 	globalOpts GlobalOptions
@@ -38,11 +42,17 @@ type GlobalOptions struct {
 	SourceSpaceID int
 	TargetSpaceID int
 	OutDir        string
+	ConfigPath    string
+	AuditLog      string
 }
 
 // Execute runs the root command tree and returns an exit code for os.Exit.
 func Execute() int {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if auditLog != nil && auditLog.Err() != nil {
+		fmt.Fprintln(os.Stderr, "warning:", auditLog.Err())
+	}
+	if err != nil {
 		// If no exit code was set, surface a generic execution failure.
 		if exitCode == ExitCodeOK {
 			fmt.Fprintln(os.Stderr, err)
@@ -59,16 +69,22 @@ func init() {
 	defaultSource := envInt("SOURCE_SPACE_ID", 0)
 	defaultTarget := envInt("TARGET_SPACE_ID", 0)
 	defaultOut := defaultString(os.Getenv("SBX_OUT_DIR"), "component-schemas/")
+	defaultConfig := defaultString(os.Getenv("SBX_CONFIG"), config.DefaultFileName)
+	defaultAudit := os.Getenv("SBX_AUDIT_LOG")
 
 	globalOpts.Token = defaultToken
 	globalOpts.SourceSpaceID = defaultSource
 	globalOpts.TargetSpaceID = defaultTarget
 	globalOpts.OutDir = defaultOut
+	globalOpts.ConfigPath = defaultConfig
+	globalOpts.AuditLog = defaultAudit
 
 	rootCmd.PersistentFlags().StringVar(&globalOpts.Token, "token", defaultToken, "Storyblok management token (env: SB_MGMT_TOKEN)")
 	rootCmd.PersistentFlags().IntVar(&globalOpts.SourceSpaceID, "source-space", defaultSource, "Source space ID (env: SOURCE_SPACE_ID)")
 	rootCmd.PersistentFlags().IntVar(&globalOpts.TargetSpaceID, "target-space", defaultTarget, "Target space ID (env: TARGET_SPACE_ID)")
-	rootCmd.PersistentFlags().StringVar(&globalOpts.OutDir, "out", defaultOut, "Output directory for component schemas (env: SBX_OUT_DIR)")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.OutDir, "out", defaultOut, "Output directory for component schemas; pull and push commands also take an archive or - for a bundle stream (env: SBX_OUT_DIR)")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.ConfigPath, "config", defaultConfig, "Project configuration file (env: SBX_CONFIG)")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.AuditLog, "audit-log", defaultAudit, "Audit log of writes to Storyblok, or off (env: SBX_AUDIT_LOG; default: audit.path in the config, then "+auditlog.DefaultPath+")")
 
 	if defaultToken != "" {
 		if tokenFlag := rootCmd.PersistentFlags().Lookup("token"); tokenFlag != nil {
@@ -79,6 +95,25 @@ func init() {
 	// Inject subcommands
 	rootCmd.AddCommand(newPullCommand())
 	rootCmd.AddCommand(newPushCommand())
+	rootCmd.AddCommand(newPlanCommand())
+	rootCmd.AddCommand(newApplyCommand())
+	rootCmd.AddCommand(newApproveCommand())
+	rootCmd.AddCommand(newPullDatasourcesCommand())
+	rootCmd.AddCommand(newPushDatasourcesCommand())
+	rootCmd.AddCommand(newPullStoriesCommand())
+	rootCmd.AddCommand(newPushStoriesCommand())
+	rootCmd.AddCommand(newPullAssetsCommand())
+	rootCmd.AddCommand(newPushAssetsCommand())
+	rootCmd.AddCommand(newBackupCommand())
+	rootCmd.AddCommand(newRestoreSpaceCommand())
+	rootCmd.AddCommand(newDriftCommand())
+	rootCmd.AddCommand(newAuditCommand())
+	rootCmd.AddCommand(newDiffCommand())
+	rootCmd.AddCommand(newImpactCommand())
+	rootCmd.AddCommand(newMigrateCommand())
+	rootCmd.AddCommand(newGenerateCommand())
+	rootCmd.AddCommand(newValidateCommand())
+	rootCmd.AddCommand(newLintCommand())
 	rootCmd.AddCommand(newCompletionCommand())
 }
 
@@ -120,6 +155,13 @@ func maskSecret(secret string) string {
 	return masked + secret[len(secret)-4:]
 }
 
+// loadConfig reads the project configuration. The default file is optional; a path given
+// via --config or SBX_CONFIG must exist.
+func loadConfig(cmd *cobra.Command) (config.Config, error) {
+	required := cmd.Flags().Changed("config") || strings.TrimSpace(os.Getenv("SBX_CONFIG")) != ""
+	return config.Load(globalOpts.ConfigPath, required)
+}
+
 // Global returns a snapshot of global options for consumers.
 func Global() GlobalOptions {
 	return globalOpts
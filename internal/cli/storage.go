@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"sbx/internal/app/engine"
+	"sbx/internal/fsutil"
+	"sbx/internal/gitref"
+	"sbx/internal/report"
+)
+
+// bundleStream is the --out or --dir value for a bundle on stdin or stdout.
+const bundleStream = "-"
+
+// storage is where a command reads or writes schema files, resolved from its --out or
+// --dir value: "-" is a bundle stream, a .tar, .tar.gz, .tgz or .zip path an archive and
+// anything else a local directory.
+type storage struct {
+	env engine.Env
+	dir string
+	// name describes the backend in errors.
+	name string
+	// revision is the commit a --from-ref source was read from.
+	revision string
+	// save persists an in-memory backend after the command ran; err is the command's.
+	save func(err error) error
+}
+
+// openSource resolves a location the command reads from; "-" reads a bundle from stdin.
+// Files the command writes, such as sbx.lock, are saved back into archives but not into
+// bundles. With a ref, the directory is read as it is in that commit.
+func openSource(ctx context.Context, spec, ref string) (*storage, error) {
+	if ref != "" {
+		if spec == bundleStream || fsutil.IsArchive(spec) {
+			return nil, fmt.Errorf("--from-ref reads a directory of the repository, not %s", spec)
+		}
+		snapshot, err := gitref.Open(ctx, ref, spec)
+		if err != nil {
+			return nil, err
+		}
+		return &storage{env: engine.Env{FS: snapshot.FS}, dir: spec, revision: snapshot.SHA}, nil
+	}
+	switch {
+	case spec == bundleStream:
+		mem, err := fsutil.ReadBundle(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return &storage{env: engine.Env{FS: mem}, dir: ".", name: "bundle on stdin"}, nil
+	case fsutil.IsArchive(spec):
+		mem, err := fsutil.ReadArchive(spec)
+		if err != nil {
+			return nil, err
+		}
+		return archiveStorage(spec, mem), nil
+	}
+	return &storage{dir: spec}, nil
+}
+
+// openTarget resolves a location the command writes to; "-" writes a bundle to stdout,
+// and the command's own output moves to stderr. Archives that exist are updated.
+func openTarget(spec string) (*storage, error) {
+	switch {
+	case spec == bundleStream:
+		mem := fsutil.NewMemFS()
+		return &storage{
+			env:  engine.Env{FS: mem, Observer: report.NewConsole(os.Stderr, os.Stderr)},
+			dir:  ".",
+			name: "bundle on stdout",
+			save: func(err error) error {
+				// A partial bundle would let the next command in the pipe run on it.
+				if err != nil {
+					return nil
+				}
+				return fsutil.WriteBundle(os.Stdout, mem)
+			},
+		}, nil
+	case fsutil.IsArchive(spec):
+		mem, err := fsutil.ReadArchive(spec)
+		if errors.Is(err, fs.ErrNotExist) {
+			mem, err = fsutil.NewMemFS(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return archiveStorage(spec, mem), nil
+	}
+	return &storage{dir: spec}, nil
+}
+
+func archiveStorage(path string, mem *fsutil.MemFS) *storage {
+	return &storage{
+		env:  engine.Env{FS: mem},
+		dir:  ".",
+		name: path,
+		save: func(error) error {
+			if !mem.Changed() {
+				return nil
+			}
+			return fsutil.WriteArchive(path, mem)
+		},
+	}
+}
+
+// close saves the backend and returns err, or the save error when the command itself
+// succeeded.
+func (s *storage) close(err error) error {
+	if s.save == nil {
+		return err
+	}
+	if saveErr := s.save(err); saveErr != nil {
+		SetExitCode(ExitCodeExecution)
+		if err == nil {
+			return fmt.Errorf("save %s: %w", s.name, saveErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/stories"
+)
+
+type storyFlags struct {
+	spaceID     int
+	startsWith  string
+	contentType string
+	tag         string
+	dryRun      bool
+	dir         string
+	publish     bool
+	protect     protectFlags
+}
+
+func (f storyFlags) filter() stories.Filter {
+	return stories.Filter{StartsWith: f.startsWith, ContentType: f.contentType, Tag: f.tag}
+}
+
+func addStoryFilterFlags(cmd *cobra.Command, flags *storyFlags) {
+	cmd.Flags().StringVar(&flags.startsWith, "starts-with", "", "Only stories whose full slug starts with this folder path")
+	cmd.Flags().StringVar(&flags.contentType, "content-type", "", "Only stories whose content is this component")
+	cmd.Flags().StringVar(&flags.tag, "tag", "", "Only stories carrying this tag")
+}
+
+func newPullStoriesCommand() *cobra.Command {
+	flags := storyFlags{spaceID: globalOpts.SourceSpaceID}
+
+	cmd := &cobra.Command{
+		Use:   "pull-stories",
+		Short: "Export stories and their folders from a Storyblok space",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.SourceSpaceID
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or SOURCE_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := stories.PullOptions{
+				Token:   globalOpts.Token,
+				SpaceID: flags.spaceID,
+				Filter:  flags.filter(),
+				OutDir:  globalOpts.OutDir,
+				DryRun:  flags.dryRun,
+			}
+
+			result, err := stories.Pull(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to export from (defaults to SOURCE_SPACE_ID)")
+	addStoryFilterFlags(cmd, &flags)
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing files")
+
+	return cmd
+}
+
+func newPushStoriesCommand() *cobra.Command {
+	flags := storyFlags{
+		spaceID: globalOpts.TargetSpaceID,
+		dir:     globalOpts.OutDir,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "push-stories",
+		Short: "Import exported stories into a Storyblok space",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("space") {
+				flags.spaceID = globalOpts.TargetSpaceID
+			}
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			if globalOpts.Token == "" {
+				return fmt.Errorf("management token is required (flag --token or SB_MGMT_TOKEN)")
+			}
+			if flags.spaceID <= 0 {
+				return fmt.Errorf("a valid space ID is required (flag --space or TARGET_SPACE_ID)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := flags.protect.check(cmd, flags.spaceID, flags.dryRun); err != nil {
+				SetExitCode(ExitCodeInvalid)
+				return err
+			}
+			options := stories.PushOptions{
+				Token:   globalOpts.Token,
+				SpaceID: flags.spaceID,
+				Dir:     flags.dir,
+				Filter:  flags.filter(),
+				Publish: flags.publish,
+				DryRun:  flags.dryRun,
+			}
+
+			result, err := stories.Push(cmd.Context(), options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.spaceID, "space", flags.spaceID, "Space ID to import into (defaults to TARGET_SPACE_ID)")
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Schema directory whose stories/ folder holds the export")
+	addStoryFilterFlags(cmd, &flags)
+	cmd.Flags().BoolVar(&flags.publish, "publish", false, "Publish imported stories that were published in the export")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print planned actions without writing to Storyblok")
+	flags.protect.register(cmd, false)
+
+	return cmd
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"sbx/internal/app/validate"
+)
+
+type validateFlags struct {
+	dir    string
+	format string
+}
+
+func newValidateCommand() *cobra.Command {
+	flags := validateFlags{
+		dir:    globalOpts.OutDir,
+		format: validate.FormatText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check local component and preset files for structural and semantic errors",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("dir") {
+				flags.dir = globalOpts.OutDir
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := validate.Options{
+				Dir:    flags.dir,
+				Format: flags.format,
+			}
+
+			result, err := validate.Run(options)
+			if err != nil {
+				code := result.ExitCode
+				if code == 0 {
+					code = ExitCodeExecution
+				}
+				SetExitCode(code)
+				return err
+			}
+
+			SetExitCode(result.ExitCode)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.dir, "dir", flags.dir, "Directory containing component schemas")
+	cmd.Flags().StringVar(&flags.format, "format", flags.format, "Output format: text, json")
+
+	return cmd
+}
@@ -0,0 +1,223 @@
+package codegen
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sbx/internal/storyblok"
+)
+
+// testInput is a small schema set: a page whose body whitelists a known component,
+// a component missing from the set and an option field.
+func testInput() Input {
+	return Input{Components: []storyblok.Component{
+		{
+			Name:        "page",
+			DisplayName: "Page",
+			Schema: map[string]any{
+				"title": map[string]any{"type": "text", "required": true, "max_length": float64(80), "pos": float64(0)},
+				"theme": map[string]any{"type": "option", "pos": float64(1), "options": []any{
+					map[string]any{"name": "Light", "value": "light"},
+					map[string]any{"name": "Dark", "value": "dark"},
+				}},
+				"body": map[string]any{
+					"type":                "bloks",
+					"pos":                 float64(2),
+					"restrict_components": true,
+					"component_whitelist": []any{"hero", "legacy_banner"},
+				},
+				"layout": map[string]any{"type": "tab"},
+			},
+		},
+		{
+			Name: "hero",
+			Schema: map[string]any{
+				"headline": map[string]any{"type": "text"},
+				"image":    map[string]any{"type": "asset"},
+			},
+		},
+	}}
+}
+
+func TestTypeScript(t *testing.T) {
+	out, err := TypeScript(testInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"export interface PageStoryblok {",
+		`  component: "page";`,
+		"  title: string;",
+		`  theme?: "light" | "dark" | "";`,
+		"  body?: (HeroStoryblok | StoryblokComponent)[];",
+		"export interface HeroStoryblok {",
+		"  image?: StoryblokAsset;",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "layout") {
+		t.Errorf("output declares the tab field:\n%s", out)
+	}
+}
+
+func TestGo(t *testing.T) {
+	out, err := Go(testInput(), "content")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "content.go", out, 0)
+	if err != nil {
+		t.Fatalf("parse generated code: %v\n%s", err, out)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("content", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("type-check generated code: %v\n%s", err, out)
+	}
+
+	for _, want := range []string{
+		"type Page struct {",
+		"Title string `json:\"title\"`",
+		"Theme PageThemeOption `json:\"theme,omitempty\"`",
+		"PageThemeOptionLight PageThemeOption = \"light\"",
+		"Body PageBodyBloks `json:\"body,omitempty\"`",
+		"func (*Hero) isPageBodyBlok() {}",
+		"type PageBodyBlokRaw struct {",
+		`case "legacy_banner":`,
+		"Image *StoryblokAsset `json:\"image,omitempty\"`",
+	} {
+		// gofmt aligns struct fields, so compare with whitespace collapsed.
+		if !strings.Contains(collapse(string(out)), want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func TestGoNameCollisions(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []storyblok.Component
+		want       string
+	}{
+		{
+			name:       "components",
+			components: []storyblok.Component{{Name: "hero_banner"}, {Name: "hero-banner"}},
+			want:       "both generate the Go type HeroBanner",
+		},
+		{
+			name: "enum and component",
+			components: []storyblok.Component{
+				{Name: "hero", Schema: map[string]any{"size": map[string]any{"type": "option", "options": []any{
+					map[string]any{"name": "S", "value": "s"},
+				}}}},
+				{Name: "hero_size_option"},
+			},
+			want: "both generate the Go identifier HeroSizeOption",
+		},
+		{
+			name: "union and component",
+			components: []storyblok.Component{
+				{Name: "page", Schema: map[string]any{"body": map[string]any{
+					"type": "bloks", "restrict_components": true, "component_whitelist": []any{"hero"},
+				}}},
+				{Name: "hero"},
+				{Name: "page_body_blok"},
+			},
+			want: "both generate the Go identifier PageBodyBlok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Go(Input{Components: tt.components}, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Go() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaBundle(t *testing.T) {
+	out, err := JSONSchemaBundle(testInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("decode bundle: %v", err)
+	}
+	if doc["$schema"] != JSONSchemaDraft {
+		t.Errorf("$schema = %v", doc["$schema"])
+	}
+	wantVariants := []any{
+		map[string]any{"$ref": "#/$defs/hero"},
+		map[string]any{"$ref": "#/$defs/page"},
+	}
+	if !reflect.DeepEqual(doc["oneOf"], wantVariants) {
+		t.Errorf("oneOf = %v, want %v", doc["oneOf"], wantVariants)
+	}
+
+	page := doc["$defs"].(map[string]any)["page"].(map[string]any)
+	properties := page["properties"].(map[string]any)
+	tests := []struct {
+		field string
+		want  map[string]any
+	}{
+		{"component", map[string]any{"const": "page"}},
+		{"title", map[string]any{"type": "string", "maxLength": float64(80), "minLength": float64(1)}},
+		{"theme", map[string]any{"type": "string", "enum": []any{"light", "dark", ""}}},
+		{"body", map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/hero"}}},
+	}
+	for _, tt := range tests {
+		if got := properties[tt.field]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("page.%s = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+	if want := []any{"_uid", "component", "title"}; !reflect.DeepEqual(page["required"], want) {
+		t.Errorf("page.required = %v, want %v", page["required"], want)
+	}
+	if page["title"] != "Page" {
+		t.Errorf("page.title = %v, want Page", page["title"])
+	}
+}
+
+func TestJSONSchemaFiles(t *testing.T) {
+	files, err := JSONSchemaFiles(testInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(files["page.schema.json"], &doc); err != nil {
+		t.Fatalf("decode page.schema.json: %v", err)
+	}
+	if doc["$id"] != "page.schema.json" {
+		t.Errorf("$id = %v", doc["$id"])
+	}
+	body := doc["properties"].(map[string]any)["body"].(map[string]any)
+	if want := map[string]any{"$ref": "hero.schema.json"}; !reflect.DeepEqual(body["items"], want) {
+		t.Errorf("body.items = %v, want %v", body["items"], want)
+	}
+}
+
+func TestUnresolved(t *testing.T) {
+	want := []string{`field "body" of component "page" whitelists unknown component "legacy_banner"; its bloks are generated untyped`}
+	if got := testInput().Unresolved(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unresolved() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,363 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// goPrelude declares the shapes shared by Storyblok field types and the blok dispatcher helpers.
+const goPrelude = `// StoryblokAsset is the value of asset fields.
+type StoryblokAsset struct {
+	ID        *int   ` + "`json:\"id\"`" + `
+	Filename  string ` + "`json:\"filename\"`" + `
+	Alt       string ` + "`json:\"alt,omitempty\"`" + `
+	Title     string ` + "`json:\"title,omitempty\"`" + `
+	Copyright string ` + "`json:\"copyright,omitempty\"`" + `
+	Focus     string ` + "`json:\"focus,omitempty\"`" + `
+	Name      string ` + "`json:\"name,omitempty\"`" + `
+	Fieldtype string ` + "`json:\"fieldtype,omitempty\"`" + `
+}
+
+// StoryblokMultilink is the value of multilink fields.
+type StoryblokMultilink struct {
+	ID        string         ` + "`json:\"id,omitempty\"`" + `
+	URL       string         ` + "`json:\"url,omitempty\"`" + `
+	CachedURL string         ` + "`json:\"cached_url,omitempty\"`" + `
+	Linktype  string         ` + "`json:\"linktype,omitempty\"`" + `
+	Target    string         ` + "`json:\"target,omitempty\"`" + `
+	Anchor    string         ` + "`json:\"anchor,omitempty\"`" + `
+	Email     string         ` + "`json:\"email,omitempty\"`" + `
+	Story     map[string]any ` + "`json:\"story,omitempty\"`" + `
+}
+
+// StoryblokRichtext is a node of a richtext document.
+type StoryblokRichtext struct {
+	Type    string              ` + "`json:\"type\"`" + `
+	Content []StoryblokRichtext ` + "`json:\"content,omitempty\"`" + `
+	Marks   []StoryblokRichtext ` + "`json:\"marks,omitempty\"`" + `
+	Attrs   map[string]any      ` + "`json:\"attrs,omitempty\"`" + `
+	Text    string              ` + "`json:\"text,omitempty\"`" + `
+}
+
+// StoryblokTableCell is a header or body cell of a table field.
+type StoryblokTableCell struct {
+	UID       string ` + "`json:\"_uid\"`" + `
+	Value     string ` + "`json:\"value,omitempty\"`" + `
+	Component string ` + "`json:\"component\"`" + `
+}
+
+// StoryblokTableRow is a body row of a table field.
+type StoryblokTableRow struct {
+	UID       string               ` + "`json:\"_uid\"`" + `
+	Body      []StoryblokTableCell ` + "`json:\"body\"`" + `
+	Component string               ` + "`json:\"component\"`" + `
+}
+
+// StoryblokTable is the value of table fields.
+type StoryblokTable struct {
+	Thead []StoryblokTableCell ` + "`json:\"thead\"`" + `
+	Tbody []StoryblokTableRow  ` + "`json:\"tbody\"`" + `
+}
+
+// StoryblokPlugin is the value of custom plugin fields.
+type StoryblokPlugin map[string]any
+
+// Blok is implemented by every generated component.
+type Blok interface {
+	ComponentName() string
+}
+
+// UnknownBlok holds a blok whose component was unknown at generation time.
+type UnknownBlok map[string]any
+
+// ComponentName returns the component discriminator.
+func (b UnknownBlok) ComponentName() string {
+	name, _ := b["component"].(string)
+	return name
+}
+
+// Bloks is a bloks field accepting any component.
+type Bloks []Blok
+
+// UnmarshalJSON decodes each blok into its concrete type.
+func (b *Bloks) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	out := make(Bloks, 0, len(raws))
+	for _, raw := range raws {
+		blok, err := UnmarshalBlok(raw)
+		if err != nil {
+			return err
+		}
+		out = append(out, blok)
+	}
+	*b = out
+	return nil
+}
+`
+
+// goReserved are prelude identifiers component types must not shadow.
+var goReserved = map[string]struct{}{
+	"StoryblokAsset": {}, "StoryblokMultilink": {}, "StoryblokRichtext": {}, "StoryblokTableCell": {},
+	"StoryblokTableRow": {}, "StoryblokTable": {}, "StoryblokPlugin": {}, "Blok": {}, "UnknownBlok": {},
+	"Bloks": {}, "UnmarshalBlok": {},
+}
+
+// GoTypeName returns the struct name generated for a component.
+func GoTypeName(component string) string {
+	name := PascalCase(component)
+	if _, ok := goReserved[name]; ok {
+		name += "Component"
+	}
+	return name
+}
+
+// Go renders a struct per component with option enums, per-field blok unions and a
+// dispatcher decoding bloks by their component discriminator.
+func Go(in Input, pkg string) ([]byte, error) {
+	if pkg == "" {
+		pkg = "storyblok"
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid Go package name %q", pkg)
+	}
+
+	components := sortedComponents(in)
+	if err := checkTypeNames(components, GoTypeName, "Go type"); err != nil {
+		return nil, err
+	}
+	names := newGoNames()
+	for _, component := range components {
+		names.declare(GoTypeName(component.Name), fmt.Sprintf("component %q", component.Name))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %s\n\n", generatedHeader)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	buf.WriteString("import (\n\t\"encoding/json\"\n\t\"fmt\"\n)\n\n")
+	buf.WriteString(goPrelude)
+
+	// UnmarshalBlok dispatches on the component discriminator.
+	buf.WriteString("\n// UnmarshalBlok decodes a blok into the struct generated for its component.\n")
+	buf.WriteString("func UnmarshalBlok(data []byte) (Blok, error) {\n")
+	buf.WriteString("\tvar probe struct {\n\t\tComponent string `json:\"component\"`\n\t}\n")
+	buf.WriteString("\tif err := json.Unmarshal(data, &probe); err != nil {\n\t\treturn nil, err\n\t}\n")
+	buf.WriteString("\tswitch probe.Component {\n")
+	for _, component := range components {
+		fmt.Fprintf(&buf, "\tcase %s:\n", strconv.Quote(component.Name))
+		fmt.Fprintf(&buf, "\t\tvar v %s\n", GoTypeName(component.Name))
+		buf.WriteString("\t\tif err := json.Unmarshal(data, &v); err != nil {\n\t\t\treturn nil, fmt.Errorf(\"decode %s: %w\", probe.Component, err)\n\t\t}\n")
+		buf.WriteString("\t\treturn &v, nil\n")
+	}
+	buf.WriteString("\tdefault:\n\t\tvar v UnknownBlok\n\t\tif err := json.Unmarshal(data, &v); err != nil {\n\t\t\treturn nil, err\n\t\t}\n\t\treturn v, nil\n\t}\n}\n")
+
+	for _, component := range components {
+		writeGoComponent(&buf, names, component.Name, in.Fields(component), component.DisplayName)
+	}
+	if names.err != nil {
+		return nil, names.err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated Go code: %w", err)
+	}
+	return formatted, nil
+}
+
+// goNames records the owner of every identifier the generated file declares, to refuse
+// schemas in which two of them generate the same one, such as the enum of the size field
+// of hero and a component named hero_size_option.
+type goNames struct {
+	owners map[string]string
+	err    error
+}
+
+func newGoNames() *goNames {
+	owners := make(map[string]string, len(goReserved))
+	for name := range goReserved {
+		owners[name] = "the generated helpers"
+	}
+	return &goNames{owners: owners}
+}
+
+// declare records name as declared by owner and keeps the first collision as err.
+func (n *goNames) declare(name, owner string) {
+	other, taken := n.owners[name]
+	switch {
+	case !taken:
+		n.owners[name] = owner
+	case other != owner && n.err == nil:
+		n.err = fmt.Errorf("%s and %s both generate the Go identifier %s; rename one of them", other, owner, name)
+	}
+}
+
+func writeGoComponent(buf *bytes.Buffer, names *goNames, componentName string, fields []Field, displayName string) {
+	typeName := GoTypeName(componentName)
+	var extra bytes.Buffer
+
+	used := map[string]struct{}{"UID": {}, "Component": {}, "Editable": {}}
+	buf.WriteString("\n")
+	if displayName != "" && displayName != componentName {
+		fmt.Fprintf(buf, "// %s models the %q component (%s).\n", typeName, componentName, goComment(displayName))
+	} else {
+		fmt.Fprintf(buf, "// %s models the %q component.\n", typeName, componentName)
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", typeName)
+	buf.WriteString("\tUID string `json:\"_uid\"`\n")
+	buf.WriteString("\tComponent string `json:\"component\"`\n")
+	buf.WriteString("\tEditable string `json:\"_editable,omitempty\"`\n")
+	for _, field := range fields {
+		fieldName := uniqueIdent(PascalCase(field.Name), used)
+		owner := fmt.Sprintf("field %q of component %q", field.Name, componentName)
+		goType := goFieldType(&extra, names, owner, typeName, fieldName, field)
+		if field.Description != "" {
+			fmt.Fprintf(buf, "\t// %s\n", goComment(field.Description))
+		}
+		tag := field.Name
+		if !field.Required {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(buf, "\t%s %s `json:%s`\n", fieldName, goType, strconv.Quote(tag))
+	}
+	buf.WriteString("}\n\n")
+	fmt.Fprintf(buf, "// ComponentName returns the component discriminator.\n")
+	fmt.Fprintf(buf, "func (*%s) ComponentName() string { return %s }\n", typeName, strconv.Quote(componentName))
+	buf.Write(extra.Bytes())
+}
+
+// goFieldType returns the Go type of a field, writing enum and union declarations to extra.
+func goFieldType(extra *bytes.Buffer, names *goNames, owner, typeName, fieldName string, field Field) string {
+	switch field.Type {
+	case "text", "textarea", "markdown", "datetime", "number":
+		// Storyblok stores number fields as numeric strings.
+		return "string"
+	case "richtext":
+		return "*StoryblokRichtext"
+	case "boolean":
+		return "bool"
+	case "option", "options":
+		if len(field.Options) == 0 {
+			if field.Type == "options" {
+				return "[]string"
+			}
+			return "string"
+		}
+		enum := typeName + fieldName + "Option"
+		writeGoEnum(extra, names, owner, enum, field)
+		if field.Type == "options" {
+			return "[]" + enum
+		}
+		return enum
+	case "multilink":
+		return "*StoryblokMultilink"
+	case "asset":
+		return "*StoryblokAsset"
+	case "multiasset":
+		return "[]StoryblokAsset"
+	case "bloks":
+		if len(field.Whitelist) == 0 {
+			return "Bloks"
+		}
+		union := typeName + fieldName + "Blok"
+		writeGoUnion(extra, names, owner, union, field)
+		return union + "s"
+	case "table":
+		return "*StoryblokTable"
+	case "custom", "plugin":
+		return "StoryblokPlugin"
+	}
+	return "json.RawMessage"
+}
+
+func writeGoEnum(extra *bytes.Buffer, names *goNames, owner, enum string, field Field) {
+	names.declare(enum, owner)
+	fmt.Fprintf(extra, "\n// %s enumerates the options of the %q field.\n", enum, field.Name)
+	fmt.Fprintf(extra, "type %s string\n\n", enum)
+	extra.WriteString("const (\n")
+	used := make(map[string]struct{})
+	for i, value := range field.Options {
+		suffix := PascalCase(value)
+		if value == "" || suffix == "X" {
+			suffix = fmt.Sprintf("Value%d", i+1)
+		}
+		name := uniqueIdent(enum+suffix, used)
+		names.declare(name, owner)
+		fmt.Fprintf(extra, "\t%s %s = %s\n", name, enum, strconv.Quote(value))
+	}
+	extra.WriteString(")\n")
+}
+
+func writeGoUnion(extra *bytes.Buffer, names *goNames, owner, union string, field Field) {
+	marker := "is" + union
+	names.declare(union, owner)
+	names.declare(union+"s", owner)
+	fmt.Fprintf(extra, "\n// %s is implemented by the components allowed in the %q field.\n", union, field.Name)
+	fmt.Fprintf(extra, "type %s interface {\n\tBlok\n\t%s()\n}\n\n", union, marker)
+	for _, component := range field.Whitelist {
+		fmt.Fprintf(extra, "func (*%s) %s() {}\n", GoTypeName(component), marker)
+	}
+
+	// Components the whitelist names but the schema set lacks are kept undecoded.
+	unknown := len(field.UnknownComponents) > 0 || len(field.UnknownGroups) > 0
+	raw := union + "Raw"
+	if unknown {
+		names.declare(raw, owner)
+		fmt.Fprintf(extra, "\n// %s holds a blok of the %q field whose component is not part of the schema set.\n", raw, field.Name)
+		fmt.Fprintf(extra, "type %s struct {\n\tComponent string\n\tRaw json.RawMessage\n}\n\n", raw)
+		extra.WriteString("// ComponentName returns the component discriminator.\n")
+		fmt.Fprintf(extra, "func (b *%s) ComponentName() string { return b.Component }\n\n", raw)
+		fmt.Fprintf(extra, "func (*%s) %s() {}\n\n", raw, marker)
+		extra.WriteString("// MarshalJSON returns the blok as it was decoded.\n")
+		fmt.Fprintf(extra, "func (b *%s) MarshalJSON() ([]byte, error) { return b.Raw, nil }\n", raw)
+	}
+
+	fmt.Fprintf(extra, "\n// %ss decodes the %q field, rejecting components outside its whitelist.\n", union, field.Name)
+	fmt.Fprintf(extra, "type %ss []%s\n\n", union, union)
+	fmt.Fprintf(extra, "// UnmarshalJSON decodes each blok into its concrete type.\n")
+	fmt.Fprintf(extra, "func (b *%ss) UnmarshalJSON(data []byte) error {\n", union)
+	extra.WriteString("\tvar raws []json.RawMessage\n\tif err := json.Unmarshal(data, &raws); err != nil {\n\t\treturn err\n\t}\n")
+	fmt.Fprintf(extra, "\tout := make(%ss, 0, len(raws))\n", union)
+	extra.WriteString("\tfor _, raw := range raws {\n\t\tblok, err := UnmarshalBlok(raw)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n")
+	if unknown {
+		keep := fmt.Sprintf("\t\t\tout = append(out, &%s{Component: other.ComponentName(), Raw: raw})\n\t\t\tcontinue\n", raw)
+		extra.WriteString("\t\tif other, ok := blok.(UnknownBlok); ok {\n")
+		if len(field.UnknownGroups) > 0 {
+			// The components of an unknown group are unknown too, so any of them may appear.
+			extra.WriteString(keep)
+		} else {
+			quoted := make([]string, 0, len(field.UnknownComponents))
+			for _, name := range field.UnknownComponents {
+				quoted = append(quoted, strconv.Quote(name))
+			}
+			fmt.Fprintf(extra, "\t\t\tswitch other.ComponentName() {\n\t\t\tcase %s:\n", strings.Join(quoted, ", "))
+			extra.WriteString(strings.ReplaceAll(keep, "\t\t\t", "\t\t\t\t"))
+			extra.WriteString("\t\t\t}\n")
+		}
+		extra.WriteString("\t\t}\n")
+	}
+	fmt.Fprintf(extra, "\t\tallowed, ok := blok.(%s)\n", union)
+	fmt.Fprintf(extra, "\t\tif !ok {\n\t\t\treturn fmt.Errorf(\"component %%q is not allowed in %s\", blok.ComponentName())\n\t\t}\n", field.Name)
+	extra.WriteString("\t\tout = append(out, allowed)\n\t}\n\t*b = out\n\treturn nil\n}\n")
+}
+
+func uniqueIdent(name string, used map[string]struct{}) string {
+	candidate := name
+	for i := 2; ; i++ {
+		if _, taken := used[candidate]; !taken {
+			used[candidate] = struct{}{}
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+}
+
+func goComment(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,287 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONSchemaDraft is the dialect of generated JSON Schema documents.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaBundleName is the file name and $id of the bundled document.
+const JSONSchemaBundleName = "storyblok.schema.json"
+
+// jsonSchemaShapes describes the values of Storyblok field types shared by every component.
+var jsonSchemaShapes = map[string]any{
+	"StoryblokAsset": map[string]any{
+		"type":     "object",
+		"required": []string{"filename"},
+		"properties": map[string]any{
+			"id":        map[string]any{"type": []string{"integer", "null"}},
+			"filename":  map[string]any{"type": []string{"string", "null"}},
+			"alt":       map[string]any{"type": []string{"string", "null"}},
+			"title":     map[string]any{"type": []string{"string", "null"}},
+			"copyright": map[string]any{"type": []string{"string", "null"}},
+			"focus":     map[string]any{"type": []string{"string", "null"}},
+			"name":      map[string]any{"type": "string"},
+			"fieldtype": map[string]any{"const": "asset"},
+		},
+	},
+	"StoryblokMultilink": map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":         map[string]any{"type": "string"},
+			"url":        map[string]any{"type": "string"},
+			"cached_url": map[string]any{"type": "string"},
+			"linktype":   map[string]any{"enum": []string{"story", "url", "email", "asset"}},
+			"target":     map[string]any{"enum": []string{"_blank", "_self"}},
+			"anchor":     map[string]any{"type": "string"},
+			"email":      map[string]any{"type": "string"},
+			"story":      map[string]any{"type": "object"},
+		},
+	},
+	"StoryblokRichtext": map[string]any{
+		"type":     "object",
+		"required": []string{"type"},
+		"properties": map[string]any{
+			"type":    map[string]any{"type": "string"},
+			"content": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/StoryblokRichtext"}},
+			"marks":   map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/StoryblokRichtext"}},
+			"attrs":   map[string]any{"type": "object"},
+			"text":    map[string]any{"type": "string"},
+		},
+	},
+	"StoryblokTable": map[string]any{
+		"type":     "object",
+		"required": []string{"thead", "tbody"},
+		"properties": map[string]any{
+			"thead": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/StoryblokTableCell"}},
+			"tbody": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":     "object",
+					"required": []string{"_uid", "body"},
+					"properties": map[string]any{
+						"_uid":      map[string]any{"type": "string"},
+						"body":      map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/StoryblokTableCell"}},
+						"component": map[string]any{"const": "_table_row"},
+					},
+				},
+			},
+		},
+	},
+	"StoryblokTableCell": map[string]any{
+		"type":     "object",
+		"required": []string{"_uid"},
+		"properties": map[string]any{
+			"_uid":      map[string]any{"type": "string"},
+			"value":     map[string]any{"type": "string"},
+			"component": map[string]any{"type": "string"},
+		},
+	},
+	"StoryblokPlugin": map[string]any{
+		"type":     "object",
+		"required": []string{"plugin"},
+		"properties": map[string]any{
+			"plugin": map[string]any{"type": "string"},
+		},
+	},
+	"StoryblokBlok": map[string]any{
+		"type":     "object",
+		"required": []string{"_uid", "component"},
+		"properties": map[string]any{
+			"_uid":      map[string]any{"type": "string"},
+			"component": map[string]any{"type": "string"},
+			"_editable": map[string]any{"type": "string"},
+		},
+	},
+}
+
+// JSONSchemaFileName returns the file name of a component's standalone schema document.
+func JSONSchemaFileName(component string) string {
+	return component + ".schema.json"
+}
+
+// JSONSchemaBundle renders a single document defining every component under $defs. The
+// root accepts any component, so story content validates against it directly.
+func JSONSchemaBundle(in Input) ([]byte, error) {
+	defs := make(map[string]any, len(in.Components)+len(jsonSchemaShapes))
+	for name, shape := range jsonSchemaShapes {
+		defs[name] = shape
+	}
+
+	ref := func(component string) string { return "#/$defs/" + jsonPointerEscape(component) }
+	components := sortedComponents(in)
+	variants := make([]any, 0, len(components))
+	for _, component := range components {
+		if _, clash := defs[component.Name]; clash {
+			return nil, fmt.Errorf("component %q clashes with a shared definition name", component.Name)
+		}
+		defs[component.Name] = componentJSONSchema(component.Name, in.Fields(component), component.DisplayName, ref)
+		variants = append(variants, map[string]any{"$ref": ref(component.Name)})
+	}
+
+	doc := map[string]any{
+		"$schema":     JSONSchemaDraft,
+		"$id":         JSONSchemaBundleName,
+		"description": generatedHeader,
+		"oneOf":       variants,
+		"$defs":       defs,
+	}
+	return encodeJSONSchema(doc)
+}
+
+// JSONSchemaFiles renders one standalone document per component keyed by file name.
+// Nested bloks reference the sibling document of the allowed component.
+func JSONSchemaFiles(in Input) (map[string][]byte, error) {
+	ref := func(component string) string { return JSONSchemaFileName(component) }
+	files := make(map[string][]byte, len(in.Components))
+	for _, component := range sortedComponents(in) {
+		doc := componentJSONSchema(component.Name, in.Fields(component), component.DisplayName, ref)
+		doc["$schema"] = JSONSchemaDraft
+		doc["$id"] = JSONSchemaFileName(component.Name)
+		doc["description"] = generatedHeader
+		doc["$defs"] = jsonSchemaShapes
+
+		data, err := encodeJSONSchema(doc)
+		if err != nil {
+			return nil, fmt.Errorf("encode schema for %s: %w", component.Name, err)
+		}
+		files[JSONSchemaFileName(component.Name)] = data
+	}
+	return files, nil
+}
+
+// componentJSONSchema describes one component's content object. Unknown properties are
+// allowed because Storyblok adds bookkeeping keys to stored content.
+func componentJSONSchema(name string, fields []Field, displayName string, ref func(string) string) map[string]any {
+	properties := map[string]any{
+		"_uid":      map[string]any{"type": "string"},
+		"component": map[string]any{"const": name},
+		"_editable": map[string]any{"type": "string"},
+	}
+	required := []string{"_uid", "component"}
+	for _, field := range fields {
+		properties[field.Name] = fieldJSONSchema(field, ref)
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+
+	doc := map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	if displayName != "" && displayName != name {
+		doc["title"] = displayName
+	}
+	return doc
+}
+
+func fieldJSONSchema(field Field, ref func(string) string) map[string]any {
+	var schema map[string]any
+	switch field.Type {
+	case "text", "textarea", "markdown", "datetime", "number":
+		// Storyblok stores number fields as numeric strings.
+		schema = map[string]any{"type": "string"}
+		if field.MaxLength > 0 {
+			schema["maxLength"] = field.MaxLength
+		}
+		if field.Regex != "" {
+			schema["pattern"] = field.Regex
+		}
+		if field.Required {
+			schema["minLength"] = 1
+		}
+	case "richtext":
+		schema = map[string]any{"$ref": "#/$defs/StoryblokRichtext"}
+	case "boolean":
+		schema = map[string]any{"type": "boolean"}
+	case "option":
+		schema = map[string]any{"type": "string"}
+		if len(field.Options) > 0 {
+			values := append([]string{}, field.Options...)
+			if !field.Required {
+				values = append(values, "")
+			}
+			schema["enum"] = dedupe(values)
+		}
+	case "options":
+		items := map[string]any{"type": "string"}
+		if len(field.Options) > 0 {
+			items["enum"] = dedupe(field.Options)
+		}
+		schema = map[string]any{"type": "array", "items": items}
+		if field.Required {
+			schema["minItems"] = 1
+		}
+	case "multilink":
+		schema = map[string]any{"$ref": "#/$defs/StoryblokMultilink"}
+	case "asset":
+		schema = map[string]any{"$ref": "#/$defs/StoryblokAsset"}
+	case "multiasset":
+		schema = map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/StoryblokAsset"}}
+	case "bloks":
+		var items map[string]any
+		switch len(field.Whitelist) {
+		case 0:
+			items = map[string]any{"$ref": "#/$defs/StoryblokBlok"}
+		case 1:
+			items = map[string]any{"$ref": ref(field.Whitelist[0])}
+		default:
+			variants := make([]any, 0, len(field.Whitelist))
+			for _, component := range field.Whitelist {
+				variants = append(variants, map[string]any{"$ref": ref(component)})
+			}
+			items = map[string]any{"oneOf": variants}
+		}
+		schema = map[string]any{"type": "array", "items": items}
+		if n := intValue(field.Def["minimum"]); n > 0 {
+			schema["minItems"] = n
+		}
+		if n := intValue(field.Def["maximum"]); n > 0 {
+			schema["maxItems"] = n
+		}
+		if field.Required && schema["minItems"] == nil {
+			schema["minItems"] = 1
+		}
+	case "table":
+		schema = map[string]any{"$ref": "#/$defs/StoryblokTable"}
+	case "custom", "plugin":
+		schema = map[string]any{"$ref": "#/$defs/StoryblokPlugin"}
+	default:
+		schema = map[string]any{}
+	}
+	if field.Description != "" {
+		schema["description"] = field.Description
+	}
+	return schema
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, dup := seen[value]; dup {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	return out
+}
+
+// jsonPointerEscape escapes a $defs key for use in a JSON pointer fragment.
+func jsonPointerEscape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func encodeJSONSchema(doc map[string]any) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
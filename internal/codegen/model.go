@@ -0,0 +1,281 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sbx/internal/storyblok"
+)
+
+// Input is the schema set code is generated from.
+type Input struct {
+	Components []storyblok.Component
+	Presets    []storyblok.ComponentPreset
+}
+
+// Field is a normalised schema field definition.
+type Field struct {
+	Name      string
+	Type      string
+	Required  bool
+	Pos       int
+	MaxLength int
+	Regex     string
+	Plugin    string
+	// Options holds the allowed values of option/options fields sourced from the schema itself.
+	Options []string
+	// Whitelist holds the component names a bloks field may contain; nil means any.
+	Whitelist []string
+	// UnknownComponents and UnknownGroups hold the whitelist entries of a restricted bloks
+	// field that match no component of the input. Generated types accept bloks of those
+	// components, or of any component outside the input for unknown groups, untyped.
+	UnknownComponents []string
+	UnknownGroups     []string
+	Description       string
+	Def               map[string]any
+}
+
+// nonContentTypes are schema entries that group fields in the editor and hold no content.
+var nonContentTypes = map[string]struct{}{
+	"tab":     {},
+	"section": {},
+}
+
+// sortedComponents returns components ordered by name.
+func sortedComponents(in Input) []storyblok.Component {
+	components := make([]storyblok.Component, len(in.Components))
+	copy(components, in.Components)
+	sort.Slice(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+	return components
+}
+
+// Fields returns the content fields of a component ordered by position then name.
+func (in Input) Fields(component storyblok.Component) []Field {
+	fields := make([]Field, 0, len(component.Schema))
+	for name, raw := range component.Schema {
+		def, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		fieldType, _ := def["type"].(string)
+		if _, skip := nonContentTypes[fieldType]; skip {
+			continue
+		}
+		field := Field{
+			Name:        name,
+			Type:        fieldType,
+			Required:    truthy(def["required"]),
+			Pos:         intValue(def["pos"]),
+			MaxLength:   intValue(def["max_length"]),
+			Regex:       stringValue(def["regex"]),
+			Plugin:      stringValue(def["field_type"]),
+			Description: stringValue(def["description"]),
+			Def:         def,
+		}
+		if fieldType == "option" || fieldType == "options" {
+			source := stringValue(def["source"])
+			if source == "" || source == "self" {
+				field.Options = optionValues(def["options"])
+			}
+		}
+		if fieldType == "bloks" {
+			field.Whitelist, field.UnknownComponents, field.UnknownGroups = in.whitelist(def)
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Pos != fields[j].Pos {
+			return fields[i].Pos < fields[j].Pos
+		}
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+// whitelist resolves the components a bloks field accepts from its component and
+// group whitelists, together with the entries that match no component of the input.
+// Unrestricted fields, and fields whose entries all match nothing, return a nil whitelist.
+func (in Input) whitelist(def map[string]any) (names, unknownComponents, unknownGroups []string) {
+	// restrict_type switches the editor between the two whitelists; honour whichever is populated.
+	if !truthy(def["restrict_components"]) {
+		return nil, nil, nil
+	}
+	found := make(map[string]struct{})
+	known := make(map[string]string, len(in.Components))
+	for _, component := range in.Components {
+		known[strings.ToLower(component.Name)] = component.Name
+	}
+	for _, name := range stringList(def["component_whitelist"]) {
+		if canonical, ok := known[strings.ToLower(name)]; ok {
+			found[canonical] = struct{}{}
+		} else {
+			unknownComponents = append(unknownComponents, name)
+		}
+	}
+	for _, group := range stringList(def["component_group_whitelist"]) {
+		key := strings.ToLower(group)
+		matched := false
+		for _, component := range in.Components {
+			byUUID := component.ComponentGroupUUID != "" && strings.ToLower(component.ComponentGroupUUID) == key
+			byName := component.ComponentGroupName != "" && strings.ToLower(component.ComponentGroupName) == key
+			if byUUID || byName {
+				found[component.Name] = struct{}{}
+				matched = true
+			}
+		}
+		if !matched {
+			unknownGroups = append(unknownGroups, group)
+		}
+	}
+	sort.Strings(unknownComponents)
+	sort.Strings(unknownGroups)
+	if len(found) == 0 {
+		return nil, unknownComponents, unknownGroups
+	}
+	names = make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, unknownComponents, unknownGroups
+}
+
+// Unresolved describes each whitelist entry of a restricted bloks field that matches no
+// component of the input, such as a component missing from the schema directory.
+func (in Input) Unresolved() []string {
+	var messages []string
+	for _, component := range sortedComponents(in) {
+		for _, field := range in.Fields(component) {
+			for _, name := range field.UnknownComponents {
+				messages = append(messages, fmt.Sprintf("field %q of component %q whitelists unknown component %q; its bloks are generated untyped", field.Name, component.Name, name))
+			}
+			for _, group := range field.UnknownGroups {
+				messages = append(messages, fmt.Sprintf("field %q of component %q whitelists component group %q, which matches no component; bloks of components outside the schema set are generated untyped", field.Name, component.Name, group))
+			}
+		}
+	}
+	return messages
+}
+
+// PresetsFor returns the presets belonging to component ordered by name.
+func (in Input) PresetsFor(component storyblok.Component) []storyblok.ComponentPreset {
+	var presets []storyblok.ComponentPreset
+	for _, preset := range in.Presets {
+		name, _ := preset.Preset["component"].(string)
+		if strings.EqualFold(name, component.Name) || (component.ID != 0 && preset.ComponentID == component.ID) {
+			presets = append(presets, preset)
+		}
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// checkTypeNames refuses components whose names differ only in separators or case,
+// such as hero_banner and hero-banner, because they would generate the same type.
+func checkTypeNames(components []storyblok.Component, typeName func(string) string, kind string) error {
+	owners := make(map[string]string, len(components))
+	for _, component := range components {
+		name := typeName(component.Name)
+		if other, ok := owners[name]; ok {
+			return fmt.Errorf("components %q and %q both generate the %s %s; rename one of them", other, component.Name, kind, name)
+		}
+		owners[name] = component.Name
+	}
+	return nil
+}
+
+// PascalCase converts a component or field name such as "hero-banner" to "HeroBanner".
+func PascalCase(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	out := sb.String()
+	if out == "" {
+		return "X"
+	}
+	if unicode.IsDigit(rune(out[0])) {
+		out = "X" + out
+	}
+	return out
+}
+
+func optionValues(value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(list))
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		option, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		v := stringValue(option["value"])
+		if _, dup := seen[v]; dup {
+			continue
+		}
+		seen[v] = struct{}{}
+		values = append(values, v)
+	}
+	return values
+}
+
+func stringList(value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok && s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+func stringValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func intValue(value any) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+func truthy(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
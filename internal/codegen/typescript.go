@@ -0,0 +1,204 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// generatedHeader marks generated files; it carries no timestamp so output stays stable.
+const generatedHeader = "Code generated by sbx generate; DO NOT EDIT."
+
+// tsPrelude declares the shapes shared by Storyblok field types.
+const tsPrelude = `export interface StoryblokAsset {
+  id: number | null;
+  filename: string | null;
+  alt?: string | null;
+  title?: string | null;
+  copyright?: string | null;
+  focus?: string | null;
+  name?: string;
+  fieldtype?: "asset";
+  [k: string]: unknown;
+}
+
+export interface StoryblokMultilink {
+  id?: string;
+  url?: string;
+  cached_url?: string;
+  linktype?: "story" | "url" | "email" | "asset";
+  target?: "_blank" | "_self";
+  anchor?: string;
+  email?: string;
+  story?: { name: string; full_slug: string; uuid: string; [k: string]: unknown };
+  [k: string]: unknown;
+}
+
+export interface StoryblokRichtext {
+  type: string;
+  content?: StoryblokRichtext[];
+  marks?: StoryblokRichtext[];
+  attrs?: Record<string, unknown>;
+  text?: string;
+  [k: string]: unknown;
+}
+
+export interface StoryblokTable {
+  thead: { _uid: string; value?: string; component: "_table_head"; [k: string]: unknown }[];
+  tbody: {
+    _uid: string;
+    body: { _uid: string; value?: string; component: "_table_col"; [k: string]: unknown }[];
+    component: "_table_row";
+    [k: string]: unknown;
+  }[];
+}
+
+export interface StoryblokPlugin {
+  plugin: string;
+  [k: string]: unknown;
+}
+
+export interface StoryblokComponent {
+  _uid: string;
+  component: string;
+  _editable?: string;
+  [k: string]: unknown;
+}
+`
+
+// TSInterfaceName returns the interface name generated for a component.
+func TSInterfaceName(component string) string {
+	return PascalCase(component) + "Storyblok"
+}
+
+// TypeScript renders one interface per component plus typed preset examples.
+func TypeScript(in Input) ([]byte, error) {
+	components := sortedComponents(in)
+	if err := checkTypeNames(components, TSInterfaceName, "TypeScript interface"); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %s\n\n", generatedHeader)
+	buf.WriteString(tsPrelude)
+
+	for _, component := range components {
+		name := TSInterfaceName(component.Name)
+		buf.WriteString("\n")
+		if component.DisplayName != "" && component.DisplayName != component.Name {
+			fmt.Fprintf(&buf, "/** %s */\n", tsComment(component.DisplayName))
+		}
+		fmt.Fprintf(&buf, "export interface %s {\n", name)
+		buf.WriteString("  _uid: string;\n")
+		fmt.Fprintf(&buf, "  component: %s;\n", strconv.Quote(component.Name))
+		buf.WriteString("  _editable?: string;\n")
+		for _, field := range in.Fields(component) {
+			if field.Description != "" {
+				fmt.Fprintf(&buf, "  /** %s */\n", tsComment(field.Description))
+			}
+			optional := "?"
+			if field.Required {
+				optional = ""
+			}
+			fmt.Fprintf(&buf, "  %s%s: %s;\n", tsKey(field.Name), optional, tsType(field))
+		}
+		buf.WriteString("}\n")
+
+		presets := in.PresetsFor(component)
+		if len(presets) == 0 {
+			continue
+		}
+		buf.WriteString("\n")
+		fmt.Fprintf(&buf, "export const %sPresets: Record<string, Partial<%s>> = {\n", name, name)
+		for _, preset := range presets {
+			data, err := json.MarshalIndent(preset.Preset, "  ", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("encode preset %s: %w", preset.Name, err)
+			}
+			fmt.Fprintf(&buf, "  %s: %s,\n", strconv.Quote(preset.Name), data)
+		}
+		buf.WriteString("};\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func tsType(field Field) string {
+	switch field.Type {
+	case "text", "textarea", "markdown", "datetime", "number":
+		// Storyblok stores number fields as numeric strings.
+		return "string"
+	case "richtext":
+		return "StoryblokRichtext"
+	case "boolean":
+		return "boolean"
+	case "option":
+		if len(field.Options) == 0 {
+			return "string"
+		}
+		values := append([]string{}, field.Options...)
+		return tsUnion(append(values, ""))
+	case "options":
+		if len(field.Options) == 0 {
+			return "string[]"
+		}
+		return fmt.Sprintf("(%s)[]", tsUnion(field.Options))
+	case "multilink":
+		return "StoryblokMultilink"
+	case "asset":
+		return "StoryblokAsset"
+	case "multiasset":
+		return "StoryblokAsset[]"
+	case "bloks":
+		if len(field.Whitelist) == 0 {
+			return "StoryblokComponent[]"
+		}
+		names := make([]string, 0, len(field.Whitelist)+1)
+		for _, component := range field.Whitelist {
+			names = append(names, TSInterfaceName(component))
+		}
+		if len(field.UnknownComponents) > 0 || len(field.UnknownGroups) > 0 {
+			// Components the whitelist names but the schema set lacks are still valid content.
+			names = append(names, "StoryblokComponent")
+		}
+		if len(names) == 1 {
+			return names[0] + "[]"
+		}
+		return fmt.Sprintf("(%s)[]", strings.Join(names, " | "))
+	case "table":
+		return "StoryblokTable"
+	case "custom", "plugin":
+		return "StoryblokPlugin"
+	}
+	return "unknown"
+}
+
+func tsUnion(values []string) string {
+	seen := make(map[string]struct{}, len(values))
+	literals := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, dup := seen[value]; dup {
+			continue
+		}
+		seen[value] = struct{}{}
+		literals = append(literals, strconv.Quote(value))
+	}
+	return strings.Join(literals, " | ")
+}
+
+func tsKey(name string) string {
+	for i, r := range name {
+		valid := r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')
+		if !valid {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}
+
+func tsComment(text string) string {
+	text = strings.ReplaceAll(text, "*/", "*\\/")
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultFileName is the project configuration file looked up in the working directory.
+// It lives outside the schema directory so schema discovery never reads it.
+const DefaultFileName = "sbx.config.json"
+
+// Config is the project configuration file.
+type Config struct {
+	Lint      LintConfig      `json:"lint"`
+	Protected ProtectedConfig `json:"protected"`
+	Audit     AuditConfig     `json:"audit"`
+
+	path string
+}
+
+// LintConfig enables, disables and parameterises lint rules. Overrides apply in order to
+// components whose name matches one of their globs.
+type LintConfig struct {
+	Rules     map[string]RuleConfig `json:"rules,omitempty"`
+	Overrides []LintOverride        `json:"overrides,omitempty"`
+}
+
+// RuleConfig configures one lint rule. Severity is "error", "warning" or "off"; empty keeps
+// the current severity. Options are merged over the rule's defaults.
+type RuleConfig struct {
+	Severity string         `json:"severity,omitempty"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+// LintOverride reconfigures rules for components matching any of Components (glob syntax,
+// case-insensitive).
+type LintOverride struct {
+	Components []string              `json:"components"`
+	Rules      map[string]RuleConfig `json:"rules"`
+}
+
+// ProtectedConfig lists spaces, such as production, that commands only write to when the
+// write is confirmed: by --confirm-space, by applying a plan one of Approvers signed, or
+// from CI.
+type ProtectedConfig struct {
+	Spaces []int `json:"spaces,omitempty"`
+	// CIEnv names an environment variable only the CI jobs allowed to write set, ideally
+	// from a secret. Runs where it is set, to anything but "false" or "0", count as
+	// confirmed. Empty disables confirmation from CI; generic markers such as CI are easy
+	// to set by hand and should not be used.
+	CIEnv     string     `json:"ci_env,omitempty"`
+	Approvers []Approver `json:"approvers,omitempty"`
+}
+
+// Approver may approve plans for protected spaces. Approvals are signed with the
+// approver's Ed25519 private key and checked against PublicKey.
+type Approver struct {
+	Name string `json:"name"`
+	// PublicKey is the base64 body of the PEM block that openssl pkey -pubout prints for
+	// the approver's key.
+	PublicKey string `json:"public_key"`
+}
+
+// Key decodes the approver's public key.
+func (a Approver) Key() (ed25519.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(a.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("public key of approver %s: %w", a.Name, err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("public key of approver %s: %w", a.Name, err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key of approver %s is not an Ed25519 key", a.Name)
+	}
+	return edKey, nil
+}
+
+// Approver returns the approver called name.
+func (p ProtectedConfig) Approver(name string) (Approver, bool) {
+	for _, approver := range p.Approvers {
+		if strings.EqualFold(approver.Name, name) {
+			return approver, true
+		}
+	}
+	return Approver{}, false
+}
+
+// IsProtected reports whether spaceID is a protected space.
+func (p ProtectedConfig) IsProtected(spaceID int) bool {
+	for _, id := range p.Spaces {
+		if id == spaceID {
+			return true
+		}
+	}
+	return false
+}
+
+// InCI reports whether the configured CI marker variable is set.
+func (p ProtectedConfig) InCI() bool {
+	if p.CIEnv == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(p.CIEnv))) {
+	case "", "false", "0":
+		return false
+	}
+	return true
+}
+
+// AuditConfig configures the log of writes made to Storyblok.
+type AuditConfig struct {
+	// Path is the JSONL file writes are appended to, relative to the working directory;
+	// "off" disables the log. Empty means .sbx/audit.jsonl.
+	Path string `json:"path,omitempty"`
+}
+
+// Load reads the configuration at path. A missing file yields an empty configuration
+// unless required is set, as for paths passed explicitly by the user.
+func Load(path string, required bool) (Config, error) {
+	cfg := Config{path: path}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// LoadDefault reads the configuration the CLI reads without --config: the file SBX_CONFIG
+// names, which must exist, or else sbx.config.json in the working directory, if any.
+func LoadDefault() (Config, error) {
+	if path := strings.TrimSpace(os.Getenv("SBX_CONFIG")); path != "" {
+		return Load(path, true)
+	}
+	return Load(DefaultFileName, false)
+}
+
+// Path returns the file the configuration was loaded from.
+func (c Config) Path() string {
+	return c.path
+}
@@ -0,0 +1,81 @@
+package content
+
+import (
+	"sort"
+	"strings"
+)
+
+// Walk visits every blok (an object with a "component" key) in story content,
+// including bloks nested in bloks fields and richtext blok nodes, in the order of their
+// keys so that reports built while walking are stable. Returning false from fn stops
+// descending into that blok.
+func Walk(value any, fn func(blok map[string]any) bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		if _, ok := v["component"].(string); ok {
+			if !fn(v) {
+				return
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			Walk(v[key], fn)
+		}
+	case []any:
+		for _, child := range v {
+			Walk(child, fn)
+		}
+	}
+}
+
+// ComponentName returns the component a blok instantiates.
+func ComponentName(blok map[string]any) string {
+	name, _ := blok["component"].(string)
+	return name
+}
+
+// IsComponent reports whether blok instantiates the named component (case-insensitive).
+func IsComponent(blok map[string]any, name string) bool {
+	return strings.EqualFold(ComponentName(blok), name)
+}
+
+// HasValue reports whether a blok holds a non-empty value for field. Empty strings,
+// empty lists and objects, and asset or link objects without a target count as empty.
+func HasValue(blok map[string]any, field string) bool {
+	value, ok := blok[field]
+	if !ok {
+		return false
+	}
+	return !IsEmpty(value)
+}
+
+// IsEmpty reports whether a field value carries no content.
+func IsEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		if len(v) == 0 {
+			return true
+		}
+		if filename, ok := v["filename"]; ok {
+			return IsEmpty(filename)
+		}
+		if linktype, ok := v["linktype"]; ok && linktype != nil {
+			return IsEmpty(v["id"]) && IsEmpty(v["url"]) && IsEmpty(v["cached_url"])
+		}
+		if docType, ok := v["type"].(string); ok && docType == "doc" {
+			return IsEmpty(v["content"])
+		}
+		return false
+	}
+	return false
+}
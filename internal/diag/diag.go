@@ -0,0 +1,140 @@
+package diag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks a diagnostic.
+type Severity int
+
+// Severities in increasing order of impact.
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// MarshalText encodes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(data []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(data))) {
+	case "error":
+		*s = SeverityError
+	case "warning", "warn":
+		*s = SeverityWarning
+	default:
+		return fmt.Errorf("invalid severity %q (expected error or warning)", data)
+	}
+	return nil
+}
+
+// Diagnostic is a problem found in a schema file. Line and Column are 1-based; zero means
+// the problem applies to the file as a whole.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+// String formats the diagnostic as file:line:col: severity: message (code).
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location += fmt.Sprintf(":%d:%d", d.Line, d.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", location, d.Severity, d.Message, d.Code)
+}
+
+// At returns a diagnostic positioned at path inside the JSON document data.
+func At(file string, data []byte, severity Severity, code string, path []string, format string, args ...any) Diagnostic {
+	line, column := Locate(data, path...)
+	return Diagnostic{
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// Sort orders diagnostics by file, position and code.
+func Sort(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Code < b.Code
+	})
+}
+
+// Count returns the number of errors and warnings.
+func Count(diagnostics []Diagnostic) (errorCount, warningCount int) {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+	return errorCount, warningCount
+}
+
+// FromJSONError describes a JSON decoding error, positioned at the offending byte when the
+// decoder reports one.
+func FromJSONError(file string, data []byte, err error) Diagnostic {
+	d := Diagnostic{
+		File:     file,
+		Line:     1,
+		Column:   1,
+		Severity: SeverityError,
+		Code:     "parse-error",
+		Message:  err.Error(),
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		d.Line, d.Column = LocateOffset(data, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		d.Line, d.Column = LocateOffset(data, typeErr.Offset)
+	}
+	return d
+}
+
+// Unique drops repeated diagnostics from a sorted slice, such as a file reported by both the
+// component and the preset loader.
+func Unique(diagnostics []Diagnostic) []Diagnostic {
+	out := diagnostics[:0]
+	for i, d := range diagnostics {
+		if i > 0 && d == diagnostics[i-1] {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// Locate returns the 1-based line and column of the value at path in the JSON document
+// data. Object members are addressed by key and array elements by index. When path cannot
+// be followed to the end, the position of the deepest member found is returned; an empty
+// path or unparseable document yields line 1, column 1.
+func Locate(data []byte, path ...string) (int, int) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var offset int64
+	seek(dec, data, path, &offset)
+	return position(data, offset)
+}
+
+// LocateOffset converts a byte offset, such as json.SyntaxError.Offset, to a line and column.
+func LocateOffset(data []byte, offset int64) (int, int) {
+	return position(data, offset)
+}
+
+func seek(dec *json.Decoder, data []byte, path []string, offset *int64) {
+	if len(path) == 0 {
+		return
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return
+	}
+	switch delim {
+	case '{':
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return
+			}
+			key, _ := keyTok.(string)
+			if key == path[0] {
+				quoted, _ := json.Marshal(key)
+				*offset = dec.InputOffset() - int64(len(quoted))
+				seek(dec, data, path[1:], offset)
+				return
+			}
+			if err := skip(dec); err != nil {
+				return
+			}
+		}
+	case '[':
+		index, err := strconv.Atoi(path[0])
+		if err != nil {
+			return
+		}
+		for i := 0; dec.More(); i++ {
+			if i == index {
+				*offset = skipSpace(data, dec.InputOffset())
+				seek(dec, data, path[1:], offset)
+				return
+			}
+			if err := skip(dec); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func skip(dec *json.Decoder) error {
+	var raw json.RawMessage
+	return dec.Decode(&raw)
+}
+
+// skipSpace advances past whitespace and the element separator preceding a value.
+func skipSpace(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func position(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(prefix, '\n')
+	return line, column
+}
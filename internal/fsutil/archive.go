@@ -0,0 +1,205 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// IsArchive reports whether path names an archive ReadArchive and WriteArchive handle:
+// .tar, .tar.gz, .tgz or .zip.
+func IsArchive(path string) bool {
+	return archiveFormat(path) != ""
+}
+
+func archiveFormat(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tgz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// ReadArchive loads the files of an archive into memory.
+func ReadArchive(path string) (*MemFS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mem := NewMemFS()
+	switch archiveFormat(path) {
+	case "zip":
+		err = readZip(mem, data)
+	case "tar", "tgz":
+		err = readBundle(mem, bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%s is not a .tar, .tar.gz, .tgz or .zip archive", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read archive %s: %w", path, err)
+	}
+	return mem, nil
+}
+
+// WriteArchive replaces path with an archive of every file in mem, in the format the
+// extension of path names.
+func WriteArchive(path string, mem *MemFS) error {
+	var buf bytes.Buffer
+	var err error
+	switch archiveFormat(path) {
+	case "zip":
+		err = writeZip(&buf, mem)
+	case "tgz":
+		gz := gzip.NewWriter(&buf)
+		if err = writeTar(gz, mem); err == nil {
+			err = gz.Close()
+		}
+	case "tar":
+		err = writeTar(&buf, mem)
+	default:
+		return fmt.Errorf("%s is not a .tar, .tar.gz, .tgz or .zip archive", path)
+	}
+	if err != nil {
+		return fmt.Errorf("write archive %s: %w", path, err)
+	}
+	return OS.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// ReadBundle loads a bundle stream, a tar archive that may be gzip-compressed, into
+// memory. It is what WriteBundle produces, so one sbx process can pipe files to another.
+func ReadBundle(r io.Reader) (*MemFS, error) {
+	mem := NewMemFS()
+	if err := readBundle(mem, r); err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	return mem, nil
+}
+
+// readBundle adds the files of a bundle stream to mem without marking it changed.
+func readBundle(mem *MemFS, r io.Reader) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return readTar(mem, gz)
+	}
+	return readTar(mem, br)
+}
+
+// WriteBundle writes every file in mem to w as an uncompressed tar stream.
+func WriteBundle(w io.Writer, mem *MemFS) error {
+	return writeTar(w, mem)
+}
+
+func readTar(mem *MemFS, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		mem.load(header.Name, data, header.FileInfo().Mode().Perm(), header.ModTime)
+	}
+}
+
+func writeTar(w io.Writer, mem *MemFS) error {
+	tw := tar.NewWriter(w)
+	for _, name := range mem.Paths() {
+		file := mem.file(name)
+		header := &tar.Header{
+			Name:    name,
+			Mode:    int64(file.mode.Perm()),
+			Size:    int64(len(file.data)),
+			ModTime: file.modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func readZip(mem *MemFS, data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		mem.load(entry.Name, content, entry.Mode().Perm(), entry.Modified)
+	}
+	return nil
+}
+
+func writeZip(w io.Writer, mem *MemFS) error {
+	zw := zip.NewWriter(w)
+	for _, name := range mem.Paths() {
+		file := mem.file(name)
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: file.modTime}
+		header.SetMode(file.mode.Perm())
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// load stores a file read from an archive or bundle.
+func (m *MemFS) load(name string, data []byte, perm fs.FileMode, modTime time.Time) {
+	if perm == 0 {
+		perm = 0o644
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[memKey(name)] = memFile{data: data, mode: perm, modTime: modTime}
+}
+
+func (m *MemFS) file(key string) memFile {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files[key]
+}
@@ -0,0 +1,50 @@
+package fsutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FS is the storage workflows read schema files from and write them to. Paths use the
+// host separator, as with the os package.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	// WriteFile creates parent directories as needed.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// OS is the local disk.
+var OS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+func (osFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+// WriteFile writes through a temporary file and a rename, so readers never see a
+// partially written file.
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := EnsureDir(filepath.Dir(name)); err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.tmp", name)
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// Or returns fsys, or OS when fsys is nil.
+func Or(fsys FS) FS {
+	if fsys == nil {
+		return OS
+	}
+	return fsys
+}
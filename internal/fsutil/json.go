@@ -2,15 +2,19 @@ package fsutil
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io/fs"
 	"os"
-	"path/filepath"
 )
 
 // ReadJSON loads JSON data from path into v.
 func ReadJSON(path string, v any) error {
-	data, err := os.ReadFile(path)
+	return ReadJSONFS(OS, path, v)
+}
+
+// ReadJSONFS loads JSON data from path in fsys into v.
+func ReadJSONFS(fsys FS, path string, v any) error {
+	data, err := fsys.ReadFile(path)
 	if err != nil {
 		return err
 	}
@@ -19,23 +23,19 @@ func ReadJSON(path string, v any) error {
 
 // WriteJSON writes v as formatted JSON to path (atomic best-effort).
 func WriteJSON(path string, v any, perm fs.FileMode) error {
+	return WriteJSONFS(OS, path, v, perm)
+}
+
+// WriteJSONFS writes v as formatted JSON to path in fsys.
+func WriteJSONFS(fsys FS, path string, v any, perm fs.FileMode) error {
 	if perm == 0 {
 		perm = 0o644
 	}
-	if err := EnsureDir(filepath.Dir(path)); err != nil {
-		return err
-	}
-
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return err
 	}
-
-	tmp := fmt.Sprintf("%s.tmp", path)
-	if err := os.WriteFile(tmp, data, perm); err != nil {
-		return err
-	}
-	return os.Rename(tmp, path)
+	return fsys.WriteFile(path, data, perm)
 }
 
 // EnsureDir ensures path exists as directory.
@@ -48,11 +48,16 @@ func EnsureDir(path string) error {
 
 // Exists reports whether the path exists.
 func Exists(path string) (bool, error) {
-	_, err := os.Stat(path)
+	return ExistsFS(OS, path)
+}
+
+// ExistsFS reports whether the path exists in fsys.
+func ExistsFS(fsys FS, path string) (bool, error) {
+	_, err := fsys.Stat(path)
 	if err == nil {
 		return true, nil
 	}
-	if os.IsNotExist(err) {
+	if errors.Is(err, fs.ErrNotExist) {
 		return false, nil
 	}
 	return false, err
@@ -0,0 +1,171 @@
+package fsutil
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS keeps files in memory. Directories exist implicitly while they contain files. It
+// is safe for concurrent use.
+type MemFS struct {
+	mu      sync.RWMutex
+	files   map[string]memFile
+	changed bool
+}
+
+type memFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS returns an empty in-memory file system.
+func NewMemFS() *MemFS {
+	return &MemFS{files: make(map[string]memFile)}
+}
+
+// memKey maps a host path to the slash-separated key files are stored under. Leading
+// separators are dropped, so "/a" and "a" name the same file.
+func memKey(name string) string {
+	key := strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if key == "" {
+		return "."
+	}
+	return key
+}
+
+// ReadFile returns a copy of the file's content.
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	file, ok := m.files[memKey(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), file.data...), nil
+}
+
+// WriteFile stores a copy of data.
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	key := memKey(name)
+	if key == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isDir(key) {
+		return &fs.PathError{Op: "write", Path: name, Err: fmt.Errorf("is a directory")}
+	}
+	m.files[key] = memFile{data: append([]byte(nil), data...), mode: perm, modTime: time.Now()}
+	m.changed = true
+	return nil
+}
+
+// ReadDir lists the files and directories directly below name, sorted by name.
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	key := memKey(name)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.files[key]; ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("not a directory")}
+	}
+
+	prefix := key + "/"
+	if key == "." {
+		prefix = ""
+	}
+	children := make(map[string]fs.FileInfo)
+	for p, file := range m.files {
+		rest, ok := strings.CutPrefix(p, prefix)
+		if !ok {
+			continue
+		}
+		if child, _, nested := strings.Cut(rest, "/"); nested {
+			children[child] = memInfo{name: child, dir: true}
+		} else {
+			children[child] = memInfo{name: child, file: file}
+		}
+	}
+	if len(children) == 0 && key != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, info := range children {
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Stat describes a file or an implicit directory.
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	key := memKey(name)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if file, ok := m.files[key]; ok {
+		return memInfo{name: path.Base(key), file: file}, nil
+	}
+	if key == "." || m.isDir(key) {
+		return memInfo{name: path.Base(key), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// Paths returns the slash-separated paths of all files, sorted.
+func (m *MemFS) Paths() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	paths := make([]string, 0, len(m.files))
+	for p := range m.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Changed reports whether a file was written since the file system was created or loaded.
+func (m *MemFS) Changed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.changed
+}
+
+func (m *MemFS) isDir(key string) bool {
+	prefix := key + "/"
+	for p := range m.files {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type memInfo struct {
+	name string
+	dir  bool
+	file memFile
+}
+
+func (i memInfo) Name() string { return i.name }
+
+func (i memInfo) Size() int64 { return int64(len(i.file.data)) }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return i.file.mode
+}
+
+func (i memInfo) ModTime() time.Time { return i.file.modTime }
+
+func (i memInfo) IsDir() bool { return i.dir }
+
+func (i memInfo) Sys() any { return nil }
@@ -0,0 +1,285 @@
+// Package gitref reads schema files from a commit of the local git repository without
+// checking it out.
+package gitref
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/state"
+)
+
+// Snapshot is a directory as it is in a commit.
+type Snapshot struct {
+	Ref string
+	SHA string
+	// FS serves the commit's files under the paths they have in the working tree. The
+	// sync state in sbx.lock is not versioned with the schemas, so it is read from and
+	// written to the working tree, as is everything a workflow writes.
+	FS fsutil.FS
+}
+
+// ErrNotInCommit reports a directory that does not exist in the commit.
+var ErrNotInCommit = errors.New("directory not in commit")
+
+// Open resolves ref to a commit and loads the files below dir at that commit through the
+// git CLI. dir is relative to the working directory, like a --dir flag. When dir does not
+// exist in the commit, Open returns an empty snapshot along with ErrNotInCommit.
+func Open(ctx context.Context, ref, dir string) (*Snapshot, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+	out, err := git(ctx, "", nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("git ref %s does not name a commit", ref)
+	}
+	sha := strings.TrimSpace(string(out))
+
+	out, err = git(ctx, "", nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top := strings.TrimSpace(string(out))
+	rel, err := repoPath(top, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Ref: ref, SHA: sha}
+	if rel != "." {
+		out, err = git(ctx, top, nil, "ls-tree", "--name-only", sha, "--", rel)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(out)) == 0 {
+			snapshot.FS = treeFS{top: top, tree: fsutil.NewMemFS()}
+			return snapshot, fmt.Errorf("%s is not in %s: %w", dir, ref, ErrNotInCommit)
+		}
+	}
+
+	tree, err := readTree(ctx, top, sha, rel)
+	if err != nil {
+		return nil, fmt.Errorf("read %s at %s: %w", dir, ref, err)
+	}
+	snapshot.FS = treeFS{top: top, tree: tree}
+	return snapshot, nil
+}
+
+// readTree loads the files below rel in commit sha straight from the object database.
+// Unlike git archive it ignores .gitattributes, so export-ignore and export-subst can
+// neither drop nor rewrite schema files. Submodules and symlinks are skipped.
+func readTree(ctx context.Context, top, sha, rel string) (*fsutil.MemFS, error) {
+	args := []string{"ls-tree", "-r", "-z", sha}
+	if rel != "." {
+		args = append(args, "--", rel)
+	}
+	out, err := git(ctx, top, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	type blob struct {
+		path string
+		mode fs.FileMode
+	}
+	var blobs []blob
+	var batch bytes.Buffer
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		if len(entry) == 0 {
+			continue
+		}
+		// Entries read "<mode> <type> <object>\t<path>".
+		meta, path, ok := strings.Cut(string(entry), "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git ls-tree entry %q", entry)
+		}
+		if fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+		mode := fs.FileMode(0o644)
+		if fields[0] == "100755" {
+			mode = 0o755
+		}
+		blobs = append(blobs, blob{path: path, mode: mode})
+		batch.WriteString(fields[2] + "\n")
+	}
+
+	tree := fsutil.NewMemFS()
+	if len(blobs) == 0 {
+		return tree, nil
+	}
+	out, err = git(ctx, top, &batch, "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+	// Each object reads "<object> <type> <size>\n<content>\n", in the order requested.
+	for _, b := range blobs {
+		header, rest, ok := bytes.Cut(out, []byte{'\n'})
+		fields := strings.Fields(string(header))
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git cat-file output for %s: %q", b.path, header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil || size < 0 || len(rest) < size+1 {
+			return nil, fmt.Errorf("truncated git cat-file output for %s", b.path)
+		}
+		if err := tree.WriteFile(b.path, rest[:size], b.mode); err != nil {
+			return nil, err
+		}
+		out = rest[size+1:]
+	}
+	return tree, nil
+}
+
+// repoPath returns name relative to the repository root top, slash-separated.
+func repoPath(top, name string) (string, error) {
+	abs := name
+	if !filepath.IsAbs(name) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		// git reports the root with symlinks resolved.
+		if resolved, err := filepath.EvalSymlinks(wd); err == nil {
+			wd = resolved
+		}
+		abs = filepath.Join(wd, name)
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the git repository at %s", name, top)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// git runs a git command in dir, or in the working directory when dir is empty, feeding
+// it stdin when that is not nil.
+func git(ctx context.Context, dir string, stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// treeFS reads from the commit and keeps the lockfile and all writes on disk.
+type treeFS struct {
+	top  string
+	tree *fsutil.MemFS
+}
+
+func (t treeFS) ReadFile(name string) ([]byte, error) {
+	if isLock(name) {
+		return fsutil.OS.ReadFile(name)
+	}
+	key, err := repoPath(t.top, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return t.tree.ReadFile(key)
+}
+
+func (t treeFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return fsutil.OS.WriteFile(name, data, perm)
+}
+
+func (t treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	key, err := repoPath(t.top, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return t.tree.ReadDir(key)
+}
+
+func (t treeFS) Stat(name string) (fs.FileInfo, error) {
+	if isLock(name) {
+		return fsutil.OS.Stat(name)
+	}
+	key, err := repoPath(t.top, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return t.tree.Stat(key)
+}
+
+func isLock(name string) bool {
+	return filepath.Base(name) == state.LockFileName
+}
+
+// Range is a pair of commits to compare, parsed from git's base..head or base...head
+// notation.
+type Range struct {
+	Base string
+	Head string
+	// MergeBase compares head against the merge base of both refs, as base...head does.
+	MergeBase bool
+}
+
+// ParseRange parses base..head or base...head. An empty side means HEAD.
+func ParseRange(spec string) (Range, error) {
+	var r Range
+	var ok bool
+	if r.Base, r.Head, ok = strings.Cut(spec, "..."); ok {
+		r.MergeBase = true
+	} else if r.Base, r.Head, ok = strings.Cut(spec, ".."); !ok {
+		return Range{}, fmt.Errorf("invalid git range %q (expected base..head or base...head)", spec)
+	}
+	if r.Base == "" {
+		r.Base = "HEAD"
+	}
+	if r.Head == "" {
+		r.Head = "HEAD"
+	}
+	return r, nil
+}
+
+// OpenRange loads dir at both ends of r. A side where dir does not exist yet, or no
+// longer, is empty. For a merge-base range the base snapshot is the commit both refs
+// share, with the ref it came from recorded as "merge-base(base, head)".
+func OpenRange(ctx context.Context, r Range, dir string) (*Snapshot, *Snapshot, error) {
+	baseRef := r.Base
+	if r.MergeBase {
+		if strings.HasPrefix(r.Base, "-") || strings.HasPrefix(r.Head, "-") {
+			return nil, nil, fmt.Errorf("invalid git range %s...%s", r.Base, r.Head)
+		}
+		out, err := git(ctx, "", nil, "merge-base", r.Base, r.Head)
+		if err != nil {
+			return nil, nil, err
+		}
+		baseRef = strings.TrimSpace(string(out))
+	}
+	base, baseErr := Open(ctx, baseRef, dir)
+	if baseErr != nil && !errors.Is(baseErr, ErrNotInCommit) {
+		return nil, nil, baseErr
+	}
+	if r.MergeBase {
+		base.Ref = fmt.Sprintf("merge-base(%s, %s)", r.Base, r.Head)
+	}
+	head, headErr := Open(ctx, r.Head, dir)
+	if headErr != nil && !errors.Is(headErr, ErrNotInCommit) {
+		return nil, nil, headErr
+	}
+	if baseErr != nil && headErr != nil {
+		return nil, nil, fmt.Errorf("%s is in neither %s nor %s", dir, r.Base, r.Head)
+	}
+	return base, head, nil
+}
@@ -0,0 +1,149 @@
+// Package report carries progress messages from workflows to whoever runs them. Workflows
+// report through the context; without an observer in the context, messages go to the
+// console exactly as the CLI prints them.
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Level grades an event and decides where the console prints it.
+type Level int
+
+const (
+	// LevelOutput is regular command output: plans, saved files and summaries.
+	LevelOutput Level = iota
+	LevelInfo
+	LevelSuccess
+	LevelWarn
+	// LevelError reports failures and problems that affect the exit code.
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelOutput:
+		return "output"
+	case LevelInfo:
+		return "info"
+	case LevelSuccess:
+		return "success"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Event is one progress message. Message has no trailing newline; an empty message
+// separates sections of output.
+type Event struct {
+	Level   Level
+	Message string
+}
+
+// Observer receives the events of a workflow. Push reports from several workers, so
+// implementations must be safe for concurrent use.
+type Observer interface {
+	Observe(Event)
+}
+
+// ObserverFunc adapts a function to Observer.
+type ObserverFunc func(Event)
+
+// Observe calls f.
+func (f ObserverFunc) Observe(event Event) { f(event) }
+
+type observerKey struct{}
+
+// WithObserver returns a context whose workflows report to observer. A nil observer
+// leaves ctx unchanged.
+func WithObserver(ctx context.Context, observer Observer) context.Context {
+	if observer == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, observerKey{}, observer)
+}
+
+func from(ctx context.Context) Observer {
+	if ctx != nil {
+		if observer, ok := ctx.Value(observerKey{}).(Observer); ok {
+			return observer
+		}
+	}
+	return console
+}
+
+func emit(ctx context.Context, level Level, format string, args []any) {
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	from(ctx).Observe(Event{Level: level, Message: message})
+}
+
+// Printf reports regular output.
+func Printf(ctx context.Context, format string, args ...any) {
+	emit(ctx, LevelOutput, format, args)
+}
+
+// Infof reports progress.
+func Infof(ctx context.Context, format string, args ...any) {
+	emit(ctx, LevelInfo, format, args)
+}
+
+// Successf reports a completed step.
+func Successf(ctx context.Context, format string, args ...any) {
+	emit(ctx, LevelSuccess, format, args)
+}
+
+// Warnf reports something the user should look at.
+func Warnf(ctx context.Context, format string, args ...any) {
+	emit(ctx, LevelWarn, format, args)
+}
+
+// Errorf reports a failure.
+func Errorf(ctx context.Context, format string, args ...any) {
+	emit(ctx, LevelError, format, args)
+}
+
+const colorReset = "\033[0m"
+
+var colors = map[Level]string{
+	LevelInfo:    "\033[36m",
+	LevelSuccess: "\033[32m",
+	LevelWarn:    "\033[33m",
+}
+
+var console = NewConsole(os.Stdout, os.Stderr)
+
+// Console prints output to stdout and everything else to stderr, with info, success and
+// warnings colored unless NO_COLOR is set. Workflows report to it by default.
+type Console struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Color  bool
+}
+
+// NewConsole returns a Console writing to stdout and stderr.
+func NewConsole(stdout, stderr io.Writer) *Console {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return &Console{Stdout: stdout, Stderr: stderr, Color: !noColor}
+}
+
+// Observe prints the event.
+func (c *Console) Observe(event Event) {
+	if event.Level == LevelOutput {
+		fmt.Fprintln(c.Stdout, event.Message)
+		return
+	}
+	if color, ok := colors[event.Level]; ok && c.Color {
+		fmt.Fprintf(c.Stderr, "%s%s%s\n", color, event.Message, colorReset)
+		return
+	}
+	fmt.Fprintln(c.Stderr, event.Message)
+}
@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity classifies the impact of a schema change on existing content.
+type Severity int
+
+// Severities in increasing order of impact.
+const (
+	SeveritySafe Severity = iota
+	SeverityRisky
+	SeverityBreaking
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityRisky:
+		return "risky"
+	case SeverityBreaking:
+		return "breaking"
+	default:
+		return "safe"
+	}
+}
+
+// MarshalText encodes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(data []byte) error {
+	parsed, err := ParseSeverity(string(data))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// ParseSeverity converts a --fail-on style value into a Severity.
+func ParseSeverity(value string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "safe":
+		return SeveritySafe, nil
+	case "risky":
+		return SeverityRisky, nil
+	case "breaking":
+		return SeverityBreaking, nil
+	}
+	return SeveritySafe, fmt.Errorf("invalid severity %q (expected safe, risky, or breaking)", value)
+}
+
+// compatibleTypes lists field type changes that keep stored values readable.
+var compatibleTypes = map[string]map[string]struct{}{
+	"text":     {"textarea": {}, "markdown": {}},
+	"textarea": {"text": {}, "markdown": {}},
+	"markdown": {"text": {}, "textarea": {}},
+	"option":   {"options": {}},
+}
+
+// classify assigns a severity to a change based on how it affects existing content.
+func classify(change Change) Change {
+	switch change.Kind {
+	case ComponentRemoved, FieldRemoved, FieldRenamed, TypeChanged, RequiredTightened, OptionsRemoved, WhitelistRemoved:
+		change.Severity = SeverityBreaking
+	case MaxLengthReduced, RegexChanged, DatasourceChanged:
+		change.Severity = SeverityRisky
+	default:
+		change.Severity = SeveritySafe
+	}
+	return change
+}
+
+// compatibleType reports whether values stored for type from remain readable as type to.
+func compatibleType(from, to string) bool {
+	_, ok := compatibleTypes[from][to]
+	return ok
+}
+
+// MaxSeverity returns the highest severity among changes.
+func MaxSeverity(changes []Change) Severity {
+	max := SeveritySafe
+	for _, change := range changes {
+		if change.Severity > max {
+			max = change.Severity
+		}
+	}
+	return max
+}
+
+// AtLeast filters changes with a severity of at least min.
+func AtLeast(changes []Change, min Severity) []Change {
+	var filtered []Change
+	for _, change := range changes {
+		if change.Severity >= min {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
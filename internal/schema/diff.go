@@ -0,0 +1,421 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sbx/internal/storyblok"
+)
+
+// ChangeKind names a semantic schema change.
+type ChangeKind string
+
+// Semantic change kinds produced by Diff.
+const (
+	ComponentAdded    ChangeKind = "component_added"
+	ComponentRemoved  ChangeKind = "component_removed"
+	FieldAdded        ChangeKind = "field_added"
+	FieldRemoved      ChangeKind = "field_removed"
+	FieldRenamed      ChangeKind = "field_renamed"
+	TypeChanged       ChangeKind = "type_changed"
+	RequiredTightened ChangeKind = "required_tightened"
+	RequiredLoosened  ChangeKind = "required_loosened"
+	MaxLengthReduced  ChangeKind = "max_length_reduced"
+	RegexChanged      ChangeKind = "regex_changed"
+	OptionsRemoved    ChangeKind = "options_removed"
+	OptionsAdded      ChangeKind = "options_added"
+	WhitelistRemoved  ChangeKind = "whitelist_removed"
+	WhitelistAdded    ChangeKind = "whitelist_added"
+	DatasourceChanged ChangeKind = "datasource_changed"
+	PropertyChanged   ChangeKind = "property_changed"
+)
+
+// Change is a single semantic difference between two versions of a component.
+type Change struct {
+	Component string     `json:"component"`
+	Field     string     `json:"field,omitempty"`
+	Kind      ChangeKind `json:"kind"`
+	Detail    string     `json:"detail,omitempty"`
+	Severity  Severity   `json:"severity"`
+}
+
+// String renders the change as a single report line.
+func (c Change) String() string {
+	location := c.Component
+	if c.Field != "" {
+		location += "." + c.Field
+	}
+	if c.Detail == "" {
+		return fmt.Sprintf("[%s] %s: %s", c.Severity, location, strings.ReplaceAll(string(c.Kind), "_", " "))
+	}
+	return fmt.Sprintf("[%s] %s: %s", c.Severity, location, c.Detail)
+}
+
+// ignoredProperties never produce changes: they are positional or space-specific.
+var ignoredProperties = map[string]struct{}{
+	"pos": {},
+	"id":  {},
+}
+
+// DiffComponents compares two sets of components by name. Components only present in
+// old are reported as removed, components only present in new as added.
+func DiffComponents(old, new []storyblok.Component) []Change {
+	oldByName := indexByName(old)
+	newByName := indexByName(new)
+
+	var changes []Change
+	for key, component := range oldByName {
+		if _, ok := newByName[key]; !ok {
+			changes = append(changes, classify(Change{Component: component.Name, Kind: ComponentRemoved}))
+		}
+	}
+	for key, component := range newByName {
+		previous, ok := oldByName[key]
+		if !ok {
+			changes = append(changes, classify(Change{Component: component.Name, Kind: ComponentAdded}))
+			continue
+		}
+		changes = append(changes, Diff(previous, component)...)
+	}
+	SortChanges(changes)
+	return changes
+}
+
+// Diff compares the schema of two versions of the same component. Group whitelists are
+// compared entry by entry, so versions from different spaces must first give them by
+// name with GroupNames.
+func Diff(old, new storyblok.Component) []Change {
+	name := new.Name
+	if name == "" {
+		name = old.Name
+	}
+
+	oldFields := fieldDefinitions(old.Schema)
+	newFields := fieldDefinitions(new.Schema)
+
+	var removed, added []string
+	var changes []Change
+	for key := range oldFields {
+		if _, ok := newFields[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	for key := range newFields {
+		if _, ok := oldFields[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	renamed := detectRenames(removed, added, oldFields, newFields)
+	for _, from := range removed {
+		if to, ok := renamed[from]; ok {
+			changes = append(changes, classify(Change{Component: name, Field: from, Kind: FieldRenamed, Detail: fmt.Sprintf("renamed to %s", to)}))
+			continue
+		}
+		changes = append(changes, classify(Change{Component: name, Field: from, Kind: FieldRemoved, Detail: fmt.Sprintf("%s field removed", fieldType(oldFields[from]))}))
+	}
+	renamedTo := make(map[string]struct{}, len(renamed))
+	for _, to := range renamed {
+		renamedTo[to] = struct{}{}
+	}
+	for _, key := range added {
+		if _, ok := renamedTo[key]; ok {
+			continue
+		}
+		change := classify(Change{Component: name, Field: key, Kind: FieldAdded, Detail: fmt.Sprintf("%s field added", fieldType(newFields[key]))})
+		if truthy(newFields[key]["required"]) {
+			// Existing stories have no value for a new required field.
+			change.Detail += " (required)"
+			change.Severity = SeverityRisky
+		}
+		changes = append(changes, change)
+	}
+
+	for key, before := range oldFields {
+		after, ok := newFields[key]
+		if !ok {
+			continue
+		}
+		changes = append(changes, diffField(name, key, before, after)...)
+	}
+
+	SortChanges(changes)
+	return changes
+}
+
+func diffField(component, field string, before, after map[string]any) []Change {
+	var changes []Change
+	add := func(kind ChangeKind, detail string) {
+		changes = append(changes, classify(Change{Component: component, Field: field, Kind: kind, Detail: detail}))
+	}
+
+	oldType, newType := fieldType(before), fieldType(after)
+	if oldType != newType {
+		change := classify(Change{Component: component, Field: field, Kind: TypeChanged, Detail: fmt.Sprintf("type changed %s → %s", oldType, newType)})
+		if compatibleType(oldType, newType) {
+			change.Severity = SeverityRisky
+		}
+		changes = append(changes, change)
+	}
+
+	oldRequired, newRequired := truthy(before["required"]), truthy(after["required"])
+	switch {
+	case !oldRequired && newRequired:
+		add(RequiredTightened, "now required")
+	case oldRequired && !newRequired:
+		add(RequiredLoosened, "no longer required")
+	}
+
+	oldMax, oldHasMax := number(before["max_length"])
+	newMax, newHasMax := number(after["max_length"])
+	switch {
+	case newHasMax && !oldHasMax:
+		add(MaxLengthReduced, fmt.Sprintf("max_length set to %d", newMax))
+	case newHasMax && newMax < oldMax:
+		add(MaxLengthReduced, fmt.Sprintf("max_length reduced %d → %d", oldMax, newMax))
+	case oldHasMax && (!newHasMax || newMax > oldMax):
+		add(PropertyChanged, "max_length raised")
+	}
+
+	if oldRegex, newRegex := text(before["regex"]), text(after["regex"]); oldRegex != newRegex {
+		if newRegex == "" {
+			add(PropertyChanged, "regex removed")
+		} else {
+			add(RegexChanged, fmt.Sprintf("regex changed to %q", newRegex))
+		}
+	}
+
+	gone, gained := setDelta(optionValues(before["options"]), optionValues(after["options"]))
+	if len(gone) > 0 {
+		add(OptionsRemoved, fmt.Sprintf("options removed: %s", strings.Join(gone, ", ")))
+	}
+	if len(gained) > 0 {
+		add(OptionsAdded, fmt.Sprintf("options added: %s", strings.Join(gained, ", ")))
+	}
+
+	gone, gained = setDelta(stringList(before["component_whitelist"]), stringList(after["component_whitelist"]))
+	oldRestricted := truthy(before["restrict_components"]) || len(stringList(before["component_whitelist"])) > 0
+	if len(gone) > 0 {
+		add(WhitelistRemoved, fmt.Sprintf("bloks removed from whitelist: %s", strings.Join(gone, ", ")))
+	}
+	if !oldRestricted && truthy(after["restrict_components"]) {
+		add(WhitelistRemoved, "bloks restricted to whitelist")
+	}
+	if len(gained) > 0 {
+		add(WhitelistAdded, fmt.Sprintf("bloks added to whitelist: %s", strings.Join(gained, ", ")))
+	}
+
+	gone, gained = setDelta(stringList(before[groupWhitelist]), stringList(after[groupWhitelist]))
+	if len(gone) > 0 {
+		add(WhitelistRemoved, fmt.Sprintf("groups removed from whitelist: %s", strings.Join(gone, ", ")))
+	}
+	if len(gained) > 0 {
+		add(WhitelistAdded, fmt.Sprintf("groups added to whitelist: %s", strings.Join(gained, ", ")))
+	}
+
+	if text(before["source"]) != text(after["source"]) || text(before["datasource_slug"]) != text(after["datasource_slug"]) {
+		add(DatasourceChanged, fmt.Sprintf("option source changed to %s", describeSource(after)))
+	}
+
+	handled := map[string]struct{}{
+		"type": {}, "required": {}, "max_length": {}, "regex": {}, "options": {},
+		"component_whitelist": {}, "restrict_components": {}, groupWhitelist: {}, "source": {}, "datasource_slug": {},
+	}
+	var properties []string
+	for _, key := range unionKeys(before, after) {
+		if _, ok := handled[key]; ok {
+			continue
+		}
+		if _, ok := ignoredProperties[key]; ok {
+			continue
+		}
+		if !reflect.DeepEqual(before[key], after[key]) {
+			properties = append(properties, key)
+		}
+	}
+	if len(properties) > 0 {
+		add(PropertyChanged, fmt.Sprintf("changed %s", strings.Join(properties, ", ")))
+	}
+
+	return changes
+}
+
+// detectRenames pairs removed and added fields whose definitions are identical apart
+// from their position, which is how a key rename shows up in a schema.
+func detectRenames(removed, added []string, oldFields, newFields map[string]map[string]any) map[string]string {
+	renamed := make(map[string]string)
+	used := make(map[string]struct{})
+	for _, from := range removed {
+		var match string
+		matches := 0
+		for _, to := range added {
+			if _, ok := used[to]; ok {
+				continue
+			}
+			if sameDefinition(oldFields[from], newFields[to]) {
+				match = to
+				matches++
+			}
+		}
+		if matches == 1 {
+			renamed[from] = match
+			used[match] = struct{}{}
+		}
+	}
+	return renamed
+}
+
+func sameDefinition(a, b map[string]any) bool {
+	for _, key := range unionKeys(a, b) {
+		if _, ok := ignoredProperties[key]; ok {
+			continue
+		}
+		if !reflect.DeepEqual(a[key], b[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SortChanges orders changes by component, field and kind for stable output.
+func SortChanges(changes []Change) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Component != changes[j].Component {
+			return changes[i].Component < changes[j].Component
+		}
+		if changes[i].Field != changes[j].Field {
+			return changes[i].Field < changes[j].Field
+		}
+		return changes[i].Kind < changes[j].Kind
+	})
+}
+
+func indexByName(components []storyblok.Component) map[string]storyblok.Component {
+	index := make(map[string]storyblok.Component, len(components))
+	for _, component := range components {
+		index[strings.ToLower(component.Name)] = component
+	}
+	return index
+}
+
+// fieldDefinitions returns the schema entries that are field definitions.
+func fieldDefinitions(schema map[string]any) map[string]map[string]any {
+	fields := make(map[string]map[string]any, len(schema))
+	for key, value := range schema {
+		if def, ok := value.(map[string]any); ok {
+			fields[key] = def
+		}
+	}
+	return fields
+}
+
+func fieldType(def map[string]any) string {
+	if t := text(def["type"]); t != "" {
+		return t
+	}
+	return "unknown"
+}
+
+func describeSource(def map[string]any) string {
+	source := text(def["source"])
+	if source == "" {
+		source = "self"
+	}
+	if slug := text(def["datasource_slug"]); slug != "" {
+		return fmt.Sprintf("%s (%s)", source, slug)
+	}
+	return source
+}
+
+func optionValues(value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		option, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		values = append(values, text(option["value"]))
+	}
+	return values
+}
+
+func stringList(value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if s := text(item); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// setDelta returns the values only in before and only in after, sorted.
+func setDelta(before, after []string) (gone, gained []string) {
+	inBefore := make(map[string]struct{}, len(before))
+	for _, v := range before {
+		inBefore[v] = struct{}{}
+	}
+	inAfter := make(map[string]struct{}, len(after))
+	for _, v := range after {
+		inAfter[v] = struct{}{}
+		if _, ok := inBefore[v]; !ok {
+			gained = append(gained, v)
+		}
+	}
+	for _, v := range before {
+		if _, ok := inAfter[v]; !ok {
+			gone = append(gone, v)
+		}
+	}
+	sort.Strings(gone)
+	sort.Strings(gained)
+	return gone, gained
+}
+
+func text(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func truthy(value any) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+func number(value any) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), v > 0
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil && n > 0
+	}
+	return 0, false
+}
@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"sbx/internal/storyblok"
+)
+
+func TestDiffComponents(t *testing.T) {
+	type result struct {
+		Field    string
+		Kind     ChangeKind
+		Severity Severity
+	}
+	page := func(schema map[string]any) []storyblok.Component {
+		return []storyblok.Component{{Name: "page", Schema: schema}}
+	}
+
+	tests := []struct {
+		name string
+		old  []storyblok.Component
+		new  []storyblok.Component
+		want []result
+	}{
+		{
+			name: "component added",
+			old:  nil,
+			new:  page(map[string]any{}),
+			want: []result{{"", ComponentAdded, SeveritySafe}},
+		},
+		{
+			name: "component removed",
+			old:  page(map[string]any{}),
+			new:  nil,
+			want: []result{{"", ComponentRemoved, SeverityBreaking}},
+		},
+		{
+			name: "optional field added",
+			old:  page(map[string]any{}),
+			new:  page(map[string]any{"title": map[string]any{"type": "text"}}),
+			want: []result{{"title", FieldAdded, SeveritySafe}},
+		},
+		{
+			name: "required field added",
+			old:  page(map[string]any{}),
+			new:  page(map[string]any{"title": map[string]any{"type": "text", "required": true}}),
+			want: []result{{"title", FieldAdded, SeverityRisky}},
+		},
+		{
+			name: "field removed",
+			old:  page(map[string]any{"title": map[string]any{"type": "text"}, "body": map[string]any{"type": "richtext"}}),
+			new:  page(map[string]any{"title": map[string]any{"type": "text"}}),
+			want: []result{{"body", FieldRemoved, SeverityBreaking}},
+		},
+		{
+			name: "field renamed",
+			old:  page(map[string]any{"headline": map[string]any{"type": "text", "pos": float64(0)}}),
+			new:  page(map[string]any{"title": map[string]any{"type": "text", "pos": float64(1)}}),
+			want: []result{{"headline", FieldRenamed, SeverityBreaking}},
+		},
+		{
+			name: "incompatible type change",
+			old:  page(map[string]any{"count": map[string]any{"type": "text"}}),
+			new:  page(map[string]any{"count": map[string]any{"type": "number"}}),
+			want: []result{{"count", TypeChanged, SeverityBreaking}},
+		},
+		{
+			name: "compatible type change",
+			old:  page(map[string]any{"intro": map[string]any{"type": "text"}}),
+			new:  page(map[string]any{"intro": map[string]any{"type": "textarea"}}),
+			want: []result{{"intro", TypeChanged, SeverityRisky}},
+		},
+		{
+			name: "required tightened and loosened",
+			old:  page(map[string]any{"a": map[string]any{"type": "text"}, "b": map[string]any{"type": "text", "required": true}}),
+			new:  page(map[string]any{"a": map[string]any{"type": "text", "required": true}, "b": map[string]any{"type": "text"}}),
+			want: []result{{"a", RequiredTightened, SeverityBreaking}, {"b", RequiredLoosened, SeveritySafe}},
+		},
+		{
+			name: "max length reduced",
+			old:  page(map[string]any{"title": map[string]any{"type": "text", "max_length": float64(120)}}),
+			new:  page(map[string]any{"title": map[string]any{"type": "text", "max_length": float64(80)}}),
+			want: []result{{"title", MaxLengthReduced, SeverityRisky}},
+		},
+		{
+			name: "options removed and added",
+			old: page(map[string]any{"theme": map[string]any{"type": "option", "options": []any{
+				map[string]any{"name": "Light", "value": "light"},
+				map[string]any{"name": "Dark", "value": "dark"},
+			}}}),
+			new: page(map[string]any{"theme": map[string]any{"type": "option", "options": []any{
+				map[string]any{"name": "Light", "value": "light"},
+				map[string]any{"name": "Contrast", "value": "contrast"},
+			}}}),
+			want: []result{{"theme", OptionsAdded, SeveritySafe}, {"theme", OptionsRemoved, SeverityBreaking}},
+		},
+		{
+			name: "whitelist narrowed",
+			old:  page(map[string]any{"body": map[string]any{"type": "bloks", "component_whitelist": []any{"hero", "teaser"}}}),
+			new:  page(map[string]any{"body": map[string]any{"type": "bloks", "component_whitelist": []any{"hero"}}}),
+			want: []result{{"body", WhitelistRemoved, SeverityBreaking}},
+		},
+		{
+			name: "datasource changed",
+			old:  page(map[string]any{"tag": map[string]any{"type": "option", "source": "internal", "datasource_slug": "tags"}}),
+			new:  page(map[string]any{"tag": map[string]any{"type": "option", "source": "internal", "datasource_slug": "labels"}}),
+			want: []result{{"tag", DatasourceChanged, SeverityRisky}},
+		},
+		{
+			name: "position only",
+			old:  page(map[string]any{"title": map[string]any{"type": "text", "pos": float64(0)}}),
+			new:  page(map[string]any{"title": map[string]any{"type": "text", "pos": float64(3)}}),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []result
+			for _, change := range DiffComponents(tt.old, tt.new) {
+				got = append(got, result{change.Field, change.Kind, change.Severity})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		kind ChangeKind
+		want Severity
+	}{
+		{ComponentAdded, SeveritySafe},
+		{ComponentRemoved, SeverityBreaking},
+		{FieldAdded, SeveritySafe},
+		{FieldRemoved, SeverityBreaking},
+		{FieldRenamed, SeverityBreaking},
+		{TypeChanged, SeverityBreaking},
+		{RequiredTightened, SeverityBreaking},
+		{RequiredLoosened, SeveritySafe},
+		{MaxLengthReduced, SeverityRisky},
+		{RegexChanged, SeverityRisky},
+		{OptionsRemoved, SeverityBreaking},
+		{OptionsAdded, SeveritySafe},
+		{WhitelistRemoved, SeverityBreaking},
+		{WhitelistAdded, SeveritySafe},
+		{DatasourceChanged, SeverityRisky},
+		{PropertyChanged, SeveritySafe},
+	}
+	for _, tt := range tests {
+		if got := classify(Change{Kind: tt.kind}).Severity; got != tt.want {
+			t.Errorf("classify(%s) = %s, want %s", tt.kind, got, tt.want)
+		}
+	}
+}
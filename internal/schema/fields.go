@@ -0,0 +1,32 @@
+package schema
+
+// fieldTypes lists the schema field types Storyblok accepts. image and file are legacy
+// types still present in older spaces; plugin is the type of custom field plugins.
+var fieldTypes = map[string]struct{}{
+	"text":       {},
+	"textarea":   {},
+	"richtext":   {},
+	"markdown":   {},
+	"number":     {},
+	"datetime":   {},
+	"boolean":    {},
+	"option":     {},
+	"options":    {},
+	"asset":      {},
+	"multiasset": {},
+	"multilink":  {},
+	"bloks":      {},
+	"table":      {},
+	"section":    {},
+	"tab":        {},
+	"custom":     {},
+	"plugin":     {},
+	"image":      {},
+	"file":       {},
+}
+
+// KnownFieldType reports whether fieldType is a schema field type Storyblok accepts.
+func KnownFieldType(fieldType string) bool {
+	_, ok := fieldTypes[fieldType]
+	return ok
+}
@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"strings"
+
+	"sbx/internal/storyblok"
+)
+
+// groupWhitelist is the field property listing the component groups a bloks field accepts.
+const groupWhitelist = "component_group_whitelist"
+
+// GroupNames maps component group UUIDs to group names. Group whitelists hold UUIDs,
+// which differ between spaces, so schemas of different spaces are compared by name.
+type GroupNames map[string]string
+
+// NewGroupNames indexes groups by UUID, together with the group each component names,
+// which covers component files pulled from another space.
+func NewGroupNames(groups []storyblok.ComponentGroup, components ...storyblok.Component) GroupNames {
+	names := make(GroupNames, len(groups))
+	for _, group := range groups {
+		if group.UUID != "" && group.Name != "" {
+			names[group.UUID] = group.Name
+		}
+	}
+	for _, component := range components {
+		if component.ComponentGroupUUID != "" && component.ComponentGroupName != "" {
+			if _, ok := names[component.ComponentGroupUUID]; !ok {
+				names[component.ComponentGroupUUID] = component.ComponentGroupName
+			}
+		}
+	}
+	return names
+}
+
+// Schema returns a copy of schema whose group whitelists name groups instead of
+// giving their UUIDs. Entries that are no known UUID already name a group and are kept.
+func (n GroupNames) Schema(schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	named := cloneMap(schema)
+	for key, value := range schema {
+		def, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		whitelist, ok := def[groupWhitelist].([]any)
+		if !ok {
+			continue
+		}
+		entries := make([]any, 0, len(whitelist))
+		for _, entry := range whitelist {
+			if uuid, ok := entry.(string); ok {
+				if name, ok := n[strings.TrimSpace(uuid)]; ok {
+					entry = name
+				}
+			}
+			entries = append(entries, entry)
+		}
+		def = cloneMap(def)
+		def[groupWhitelist] = entries
+		named[key] = def
+	}
+	return named
+}
+
+// Component returns component with the group whitelists of its schema given by name.
+func (n GroupNames) Component(component storyblok.Component) storyblok.Component {
+	component.Schema = n.Schema(component.Schema)
+	return component
+}
+
+// Components returns copies of components with their group whitelists given by name.
+func (n GroupNames) Components(components []storyblok.Component) []storyblok.Component {
+	named := make([]storyblok.Component, 0, len(components))
+	for _, component := range components {
+		named = append(named, n.Component(component))
+	}
+	return named
+}
@@ -0,0 +1,37 @@
+package schema
+
+import "sbx/internal/storyblok"
+
+// LintSuppressKey lists the lint rules a component file or one of its field definitions
+// disables. It only has meaning locally and is never pushed to Storyblok.
+const LintSuppressKey = "sbx_lint_disable"
+
+// StripLocal returns component without the properties that only have meaning in local
+// files. The maps of component itself are left untouched.
+func StripLocal(component storyblok.Component) storyblok.Component {
+	if _, ok := component.Extras[LintSuppressKey]; ok {
+		extras := cloneMap(component.Extras)
+		delete(extras, LintSuppressKey)
+		component.Extras = extras
+	}
+	var stripped map[string]any
+	for key, value := range component.Schema {
+		def, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, ok := def[LintSuppressKey]; !ok {
+			continue
+		}
+		if stripped == nil {
+			stripped = cloneMap(component.Schema)
+		}
+		def = cloneMap(def)
+		delete(def, LintSuppressKey)
+		stripped[key] = def
+	}
+	if stripped != nil {
+		component.Schema = stripped
+	}
+	return component
+}
@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Conflict marker keys written in place of a conflicting value.
+const (
+	MarkerOurs   = "<<<<<<< local"
+	MarkerBase   = "||||||| base"
+	MarkerTheirs = ">>>>>>> target"
+)
+
+// Conflict records a field (or field property) edited differently on both sides.
+// A nil value means the side deleted it.
+type Conflict struct {
+	Field    string
+	Property string
+	Base     any
+	Ours     any
+	Theirs   any
+}
+
+// Path renders the conflict location as field or field.property.
+func (c Conflict) Path() string {
+	if c.Property == "" {
+		return c.Field
+	}
+	return c.Field + "." + c.Property
+}
+
+// MergeResult is the outcome of a three-way schema merge.
+type MergeResult struct {
+	Schema    map[string]any
+	Conflicts []Conflict
+}
+
+// Marked returns the merged schema with every conflict replaced by an object holding
+// the local, base and target values under conflict marker keys.
+func (r MergeResult) Marked() map[string]any {
+	marked := cloneMap(r.Schema)
+	for _, conflict := range r.Conflicts {
+		field, property := conflict.Field, conflict.Property
+		markers := map[string]any{
+			MarkerOurs:   conflict.Ours,
+			MarkerBase:   conflict.Base,
+			MarkerTheirs: conflict.Theirs,
+		}
+		if property == "" {
+			marked[field] = markers
+			continue
+		}
+		fieldMap, ok := marked[field].(map[string]any)
+		if !ok {
+			fieldMap = make(map[string]any)
+		} else {
+			fieldMap = cloneMap(fieldMap)
+		}
+		fieldMap[property] = markers
+		marked[field] = fieldMap
+	}
+	return marked
+}
+
+// HasMarkers reports whether schema still holds conflict markers written by Marked.
+func HasMarkers(schema map[string]any) bool {
+	for key, value := range schema {
+		if key == MarkerOurs || key == MarkerTheirs {
+			return true
+		}
+		if nested, ok := value.(map[string]any); ok && HasMarkers(nested) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge performs a three-way merge of schema fields. Changes made on only one side
+// relative to base are applied; when both sides changed the same field, their field
+// properties are merged and only properties edited differently on both sides conflict.
+func Merge(base, ours, theirs map[string]any) MergeResult {
+	result := MergeResult{Schema: make(map[string]any)}
+
+	for _, key := range unionKeys(base, ours, theirs) {
+		b, inBase := base[key]
+		o, inOurs := ours[key]
+		t, inTheirs := theirs[key]
+
+		value, present, conflicts := mergeValue(key, "", b, inBase, o, inOurs, t, inTheirs)
+		if present {
+			result.Schema[key] = value
+		}
+		result.Conflicts = append(result.Conflicts, conflicts...)
+	}
+
+	sort.Slice(result.Conflicts, func(i, j int) bool { return result.Conflicts[i].Path() < result.Conflicts[j].Path() })
+	return result
+}
+
+// mergeValue merges a schema field, or a field property when property is set.
+func mergeValue(field, property string, b any, inBase bool, o any, inOurs bool, t any, inTheirs bool) (any, bool, []Conflict) {
+	oursChanged := changed(b, inBase, o, inOurs)
+	theirsChanged := changed(b, inBase, t, inTheirs)
+
+	switch {
+	case !oursChanged && !theirsChanged:
+		return b, inBase, nil
+	case oursChanged && !theirsChanged:
+		return o, inOurs, nil
+	case !oursChanged && theirsChanged:
+		return t, inTheirs, nil
+	case inOurs == inTheirs && reflect.DeepEqual(o, t):
+		return o, inOurs, nil
+	}
+
+	if property == "" && inOurs && inTheirs {
+		bMap, _ := b.(map[string]any)
+		oMap, oOK := o.(map[string]any)
+		tMap, tOK := t.(map[string]any)
+		if oOK && tOK {
+			merged := make(map[string]any)
+			var conflicts []Conflict
+			for _, key := range unionKeys(bMap, oMap, tMap) {
+				bv, bIn := bMap[key]
+				ov, oIn := oMap[key]
+				tv, tIn := tMap[key]
+				value, present, nested := mergeValue(field, key, bv, bIn, ov, oIn, tv, tIn)
+				if present {
+					merged[key] = value
+				}
+				conflicts = append(conflicts, nested...)
+			}
+			return merged, true, conflicts
+		}
+	}
+
+	conflict := Conflict{Field: field, Property: property, Base: b, Ours: o, Theirs: t}
+	// Keep the local value in the merged schema; Marked replaces it with markers.
+	return o, inOurs, []Conflict{conflict}
+}
+
+func changed(base any, inBase bool, value any, present bool) bool {
+	if inBase != present {
+		return true
+	}
+	return present && !reflect.DeepEqual(base, value)
+}
+
+func unionKeys(maps ...map[string]any) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func cloneMap(src map[string]any) map[string]any {
+	dst := make(map[string]any, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
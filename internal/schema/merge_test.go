@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	text := func(props ...any) map[string]any {
+		def := map[string]any{"type": "text"}
+		for i := 0; i+1 < len(props); i += 2 {
+			def[props[i].(string)] = props[i+1]
+		}
+		return def
+	}
+
+	tests := []struct {
+		name      string
+		base      map[string]any
+		ours      map[string]any
+		theirs    map[string]any
+		want      map[string]any
+		conflicts []string
+	}{
+		{
+			name:   "unchanged",
+			base:   map[string]any{"title": text()},
+			ours:   map[string]any{"title": text()},
+			theirs: map[string]any{"title": text()},
+			want:   map[string]any{"title": text()},
+		},
+		{
+			name:   "independent additions",
+			base:   map[string]any{"title": text()},
+			ours:   map[string]any{"title": text(), "subtitle": text()},
+			theirs: map[string]any{"title": text(), "teaser": text()},
+			want:   map[string]any{"title": text(), "subtitle": text(), "teaser": text()},
+		},
+		{
+			name:   "deletion on one side",
+			base:   map[string]any{"title": text(), "legacy": text()},
+			ours:   map[string]any{"title": text(), "legacy": text()},
+			theirs: map[string]any{"title": text()},
+			want:   map[string]any{"title": text()},
+		},
+		{
+			name:   "edits to different properties of one field",
+			base:   map[string]any{"title": text()},
+			ours:   map[string]any{"title": text("required", true)},
+			theirs: map[string]any{"title": text("max_length", float64(80))},
+			want:   map[string]any{"title": text("required", true, "max_length", float64(80))},
+		},
+		{
+			name:   "same edit on both sides",
+			base:   map[string]any{"title": text()},
+			ours:   map[string]any{"title": text("required", true)},
+			theirs: map[string]any{"title": text("required", true)},
+			want:   map[string]any{"title": text("required", true)},
+		},
+		{
+			name:      "conflicting edits to one property",
+			base:      map[string]any{"title": text("max_length", float64(80))},
+			ours:      map[string]any{"title": text("max_length", float64(60))},
+			theirs:    map[string]any{"title": text("max_length", float64(120))},
+			want:      map[string]any{"title": text("max_length", float64(60))},
+			conflicts: []string{"title.max_length"},
+		},
+		{
+			name:      "edited locally and deleted in the target",
+			base:      map[string]any{"title": text()},
+			ours:      map[string]any{"title": text("required", true)},
+			theirs:    map[string]any{},
+			want:      map[string]any{"title": text("required", true)},
+			conflicts: []string{"title"},
+		},
+		{
+			name:      "added on both sides with different definitions",
+			base:      map[string]any{},
+			ours:      map[string]any{"cta": text()},
+			theirs:    map[string]any{"cta": map[string]any{"type": "multilink"}},
+			want:      map[string]any{"cta": map[string]any{"type": "text"}},
+			conflicts: []string{"cta.type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(tt.base, tt.ours, tt.theirs)
+			if !reflect.DeepEqual(got.Schema, tt.want) {
+				t.Errorf("schema = %v, want %v", got.Schema, tt.want)
+			}
+			var paths []string
+			for _, conflict := range got.Conflicts {
+				paths = append(paths, conflict.Path())
+			}
+			if !reflect.DeepEqual(paths, tt.conflicts) {
+				t.Errorf("conflicts = %v, want %v", paths, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMergeResultMarked(t *testing.T) {
+	result := Merge(
+		map[string]any{"title": map[string]any{"type": "text", "max_length": float64(80)}},
+		map[string]any{"title": map[string]any{"type": "text", "max_length": float64(60)}},
+		map[string]any{"title": map[string]any{"type": "text", "max_length": float64(120)}},
+	)
+	marked := result.Marked()
+	if !HasMarkers(marked) {
+		t.Fatalf("Marked() = %v, want conflict markers", marked)
+	}
+	want := map[string]any{MarkerOurs: float64(60), MarkerBase: float64(80), MarkerTheirs: float64(120)}
+	if got := marked["title"].(map[string]any)["max_length"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("title.max_length = %v, want %v", got, want)
+	}
+	if HasMarkers(result.Schema) {
+		t.Error("Marked() changed the merged schema")
+	}
+}
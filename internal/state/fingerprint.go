@@ -0,0 +1,134 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"sbx/internal/storyblok"
+)
+
+// trackedAttributes are the top-level component properties editors can change in the
+// Storyblok UI besides the schema itself.
+var trackedAttributes = []string{
+	"display_name",
+	"component_group_uuid",
+	"is_root",
+	"is_nestable",
+	"color",
+	"icon",
+	"image",
+	"preview_field",
+	"internal_tag_ids",
+}
+
+// Change kinds reported by Compare.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// FieldChange describes a schema field or component attribute that differs between two fingerprints.
+type FieldChange struct {
+	Field     string `json:"field"`
+	Kind      string `json:"kind"`
+	Attribute bool   `json:"attribute,omitempty"`
+}
+
+// Fingerprint hashes a component and each of its schema fields and tracked attributes.
+func Fingerprint(component storyblok.Component) ComponentState {
+	entry := ComponentState{
+		Name:       component.Name,
+		ID:         component.ID,
+		Fields:     make(map[string]string, len(component.Schema)),
+		Attributes: make(map[string]string),
+	}
+	for key, value := range component.Schema {
+		entry.Fields[key] = HashValue(value)
+	}
+
+	attrs := componentAttributes(component)
+	for _, key := range trackedAttributes {
+		if value, ok := attrs[key]; ok && !isEmpty(value) {
+			entry.Attributes[key] = HashValue(value)
+		}
+	}
+
+	entry.Hash = HashValue(map[string]any{
+		"fields":     entry.Fields,
+		"attributes": entry.Attributes,
+	})
+	entry.Schema = component.Schema
+	return entry
+}
+
+// HashValue returns a stable SHA-256 hex digest of the JSON encoding of value.
+// encoding/json sorts map keys, which keeps the digest independent of key order.
+func HashValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Compare lists the fields and attributes that differ between a recorded state and the current one.
+func Compare(recorded, current ComponentState) []FieldChange {
+	if recorded.Hash == current.Hash {
+		return nil
+	}
+	changes := compareHashes(recorded.Fields, current.Fields, false)
+	changes = append(changes, compareHashes(recorded.Attributes, current.Attributes, true)...)
+	return changes
+}
+
+func compareHashes(recorded, current map[string]string, attribute bool) []FieldChange {
+	var changes []FieldChange
+	for key, hash := range recorded {
+		now, ok := current[key]
+		switch {
+		case !ok:
+			changes = append(changes, FieldChange{Field: key, Kind: ChangeRemoved, Attribute: attribute})
+		case now != hash:
+			changes = append(changes, FieldChange{Field: key, Kind: ChangeModified, Attribute: attribute})
+		}
+	}
+	for key := range current {
+		if _, ok := recorded[key]; !ok {
+			changes = append(changes, FieldChange{Field: key, Kind: ChangeAdded, Attribute: attribute})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func componentAttributes(component storyblok.Component) map[string]any {
+	data, err := json.Marshal(component)
+	if err != nil {
+		return nil
+	}
+	var attrs map[string]any
+	if err := json.Unmarshal(data, &attrs); err != nil {
+		return nil
+	}
+	return attrs
+}
+
+// isEmpty reports whether value stands for an unset attribute. false is a value: turning
+// is_root or is_nestable off modifies the attribute rather than removing it.
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
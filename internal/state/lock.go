@@ -0,0 +1,207 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sbx/internal/fsutil"
+	"sbx/internal/storyblok"
+)
+
+// LockFileName is the file recording the last-synced state per space. It deliberately
+// has no .json suffix so schema discovery never mistakes it for a component file.
+const LockFileName = "sbx.lock"
+
+const lockVersion = 1
+
+// Lockfile records what sbx last read from or wrote to each space.
+type Lockfile struct {
+	Version int                    `json:"version"`
+	Spaces  map[string]*SpaceState `json:"spaces"`
+
+	path string
+	fsys fsutil.FS
+	now  func() time.Time
+}
+
+// SpaceState holds component fingerprints for a single space. Assets maps the sha256 of
+// files sbx uploaded into the space to the resulting asset.
+type SpaceState struct {
+	Components map[string]ComponentState `json:"components"`
+	Assets     map[string]AssetState     `json:"assets,omitempty"`
+}
+
+// AssetState identifies an asset sbx uploaded.
+type AssetState struct {
+	ID  int    `json:"id,omitempty"`
+	URL string `json:"url"`
+}
+
+// ComponentState is the recorded fingerprint of a component after a sync. Schema keeps
+// a snapshot of the synced schema so it can serve as the base of a three-way merge.
+type ComponentState struct {
+	Name       string            `json:"name"`
+	ID         int               `json:"id,omitempty"`
+	Hash       string            `json:"hash"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Schema     map[string]any    `json:"schema,omitempty"`
+	// MergeBase is the target schema that merge conflicts were last written against.
+	// Until the component is synced again it replaces Schema as the base of the next
+	// merge, so conflicts resolved in the local file are not reported again.
+	MergeBase map[string]any `json:"merge_base,omitempty"`
+	SyncedAt  time.Time      `json:"synced_at"`
+}
+
+// Load reads the lockfile from dir. A missing file yields an empty lockfile.
+func Load(dir string) (*Lockfile, error) {
+	return LoadFS(fsutil.OS, dir)
+}
+
+// LoadFS reads the lockfile from dir in fsys, where Save writes it back.
+func LoadFS(fsys fsutil.FS, dir string) (*Lockfile, error) {
+	path := filepath.Join(dir, LockFileName)
+	lock := &Lockfile{
+		Version: lockVersion,
+		Spaces:  make(map[string]*SpaceState),
+		path:    path,
+		fsys:    fsys,
+		now:     time.Now,
+	}
+	exists, err := fsutil.ExistsFS(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return lock, nil
+	}
+	if err := fsutil.ReadJSONFS(fsys, path, lock); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if lock.Version > lockVersion {
+		return nil, fmt.Errorf("%s has unsupported version %d", path, lock.Version)
+	}
+	if lock.Spaces == nil {
+		lock.Spaces = make(map[string]*SpaceState)
+	}
+	lock.path = path
+	return lock, nil
+}
+
+// SetClock replaces the clock that stamps recorded components.
+func (l *Lockfile) SetClock(now func() time.Time) {
+	if now != nil {
+		l.now = now
+	}
+}
+
+// Path returns the file location backing the lockfile.
+func (l *Lockfile) Path() string {
+	return l.path
+}
+
+// Save writes the lockfile back to disk.
+func (l *Lockfile) Save() error {
+	l.Version = lockVersion
+	return fsutil.WriteJSONFS(l.fsys, l.path, l, 0)
+}
+
+// Get returns the recorded state of a component in a space.
+func (l *Lockfile) Get(spaceID int, name string) (ComponentState, bool) {
+	space, ok := l.Spaces[spaceKey(spaceID)]
+	if !ok || space == nil {
+		return ComponentState{}, false
+	}
+	entry, ok := space.Components[componentKey(name)]
+	return entry, ok
+}
+
+// Record stores the fingerprint of component as the last-synced state for spaceID.
+func (l *Lockfile) Record(spaceID int, component storyblok.Component) {
+	key := spaceKey(spaceID)
+	space, ok := l.Spaces[key]
+	if !ok || space == nil {
+		space = &SpaceState{}
+		l.Spaces[key] = space
+	}
+	if space.Components == nil {
+		space.Components = make(map[string]ComponentState)
+	}
+	entry := Fingerprint(component)
+	entry.SyncedAt = l.now().UTC().Truncate(time.Second)
+	space.Components[componentKey(component.Name)] = entry
+}
+
+// SetMergeBase records the schema of target as the base of the next three-way merge of
+// the component in spaceID. The last-synced state stays in place, so the component is
+// still reported as drifted until it is pushed.
+func (l *Lockfile) SetMergeBase(spaceID int, target storyblok.Component) {
+	space, ok := l.Spaces[spaceKey(spaceID)]
+	if !ok || space == nil {
+		return
+	}
+	key := componentKey(target.Name)
+	entry, ok := space.Components[key]
+	if !ok {
+		return
+	}
+	entry.MergeBase = target.Schema
+	space.Components[key] = entry
+}
+
+// Forget drops the recorded state of a component that no longer exists in spaceID.
+func (l *Lockfile) Forget(spaceID int, name string) {
+	if space, ok := l.Spaces[spaceKey(spaceID)]; ok && space != nil {
+		delete(space.Components, componentKey(name))
+	}
+}
+
+// Asset returns the asset previously uploaded to spaceID with the given content hash.
+func (l *Lockfile) Asset(spaceID int, hash string) (AssetState, bool) {
+	space, ok := l.Spaces[spaceKey(spaceID)]
+	if !ok || space == nil {
+		return AssetState{}, false
+	}
+	asset, ok := space.Assets[hash]
+	return asset, ok
+}
+
+// RecordAsset remembers that content with hash was uploaded to spaceID as asset.
+func (l *Lockfile) RecordAsset(spaceID int, hash string, asset AssetState) {
+	key := spaceKey(spaceID)
+	space, ok := l.Spaces[key]
+	if !ok || space == nil {
+		space = &SpaceState{Components: make(map[string]ComponentState)}
+		l.Spaces[key] = space
+	}
+	if space.Assets == nil {
+		space.Assets = make(map[string]AssetState)
+	}
+	space.Assets[hash] = asset
+}
+
+// Names lists the component names recorded for a space in sorted order.
+func (l *Lockfile) Names(spaceID int) []string {
+	space, ok := l.Spaces[spaceKey(spaceID)]
+	if !ok || space == nil {
+		return nil
+	}
+	names := make([]string, 0, len(space.Components))
+	for _, entry := range space.Components {
+		names = append(names, entry.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func spaceKey(spaceID int) string {
+	return strconv.Itoa(spaceID)
+}
+
+func componentKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,319 @@
+package storyblok
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders so uploads report image dimensions
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const assetsPerPage = 100
+
+// Asset is a file stored in a space's asset library. Filename holds its public URL.
+type Asset struct {
+	ID            int    `json:"id,omitempty"`
+	Filename      string `json:"filename"`
+	Alt           string `json:"alt,omitempty"`
+	Title         string `json:"title,omitempty"`
+	Copyright     string `json:"copyright,omitempty"`
+	Focus         string `json:"focus,omitempty"`
+	AssetFolderID int    `json:"asset_folder_id,omitempty"`
+	ContentType   string `json:"content_type,omitempty"`
+	ContentLength int64  `json:"content_length,omitempty"`
+}
+
+// AssetFolder groups assets in the asset library. ParentID is zero at the top level.
+type AssetFolder struct {
+	ID       int    `json:"id,omitempty"`
+	Name     string `json:"name"`
+	ParentID int    `json:"parent_id,omitempty"`
+}
+
+// SignedUpload is the response to an asset upload request: the new asset's ID and URLs,
+// plus the storage endpoint and form fields the file must be posted with.
+type SignedUpload struct {
+	ID        int               `json:"id"`
+	PrettyURL string            `json:"pretty_url"`
+	PublicURL string            `json:"public_url"`
+	PostURL   string            `json:"post_url"`
+	Fields    map[string]string `json:"fields"`
+}
+
+// ListAssets pages through every asset of a space.
+func (c *Client) ListAssets(ctx context.Context, spaceID int) ([]Asset, error) {
+	var assets []Asset
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(assetsPerPage))
+
+		var response struct {
+			Assets []Asset `json:"assets"`
+		}
+		if err := c.do(ctx, requestArgs{
+			method:  http.MethodGet,
+			path:    fmt.Sprintf("/spaces/%d/assets", spaceID),
+			query:   query,
+			spaceID: spaceID,
+			out:     &response,
+		}); err != nil {
+			return nil, err
+		}
+		assets = append(assets, response.Assets...)
+		if len(response.Assets) < assetsPerPage {
+			return assets, nil
+		}
+	}
+}
+
+// UpdateAsset saves an asset's metadata and folder.
+func (c *Client) UpdateAsset(ctx context.Context, spaceID int, asset Asset) error {
+	if asset.ID == 0 {
+		return fmt.Errorf("asset ID is required for update")
+	}
+	payload := map[string]any{"asset": map[string]any{
+		"alt":             asset.Alt,
+		"title":           asset.Title,
+		"copyright":       asset.Copyright,
+		"focus":           asset.Focus,
+		"asset_folder_id": nullableID(asset.AssetFolderID),
+	}}
+	return c.do(ctx, requestArgs{
+		method:  http.MethodPut,
+		path:    fmt.Sprintf("/spaces/%d/assets/%d", spaceID, asset.ID),
+		spaceID: spaceID,
+		payload: payload,
+		isWrite: true,
+	})
+}
+
+// ListAssetFolders returns the asset folders of a space.
+func (c *Client) ListAssetFolders(ctx context.Context, spaceID int) ([]AssetFolder, error) {
+	var response struct {
+		Folders []AssetFolder `json:"asset_folders"`
+	}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodGet,
+		path:    fmt.Sprintf("/spaces/%d/asset_folders", spaceID),
+		spaceID: spaceID,
+		out:     &response,
+	}); err != nil {
+		return nil, err
+	}
+	return response.Folders, nil
+}
+
+// CreateAssetFolder creates an asset folder.
+func (c *Client) CreateAssetFolder(ctx context.Context, spaceID int, folder AssetFolder) (AssetFolder, error) {
+	var response struct {
+		Folder AssetFolder `json:"asset_folder"`
+	}
+	payload := map[string]any{"asset_folder": map[string]any{
+		"name":      folder.Name,
+		"parent_id": nullableID(folder.ParentID),
+	}}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf("/spaces/%d/asset_folders", spaceID),
+		spaceID: spaceID,
+		payload: payload,
+		out:     &response,
+		isWrite: true,
+	}); err != nil {
+		return AssetFolder{}, err
+	}
+	return response.Folder, nil
+}
+
+// nullableID sends zero IDs as null.
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// CreateSignedUpload registers a new asset and returns where to upload its file. size is
+// the image dimensions as "WIDTHxHEIGHT" and may be empty for other files.
+func (c *Client) CreateSignedUpload(ctx context.Context, spaceID int, filename, size string, folderID int) (SignedUpload, error) {
+	payload := map[string]any{
+		"filename":        filename,
+		"validate_upload": 1,
+	}
+	if size != "" {
+		payload["size"] = size
+	}
+	if folderID != 0 {
+		payload["asset_folder_id"] = folderID
+	}
+	var response SignedUpload
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf("/spaces/%d/assets", spaceID),
+		spaceID: spaceID,
+		payload: payload,
+		out:     &response,
+		isWrite: true,
+	}); err != nil {
+		return SignedUpload{}, err
+	}
+	return response, nil
+}
+
+// FinishUpload marks a signed upload as complete so the asset becomes available.
+func (c *Client) FinishUpload(ctx context.Context, spaceID, assetID int) (Asset, error) {
+	var response Asset
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodGet,
+		path:    fmt.Sprintf("/spaces/%d/assets/%d/finish_upload", spaceID, assetID),
+		spaceID: spaceID,
+		out:     &response,
+	}); err != nil {
+		return Asset{}, err
+	}
+	return response, nil
+}
+
+// UploadAsset stores data as a new asset in spaceID using the signed-upload flow. meta
+// names the file in Filename and may carry a folder and metadata for the new asset.
+func (c *Client) UploadAsset(ctx context.Context, spaceID int, meta Asset, data []byte) (Asset, error) {
+	filename := meta.Filename
+	signed, err := c.CreateSignedUpload(ctx, spaceID, filename, imageSize(data), meta.AssetFolderID)
+	if err != nil {
+		return Asset{}, err
+	}
+	if err := c.postSignedUpload(ctx, signed, filename, data); err != nil {
+		return Asset{}, err
+	}
+	asset, err := c.FinishUpload(ctx, spaceID, signed.ID)
+	if err != nil {
+		return Asset{}, err
+	}
+	if meta.Alt != "" || meta.Title != "" || meta.Copyright != "" || meta.Focus != "" {
+		meta.ID = signed.ID
+		if err := c.UpdateAsset(ctx, spaceID, meta); err != nil {
+			return Asset{}, err
+		}
+		asset.Alt, asset.Title, asset.Copyright, asset.Focus = meta.Alt, meta.Title, meta.Copyright, meta.Focus
+	}
+	if asset.ID == 0 {
+		asset.ID = signed.ID
+	}
+	if asset.Filename == "" {
+		asset.Filename = signed.PrettyURL
+	}
+	if asset.Filename == "" {
+		asset.Filename = signed.PublicURL
+	}
+	return asset, nil
+}
+
+// postSignedUpload sends the file to the storage endpoint. The form is pre-signed, so the
+// request carries no management token and bypasses the API rate limiter.
+func (c *Client) postSignedUpload(ctx context.Context, signed SignedUpload, filename string, data []byte) error {
+	if signed.PostURL == "" {
+		return fmt.Errorf("asset upload for %s returned no post_url", filename)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range signed.Fields {
+		if err := form.WriteField(key, value); err != nil {
+			return err
+		}
+	}
+	// The storage endpoint ignores fields after the file, so it must come last.
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, signed.PostURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: responseBody, Message: fmt.Sprintf("upload %s: %s", filename, http.StatusText(resp.StatusCode))}
+	}
+	return nil
+}
+
+// Download fetches a public asset URL. Protocol-relative URLs, as Storyblok stores them,
+// are fetched over https.
+func (c *Client) Download(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, AssetURL(rawURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("download %s: %s", rawURL, http.StatusText(resp.StatusCode))}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// AssetURL makes a protocol-relative asset URL absolute.
+func AssetURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if strings.HasPrefix(rawURL, "//") {
+		return "https:" + rawURL
+	}
+	return rawURL
+}
+
+// AssetFilename returns the file name at the end of an asset URL.
+func AssetFilename(rawURL string) string {
+	rawURL = AssetURL(rawURL)
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	name := path.Base(rawURL)
+	if name == "." || name == "/" || strings.HasSuffix(rawURL, "/") {
+		return ""
+	}
+	return name
+}
+
+// IsSpaceAsset reports whether rawURL points into the asset library of spaceID.
+func IsSpaceAsset(rawURL string, spaceID int) bool {
+	return strings.Contains(rawURL, fmt.Sprintf("/f/%d/", spaceID))
+}
+
+func imageSize(data []byte) string {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", config.Width, config.Height)
+}
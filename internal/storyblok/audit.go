@@ -0,0 +1,201 @@
+package storyblok
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes one write request made through a client.
+type AuditRecord struct {
+	Time    time.Time
+	Method  string
+	Path    string
+	SpaceID int
+	// Entity is the name, slug or filename of the written entity, when known.
+	Entity string
+	// BeforeHash and AfterHash fingerprint the entity as the client last read it and as
+	// the API returned it after the write. Either is empty when unknown, such as before a
+	// create or after a delete.
+	BeforeHash string
+	AfterHash  string
+	// Status is the HTTP status of the last attempt; 0 when no response arrived.
+	Status int
+	Err    error
+}
+
+// Auditor receives a record of every write request. It must be safe for concurrent use.
+type Auditor interface {
+	Audit(record AuditRecord)
+}
+
+// auditTrail remembers the entities read in a context, so that writes to them can be
+// recorded with the state they replaced.
+type auditTrail struct {
+	auditor Auditor
+
+	mu   sync.Mutex
+	seen map[string]auditEntity
+}
+
+type auditEntity struct {
+	name string
+	hash string
+}
+
+type auditKey struct{}
+
+// WithAuditor attaches auditor to a context: client requests made with it report every
+// write to auditor.
+func WithAuditor(ctx context.Context, auditor Auditor) context.Context {
+	if auditor == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, auditKey{}, &auditTrail{auditor: auditor, seen: make(map[string]auditEntity)})
+}
+
+func auditTrailFrom(ctx context.Context) *auditTrail {
+	trail, _ := ctx.Value(auditKey{}).(*auditTrail)
+	return trail
+}
+
+// observe records the outcome of a request: successful reads update what the trail
+// knows about entities, and writes are passed to the auditor.
+func (t *auditTrail) observe(args requestArgs, payload []byte, status int, body []byte, err error) {
+	if !args.isWrite {
+		if err == nil {
+			t.remember(args.path, body)
+		}
+		return
+	}
+
+	record := AuditRecord{
+		Time:    time.Now().UTC(),
+		Method:  args.method,
+		Path:    args.path,
+		SpaceID: args.spaceID,
+		Status:  status,
+		Err:     err,
+	}
+	t.mu.Lock()
+	before, known := t.seen[args.path]
+	t.mu.Unlock()
+	if known && args.method != http.MethodPost {
+		record.BeforeHash = before.hash
+		record.Entity = before.name
+	}
+	if name := entityName(payload); name != "" {
+		record.Entity = name
+	}
+	if err == nil {
+		if args.method == http.MethodDelete {
+			t.mu.Lock()
+			delete(t.seen, args.path)
+			t.mu.Unlock()
+		} else if written := t.remember(args.path, body); written != nil {
+			record.AfterHash = written.hash
+			if written.name != "" {
+				record.Entity = written.name
+			}
+		}
+	}
+	t.auditor.Audit(record)
+}
+
+// remember stores the entities of a response body under their API paths and returns the
+// single entity of the response, if it has one.
+func (t *auditTrail) remember(requestPath string, body []byte) *auditEntity {
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal(body, &envelope) != nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var single *auditEntity
+	for _, raw := range envelope {
+		raw = json.RawMessage(strings.TrimSpace(string(raw)))
+		if len(raw) == 0 {
+			continue
+		}
+		switch raw[0] {
+		case '{':
+			if key, entity, ok := parseEntity(requestPath, raw); ok {
+				t.seen[key] = entity
+				single = &entity
+			}
+		case '[':
+			var items []json.RawMessage
+			if json.Unmarshal(raw, &items) != nil {
+				continue
+			}
+			for _, item := range items {
+				if key, entity, ok := parseEntity(requestPath, item); ok {
+					t.seen[key] = entity
+				}
+			}
+		}
+	}
+	return single
+}
+
+// parseEntity returns the API path of an object with an ID below requestPath, its name
+// and its hash.
+func parseEntity(requestPath string, raw json.RawMessage) (string, auditEntity, bool) {
+	var object map[string]any
+	if json.Unmarshal(raw, &object) != nil {
+		return "", auditEntity{}, false
+	}
+	id, ok := object["id"].(float64)
+	if !ok || id <= 0 {
+		return "", auditEntity{}, false
+	}
+	idText := strconv.FormatInt(int64(id), 10)
+	key := requestPath
+	if !strings.HasSuffix(requestPath, "/"+idText) {
+		key = strings.TrimSuffix(requestPath, "/") + "/" + idText
+	}
+	// Re-encoding sorts the keys, so the hash does not depend on the API's field order.
+	canonical, err := json.Marshal(object)
+	if err != nil {
+		return "", auditEntity{}, false
+	}
+	sum := sha256.Sum256(canonical)
+	return key, auditEntity{name: objectName(object), hash: hex.EncodeToString(sum[:])}, true
+}
+
+// entityName returns the name of the object a write request payload wraps, such as
+// {"component": {"name": ...}}.
+func entityName(payload []byte) string {
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal(payload, &envelope) != nil {
+		return ""
+	}
+	for _, raw := range envelope {
+		var object map[string]any
+		if json.Unmarshal(raw, &object) == nil {
+			if name := objectName(object); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func objectName(object map[string]any) string {
+	for _, key := range []string{"name", "slug", "full_slug"} {
+		if value, ok := object[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	if value, ok := object["filename"].(string); ok && value != "" {
+		return path.Base(value)
+	}
+	return ""
+}
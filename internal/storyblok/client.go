@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"strings"
@@ -105,13 +106,12 @@ func buildAuthHeader(token string) string {
 	return token
 }
 
-func (c *Client) do(ctx context.Context, args requestArgs) error {
+func (c *Client) do(ctx context.Context, args requestArgs) (err error) {
 	if c.token == "" {
 		return fmt.Errorf("storyblok client requires a token")
 	}
 
 	var payload []byte
-	var err error
 	if args.payload != nil {
 		payload, err = json.Marshal(args.payload)
 		if err != nil {
@@ -119,6 +119,14 @@ func (c *Client) do(ctx context.Context, args requestArgs) error {
 		}
 	}
 
+	var status int
+	var responseBody []byte
+	if trail := auditTrailFrom(ctx); trail != nil {
+		defer func() {
+			trail.observe(args, payload, status, responseBody, err)
+		}()
+	}
+
 	backoff := c.backoffStart
 	var lastErr error
 
@@ -173,17 +181,23 @@ func (c *Client) do(ctx context.Context, args requestArgs) error {
 			}
 		}
 
+		status = resp.StatusCode
 		func() {
 			defer resp.Body.Close()
 
 			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				if args.out != nil {
-					decoder := json.NewDecoder(resp.Body)
-					if err := decoder.Decode(args.out); err != nil && err != io.EOF {
+				data, err := io.ReadAll(resp.Body)
+				if err != nil {
+					lastErr = err
+					return
+				}
+				if args.out != nil && len(bytes.TrimSpace(data)) > 0 {
+					if err := json.Unmarshal(data, args.out); err != nil {
 						lastErr = err
 						return
 					}
 				}
+				responseBody = data
 
 				if c.limiter != nil {
 					if args.isWrite {
@@ -338,6 +352,16 @@ func (c *Client) UpdateComponent(ctx context.Context, spaceID, componentID int,
 	return response.Component, nil
 }
 
+// DeleteComponent removes a component by ID.
+func (c *Client) DeleteComponent(ctx context.Context, spaceID, componentID int) error {
+	return c.do(ctx, requestArgs{
+		method:  http.MethodDelete,
+		path:    fmt.Sprintf("/spaces/%d/components/%d", spaceID, componentID),
+		spaceID: spaceID,
+		isWrite: true,
+	})
+}
+
 // ListComponentGroups fetches component groups.
 func (c *Client) ListComponentGroups(ctx context.Context, spaceID int) ([]ComponentGroup, error) {
 	var response struct {
@@ -480,3 +504,152 @@ func (c *Client) GetSpaceOptions(ctx context.Context, spaceID int) (SpaceOptions
 	}
 	return response.Space, nil
 }
+
+// UpdateSpaceLanguages replaces the languages of a space.
+func (c *Client) UpdateSpaceLanguages(ctx context.Context, spaceID int, languages []LangOption) error {
+	list := make([]map[string]any, 0, len(languages))
+	for _, lang := range languages {
+		list = append(list, map[string]any{"code": lang.Code, "name": lang.Name})
+	}
+	return c.do(ctx, requestArgs{
+		method:  http.MethodPut,
+		path:    fmt.Sprintf("/spaces/%d", spaceID),
+		spaceID: spaceID,
+		payload: map[string]any{"space": map[string]any{"languages": list}},
+		isWrite: true,
+	})
+}
+
+const storiesPerPage = 100
+
+// StoryFilter narrows story listings. Zero values are omitted from the query.
+type StoryFilter struct {
+	ContainComponent string
+	StartsWith       string
+	WithTag          string
+	FolderOnly       bool
+}
+
+func (f StoryFilter) query() url.Values {
+	query := url.Values{}
+	if f.ContainComponent != "" {
+		query.Set("contain_component", f.ContainComponent)
+	}
+	if f.StartsWith != "" {
+		query.Set("starts_with", f.StartsWith)
+	}
+	if f.WithTag != "" {
+		query.Set("with_tag", f.WithTag)
+	}
+	if f.FolderOnly {
+		query.Set("folder_only", "1")
+	}
+	return query
+}
+
+// ListStories pages through all stories matching filter. Listings omit story content;
+// use GetStory to load it.
+func (c *Client) ListStories(ctx context.Context, spaceID int, filter StoryFilter) ([]Story, error) {
+	var stories []Story
+	for page := 1; ; page++ {
+		query := filter.query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(storiesPerPage))
+
+		var response struct {
+			Stories []Story `json:"stories"`
+		}
+		if err := c.do(ctx, requestArgs{
+			method:  http.MethodGet,
+			path:    fmt.Sprintf("/spaces/%d/stories", spaceID),
+			query:   query,
+			spaceID: spaceID,
+			out:     &response,
+		}); err != nil {
+			return nil, err
+		}
+		stories = append(stories, response.Stories...)
+		if len(response.Stories) < storiesPerPage {
+			return stories, nil
+		}
+	}
+}
+
+// GetStory fetches a story including its content.
+func (c *Client) GetStory(ctx context.Context, spaceID, storyID int) (Story, error) {
+	var response struct {
+		Story Story `json:"story"`
+	}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodGet,
+		path:    fmt.Sprintf("/spaces/%d/stories/%d", spaceID, storyID),
+		spaceID: spaceID,
+		out:     &response,
+	}); err != nil {
+		return Story{}, err
+	}
+	return response.Story, nil
+}
+
+// CreateStory creates a story or folder. When publish is true the story is published
+// right away.
+func (c *Client) CreateStory(ctx context.Context, spaceID int, story Story, publish bool) (Story, error) {
+	var response struct {
+		Story Story `json:"story"`
+	}
+	story.ID = 0
+	payload := map[string]any{"story": story}
+	if publish {
+		payload["publish"] = 1
+	}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf("/spaces/%d/stories", spaceID),
+		spaceID: spaceID,
+		payload: payload,
+		out:     &response,
+		isWrite: true,
+	}); err != nil {
+		return Story{}, err
+	}
+	return response.Story, nil
+}
+
+// UpdateStory saves a story's content. When publish is true the new version is
+// published; otherwise it is stored as a draft.
+func (c *Client) UpdateStory(ctx context.Context, spaceID int, story Story, publish bool) (Story, error) {
+	return c.updateStory(ctx, spaceID, story.ID, story, publish)
+}
+
+// UpdateStoryContent replaces only the content of a story, leaving its other properties
+// as they are in the space.
+func (c *Client) UpdateStoryContent(ctx context.Context, spaceID, storyID int, content map[string]any, publish bool) (Story, error) {
+	return c.updateStory(ctx, spaceID, storyID, map[string]any{"content": content}, publish)
+}
+
+func (c *Client) updateStory(ctx context.Context, spaceID, storyID int, story any, publish bool) (Story, error) {
+	if storyID == 0 {
+		return Story{}, fmt.Errorf("story ID is required for update")
+	}
+	var response struct {
+		Story Story `json:"story"`
+	}
+	payload := map[string]any{
+		"story":        story,
+		"force_update": "1",
+	}
+	if publish {
+		payload["publish"] = 1
+	}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodPut,
+		path:    fmt.Sprintf("/spaces/%d/stories/%d", spaceID, storyID),
+		spaceID: spaceID,
+		payload: payload,
+		out:     &response,
+		isWrite: true,
+	}); err != nil {
+		return Story{}, err
+	}
+	return response.Story, nil
+}
@@ -0,0 +1,205 @@
+package storyblok
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const datasourcesPerPage = 100
+
+// Datasource is a key/value list that option fields can source their choices from.
+type Datasource struct {
+	ID         int                   `json:"id,omitempty"`
+	Name       string                `json:"name"`
+	Slug       string                `json:"slug"`
+	Dimensions []DatasourceDimension `json:"dimensions,omitempty"`
+}
+
+// DatasourceDimension is an alternative set of values, typically one per language.
+type DatasourceDimension struct {
+	ID           int    `json:"id,omitempty"`
+	Name         string `json:"name"`
+	EntryValue   string `json:"entry_value"`
+	DatasourceID int    `json:"datasource_id,omitempty"`
+}
+
+// DatasourceEntry is a single name/value pair. DimensionValue is only set when entries are
+// listed for a dimension.
+type DatasourceEntry struct {
+	ID             int    `json:"id,omitempty"`
+	Name           string `json:"name"`
+	Value          string `json:"value"`
+	DimensionValue string `json:"dimension_value,omitempty"`
+	DatasourceID   int    `json:"datasource_id,omitempty"`
+}
+
+// datasourcePayload is the write format: dimensions go in dimensions_attributes.
+func datasourcePayload(datasource Datasource) map[string]any {
+	dimensions := make([]map[string]any, 0, len(datasource.Dimensions))
+	for _, dim := range datasource.Dimensions {
+		attrs := map[string]any{"name": dim.Name, "entry_value": dim.EntryValue}
+		if dim.ID != 0 {
+			attrs["id"] = dim.ID
+		}
+		dimensions = append(dimensions, attrs)
+	}
+	return map[string]any{"datasource": map[string]any{
+		"name":                  datasource.Name,
+		"slug":                  datasource.Slug,
+		"dimensions_attributes": dimensions,
+	}}
+}
+
+// ListDatasources pages through all datasources of a space.
+func (c *Client) ListDatasources(ctx context.Context, spaceID int) ([]Datasource, error) {
+	var datasources []Datasource
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(datasourcesPerPage))
+
+		var response struct {
+			Datasources []Datasource `json:"datasources"`
+		}
+		if err := c.do(ctx, requestArgs{
+			method:  http.MethodGet,
+			path:    fmt.Sprintf("/spaces/%d/datasources", spaceID),
+			query:   query,
+			spaceID: spaceID,
+			out:     &response,
+		}); err != nil {
+			return nil, err
+		}
+		datasources = append(datasources, response.Datasources...)
+		if len(response.Datasources) < datasourcesPerPage {
+			return datasources, nil
+		}
+	}
+}
+
+// CreateDatasource creates a datasource together with its dimensions.
+func (c *Client) CreateDatasource(ctx context.Context, spaceID int, datasource Datasource) (Datasource, error) {
+	var response struct {
+		Datasource Datasource `json:"datasource"`
+	}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf("/spaces/%d/datasources", spaceID),
+		spaceID: spaceID,
+		payload: datasourcePayload(datasource),
+		out:     &response,
+		isWrite: true,
+	}); err != nil {
+		return Datasource{}, err
+	}
+	return response.Datasource, nil
+}
+
+// UpdateDatasource saves a datasource's name and dimensions. Dimensions without an ID are
+// added; existing dimensions must carry their ID.
+func (c *Client) UpdateDatasource(ctx context.Context, spaceID int, datasource Datasource) (Datasource, error) {
+	if datasource.ID == 0 {
+		return Datasource{}, fmt.Errorf("datasource ID is required for update")
+	}
+	var response struct {
+		Datasource Datasource `json:"datasource"`
+	}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodPut,
+		path:    fmt.Sprintf("/spaces/%d/datasources/%d", spaceID, datasource.ID),
+		spaceID: spaceID,
+		payload: datasourcePayload(datasource),
+		out:     &response,
+		isWrite: true,
+	}); err != nil {
+		return Datasource{}, err
+	}
+	if response.Datasource.ID == 0 {
+		// Some API versions answer updates with an empty body.
+		return datasource, nil
+	}
+	return response.Datasource, nil
+}
+
+// ListDatasourceEntries pages through the entries of a datasource. With a dimension
+// (its entry_value), each entry also carries its value for that dimension.
+func (c *Client) ListDatasourceEntries(ctx context.Context, spaceID, datasourceID int, dimension string) ([]DatasourceEntry, error) {
+	var entries []DatasourceEntry
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("datasource_id", strconv.Itoa(datasourceID))
+		if dimension != "" {
+			query.Set("dimension", dimension)
+		}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(datasourcesPerPage))
+
+		var response struct {
+			Entries []DatasourceEntry `json:"datasource_entries"`
+		}
+		if err := c.do(ctx, requestArgs{
+			method:  http.MethodGet,
+			path:    fmt.Sprintf("/spaces/%d/datasource_entries", spaceID),
+			query:   query,
+			spaceID: spaceID,
+			out:     &response,
+		}); err != nil {
+			return nil, err
+		}
+		entries = append(entries, response.Entries...)
+		if len(response.Entries) < datasourcesPerPage {
+			return entries, nil
+		}
+	}
+}
+
+// CreateDatasourceEntry adds an entry to entry.DatasourceID.
+func (c *Client) CreateDatasourceEntry(ctx context.Context, spaceID int, entry DatasourceEntry) (DatasourceEntry, error) {
+	var response struct {
+		Entry DatasourceEntry `json:"datasource_entry"`
+	}
+	payload := map[string]any{"datasource_entry": map[string]any{
+		"name":          entry.Name,
+		"value":         entry.Value,
+		"datasource_id": entry.DatasourceID,
+	}}
+	if err := c.do(ctx, requestArgs{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf("/spaces/%d/datasource_entries", spaceID),
+		spaceID: spaceID,
+		payload: payload,
+		out:     &response,
+		isWrite: true,
+	}); err != nil {
+		return DatasourceEntry{}, err
+	}
+	return response.Entry, nil
+}
+
+// UpdateDatasourceEntry saves an entry's name and value. With a non-zero dimensionID it
+// saves entry.DimensionValue for that dimension instead.
+func (c *Client) UpdateDatasourceEntry(ctx context.Context, spaceID int, entry DatasourceEntry, dimensionID int) error {
+	if entry.ID == 0 {
+		return fmt.Errorf("datasource entry ID is required for update")
+	}
+	fields := map[string]any{
+		"name":          entry.Name,
+		"value":         entry.Value,
+		"datasource_id": entry.DatasourceID,
+	}
+	payload := map[string]any{"datasource_entry": fields}
+	if dimensionID != 0 {
+		fields["dimension_value"] = entry.DimensionValue
+		payload["dimension_id"] = dimensionID
+	}
+	return c.do(ctx, requestArgs{
+		method:  http.MethodPut,
+		path:    fmt.Sprintf("/spaces/%d/datasource_entries/%d", spaceID, entry.ID),
+		spaceID: spaceID,
+		payload: payload,
+		isWrite: true,
+	})
+}
@@ -19,7 +19,10 @@ type Component struct {
 	InternalTagsList   []InternalTag     `json:"internal_tags_list,omitempty"`
 	InternalTagIDs     IntSlice          `json:"internal_tag_ids,omitempty"`
 	AllPresets         []ComponentPreset `json:"all_presets,omitempty"`
-	Extras             map[string]any    `json:"-"`
+	// ClearPreset sends preset_id as null, which unsets the default preset; a zero
+	// PresetID is omitted and leaves it as it is.
+	ClearPreset bool           `json:"-"`
+	Extras      map[string]any `json:"-"`
 }
 
 // UnmarshalJSON preserves unknown fields in Extras.
@@ -68,7 +71,7 @@ func (c Component) MarshalJSON() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(c.Extras) == 0 {
+	if len(c.Extras) == 0 && !c.ClearPreset {
 		return data, nil
 	}
 
@@ -80,6 +83,9 @@ func (c Component) MarshalJSON() ([]byte, error) {
 	for k, v := range c.Extras {
 		base[k] = v
 	}
+	if c.ClearPreset {
+		base["preset_id"] = nil
+	}
 
 	return json.Marshal(base)
 }
@@ -291,3 +297,79 @@ func (s SpaceOptions) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(base)
 }
+
+// Story models a Storyblok story or folder. Unknown fields are preserved in Extras.
+type Story struct {
+	ID        int            `json:"id,omitempty"`
+	UUID      string         `json:"uuid,omitempty"`
+	Name      string         `json:"name"`
+	Slug      string         `json:"slug"`
+	FullSlug  string         `json:"full_slug,omitempty"`
+	ParentID  int            `json:"parent_id,omitempty"`
+	IsFolder  bool           `json:"is_folder,omitempty"`
+	Published bool           `json:"published,omitempty"`
+	TagList   []string       `json:"tag_list,omitempty"`
+	Content   map[string]any `json:"content,omitempty"`
+	Extras    map[string]any `json:"-"`
+}
+
+// UnmarshalJSON keeps extra fields for stories.
+func (s *Story) UnmarshalJSON(data []byte) error {
+	type alias Story
+	tmp := alias{}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	exclude := map[string]struct{}{
+		"id":        {},
+		"uuid":      {},
+		"name":      {},
+		"slug":      {},
+		"full_slug": {},
+		"parent_id": {},
+		"is_folder": {},
+		"published": {},
+		"tag_list":  {},
+		"content":   {},
+	}
+
+	extra := make(map[string]any)
+	for k, v := range raw {
+		if _, ok := exclude[k]; !ok {
+			extra[k] = v
+		}
+	}
+
+	*s = Story(tmp)
+	s.Extras = extra
+	return nil
+}
+
+func (s Story) MarshalJSON() ([]byte, error) {
+	type alias Story
+	tmp := alias(s)
+	data, err := json.Marshal(tmp)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Extras) == 0 {
+		return data, nil
+	}
+
+	var base map[string]any
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+
+	for k, v := range s.Extras {
+		base[k] = v
+	}
+
+	return json.Marshal(base)
+}
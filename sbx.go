@@ -0,0 +1,414 @@
+// Package sbx runs the sbx pull, push, sync and diff workflows in-process.
+//
+// Every workflow takes an Env. Its zero value behaves like the CLI: a rate-limited client
+// for the workflow's token, the local disk, the system clock and console output. Set its
+// fields to drive a workflow with your own client, file system, clock or progress
+// observer:
+//
+//	res, err := sbx.Push(ctx, sbx.PushOptions{
+//		Env:     sbx.Env{Observer: sbx.ObserverFunc(func(e sbx.Event) { log.Print(e.Message) })},
+//		Token:   token,
+//		SpaceID: 12345,
+//		Dir:     "components",
+//		All:     true,
+//	})
+//
+// Workflows report failures both as an error and as an Event at LevelError.
+package sbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"sbx/internal/app/datasources"
+	"sbx/internal/app/diff"
+	"sbx/internal/app/engine"
+	"sbx/internal/app/pull"
+	"sbx/internal/app/push"
+	"sbx/internal/config"
+	"sbx/internal/fsutil"
+	"sbx/internal/infra/limiter"
+	"sbx/internal/matcher"
+	"sbx/internal/report"
+	"sbx/internal/schema"
+	"sbx/internal/state"
+	"sbx/internal/storyblok"
+)
+
+// Env carries the client, file system, clock and observer a workflow runs against.
+type Env = engine.Env
+
+// Client talks to the Storyblok Management API.
+type Client = storyblok.Client
+
+// ClientOption configures a Client.
+type ClientOption = storyblok.Option
+
+// NewClient returns a client for token, rate limited the way the CLI limits its own.
+func NewClient(token string, opts ...ClientOption) *Client {
+	lim := limiter.NewSpaceLimiter(7, 7, 7)
+	return storyblok.NewClient(token, append([]ClientOption{storyblok.WithLimiter(lim)}, opts...)...)
+}
+
+// WithBaseURL points the client at another API host, such as a regional endpoint or a
+// test server.
+func WithBaseURL(rawURL string) ClientOption { return storyblok.WithBaseURL(rawURL) }
+
+// WithHTTPClient sends requests through hc.
+func WithHTTPClient(hc *http.Client) ClientOption { return storyblok.WithHTTPClient(hc) }
+
+// Auditor receives a record of every write request a client makes. Attach one to the
+// context passed to a workflow with WithAuditor.
+type Auditor = storyblok.Auditor
+
+// AuditRecord describes one write request.
+type AuditRecord = storyblok.AuditRecord
+
+// WithAuditor returns a context whose client requests report every write to auditor.
+func WithAuditor(ctx context.Context, auditor Auditor) context.Context {
+	return storyblok.WithAuditor(ctx, auditor)
+}
+
+// FS is the storage workflows read and write schema files through. WriteFile must create
+// parent directories.
+type FS = fsutil.FS
+
+// OSFS is the local disk.
+var OSFS FS = fsutil.OS
+
+// MemFS keeps files in memory, to test workflows or hand files from one to another
+// without a directory.
+type MemFS = fsutil.MemFS
+
+// NewMemFS returns an empty in-memory file system.
+func NewMemFS() *MemFS { return fsutil.NewMemFS() }
+
+// ReadArchive loads a .tar, .tar.gz, .tgz or .zip archive into memory.
+func ReadArchive(path string) (*MemFS, error) { return fsutil.ReadArchive(path) }
+
+// WriteArchive writes every file of mem to an archive in the format the extension of
+// path names.
+func WriteArchive(path string, mem *MemFS) error { return fsutil.WriteArchive(path, mem) }
+
+// ReadBundle loads a bundle stream, as the CLI reads with --dir -, into memory.
+func ReadBundle(r io.Reader) (*MemFS, error) { return fsutil.ReadBundle(r) }
+
+// WriteBundle writes every file of mem to w as a bundle stream.
+func WriteBundle(w io.Writer, mem *MemFS) error { return fsutil.WriteBundle(w, mem) }
+
+// Event is one progress message from a workflow.
+type Event = report.Event
+
+// Level grades an Event.
+type Level = report.Level
+
+// Event levels. LevelOutput is what the CLI prints to stdout; the others go to stderr.
+const (
+	LevelOutput  = report.LevelOutput
+	LevelInfo    = report.LevelInfo
+	LevelSuccess = report.LevelSuccess
+	LevelWarn    = report.LevelWarn
+	LevelError   = report.LevelError
+)
+
+// Observer receives the events of a workflow. It must be safe for concurrent use.
+type Observer = report.Observer
+
+// ObserverFunc adapts a function to Observer.
+type ObserverFunc = report.ObserverFunc
+
+// Component selector match modes.
+const (
+	MatchExact  = matcher.ModeExact
+	MatchPrefix = matcher.ModePrefix
+	MatchGlob   = matcher.ModeGlob
+)
+
+type (
+	// PullOptions configures Pull.
+	PullOptions = pull.Options
+	// PullResult summarises Pull.
+	PullResult = pull.Result
+	// PushOptions configures Push.
+	PushOptions = push.Options
+	// PushResult summarises Push.
+	PushResult = push.Result
+	// DatasourcePullResult summarises the datasources Sync copies.
+	DatasourcePullResult = datasources.PullResult
+	// Plan is the set of writes a push would make, as MakePlan computes it.
+	Plan = push.Plan
+	// PlanOperation is one write of a Plan.
+	PlanOperation = push.Operation
+	// PlanOptions configures MakePlan.
+	PlanOptions = push.PlanOptions
+	// ApplyOptions configures Apply.
+	ApplyOptions = push.ApplyOptions
+	// ApproveOptions configures ApprovePlan.
+	ApproveOptions = push.ApproveOptions
+	// Approver may sign plans for protected spaces.
+	Approver = config.Approver
+	// DiffOptions configures Diff.
+	DiffOptions = diff.Options
+	// DiffResult lists the changes Diff or DiffRevisions found.
+	DiffResult = diff.Result
+	// DiffRevisionsOptions configures DiffRevisions.
+	DiffRevisionsOptions = diff.RevisionsOptions
+	// Revision is one version of the schema directory, such as a git commit.
+	Revision = diff.Revision
+	// Change is one classified schema difference.
+	Change = schema.Change
+)
+
+// Pull writes components and presets of a space to OutDir.
+func Pull(ctx context.Context, opts PullOptions) (PullResult, error) {
+	return pull.Run(ctx, opts)
+}
+
+// Push creates or updates the components and presets in Dir in a space. Spaces the
+// project configuration protects are treated as Protected, as in the CLI.
+func Push(ctx context.Context, opts PushOptions) (PushResult, error) {
+	var err error
+	if opts.Protected, err = protected(opts.SpaceID, opts.Protected); err != nil {
+		return PushResult{ExitCode: 1}, err
+	}
+	return push.Run(ctx, opts)
+}
+
+// MakePlan computes the writes a push would make, including deletions with Prune, and
+// fingerprints the target space without writing to it.
+func MakePlan(ctx context.Context, opts PlanOptions) (Plan, PushResult, error) {
+	var err error
+	if opts.Protected, err = protected(opts.SpaceID, opts.Protected); err != nil {
+		return Plan{}, PushResult{ExitCode: 1}, err
+	}
+	return push.MakePlan(ctx, opts)
+}
+
+// Apply makes exactly the writes of a plan. It returns an error wrapping ErrStalePlan
+// without writing when the target space changed since the plan was made. Spaces the
+// project configuration protects are treated as Protected, as in the CLI.
+func Apply(ctx context.Context, opts ApplyOptions) (PushResult, error) {
+	var err error
+	if opts.Protected, err = protected(opts.Plan.SpaceID, opts.Protected); err != nil {
+		return PushResult{ExitCode: 1}, err
+	}
+	return push.Apply(ctx, opts)
+}
+
+// protected reports whether writes to spaceID are protected: when the caller already set
+// them to be or when the project configuration the CLI reads protects the space.
+func protected(spaceID int, set bool) (bool, error) {
+	if set {
+		return true, nil
+	}
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		return false, err
+	}
+	return cfg.Protected.IsProtected(spaceID), nil
+}
+
+// ApprovePlan signs a plan file as approved by the approver whose key it is given, for
+// someone else to apply to protected spaces.
+func ApprovePlan(ctx context.Context, opts ApproveOptions) (Plan, error) {
+	return push.ApprovePlan(ctx, opts)
+}
+
+// ErrStalePlan reports a plan whose target space changed after the plan was made.
+var ErrStalePlan = push.ErrStalePlan
+
+// Diff classifies the changes a push of Dir would make to a space.
+func Diff(ctx context.Context, opts DiffOptions) (DiffResult, error) {
+	return diff.Run(ctx, opts)
+}
+
+// DiffRevisions classifies the changes between two versions of Dir, such as the files of
+// two git commits, including added and removed components.
+func DiffRevisions(ctx context.Context, opts DiffRevisionsOptions) (DiffResult, error) {
+	return diff.Revisions(ctx, opts)
+}
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	Env
+	// Token must have access to both spaces.
+	Token         string
+	SourceSpaceID int
+	TargetSpaceID int
+	Names         []string
+	MatchMode     string
+	All           bool
+	// Dir holds the pulled schema between the two steps. When Env.FS is not set, Sync
+	// keeps the files in memory and Dir only prefixes their paths.
+	Dir string
+	// StateFS keeps Dir's sbx.lock between runs when Env.FS is not set. Sync reads the
+	// lock from it before pulling and writes it back after pushing, so drift is detected
+	// and uploaded images are reused. Without either, every Sync starts without a lock.
+	StateFS FS
+	// DryRun pulls the source as usual and only plans the push.
+	DryRun bool
+	// Force, Merge, FailOn, Strict, PresetPolicy, Protected and AllowDestructive work
+	// as for Push.
+	Force            bool
+	Merge            bool
+	FailOn           string
+	Strict           bool
+	PresetPolicy     string
+	Protected        bool
+	AllowDestructive bool
+	// WithImages copies preset screenshots into the target's asset library.
+	WithImages bool
+	// WithDatasources also copies the datasources the synced components reference,
+	// with their entries, before the components.
+	WithDatasources bool
+}
+
+// SyncResult summarises the steps of Sync.
+type SyncResult struct {
+	ExitCode int
+	Pull     PullResult
+	// Datasources is set when WithDatasources pulled referenced datasources.
+	Datasources DatasourcePullResult
+	Push        PushResult
+	Duration    time.Duration
+}
+
+// Sync copies components and presets, and with WithDatasources the datasources they
+// reference, from one space to another by pulling them into Dir and pushing them from
+// there, without touching the disk unless Env.FS or StateFS is set.
+func Sync(ctx context.Context, opts SyncOptions) (result SyncResult, retErr error) {
+	result = SyncResult{ExitCode: 0}
+	start := opts.Clock()()
+
+	if opts.SourceSpaceID == opts.TargetSpaceID {
+		result.ExitCode = 1
+		return result, fmt.Errorf("source and target space are both %d", opts.SourceSpaceID)
+	}
+	if opts.MatchMode == "" {
+		opts.MatchMode = MatchExact
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.FS == nil {
+		opts.FS = NewMemFS()
+		if opts.StateFS != nil {
+			lockPath := filepath.Join(opts.Dir, state.LockFileName)
+			if err := copyFile(opts.StateFS, opts.FS, lockPath); err != nil {
+				result.ExitCode = 1
+				return result, fmt.Errorf("load %s: %w", lockPath, err)
+			}
+			defer func() {
+				if err := copyFile(opts.FS, opts.StateFS, lockPath); err != nil && retErr == nil {
+					result.ExitCode = failureCode(result.ExitCode)
+					retErr = fmt.Errorf("save %s: %w", lockPath, err)
+				}
+			}()
+		}
+	}
+	if opts.Client == nil {
+		// One client for both steps, so they share its rate limiter.
+		opts.Client = NewClient(opts.Token)
+	}
+
+	pulled, err := Pull(ctx, PullOptions{
+		Env:        opts.Env,
+		Token:      opts.Token,
+		SpaceID:    opts.SourceSpaceID,
+		Names:      opts.Names,
+		MatchMode:  opts.MatchMode,
+		All:        opts.All,
+		OutDir:     opts.Dir,
+		WithImages: opts.WithImages,
+	})
+	result.Pull = pulled
+	if err != nil {
+		result.ExitCode = failureCode(pulled.ExitCode)
+		result.Duration = opts.Since(start)
+		return result, fmt.Errorf("pull from space %d: %w", opts.SourceSpaceID, err)
+	}
+
+	if opts.WithDatasources {
+		names := opts.Names
+		if opts.All {
+			names = nil
+		}
+		slugs, err := push.ReferencedDatasources(ctx, opts.FS, opts.Dir, names, opts.MatchMode)
+		if err != nil {
+			result.ExitCode = 1
+			result.Duration = opts.Since(start)
+			return result, err
+		}
+		if len(slugs) > 0 {
+			copied, err := datasources.Pull(ctx, datasources.PullOptions{
+				Env:       opts.Env,
+				Token:     opts.Token,
+				SpaceID:   opts.SourceSpaceID,
+				Names:     slugs,
+				MatchMode: MatchExact,
+				OutDir:    opts.Dir,
+			})
+			result.Datasources = copied
+			if err != nil {
+				result.ExitCode = failureCode(copied.ExitCode)
+				result.Duration = opts.Since(start)
+				return result, fmt.Errorf("pull datasources from space %d: %w", opts.SourceSpaceID, err)
+			}
+		}
+	}
+
+	pushed, err := Push(ctx, PushOptions{
+		Env:              opts.Env,
+		Token:            opts.Token,
+		SpaceID:          opts.TargetSpaceID,
+		Names:            opts.Names,
+		MatchMode:        opts.MatchMode,
+		All:              opts.All,
+		Dir:              opts.Dir,
+		DryRun:           opts.DryRun,
+		Force:            opts.Force,
+		Merge:            opts.Merge,
+		FailOn:           opts.FailOn,
+		Strict:           opts.Strict,
+		PresetPolicy:     opts.PresetPolicy,
+		UploadImages:     opts.WithImages,
+		WithDatasources:  opts.WithDatasources,
+		Protected:        opts.Protected,
+		AllowDestructive: opts.AllowDestructive,
+	})
+	result.Push = pushed
+	result.ExitCode = max(pulled.ExitCode, result.Datasources.ExitCode, pushed.ExitCode)
+	result.Duration = opts.Since(start)
+	if err != nil {
+		result.ExitCode = failureCode(pushed.ExitCode)
+		return result, fmt.Errorf("push to space %d: %w", opts.TargetSpaceID, err)
+	}
+	return result, nil
+}
+
+// copyFile copies path from one file system to another. A missing file is not copied.
+func copyFile(from, to FS, path string) error {
+	data, err := from.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return to.WriteFile(path, data, 0o644)
+}
+
+// failureCode returns the exit code of a failed step, which is an execution error when the
+// step did not set one, as in the CLI.
+func failureCode(code int) int {
+	if code == 0 {
+		return 3
+	}
+	return code
+}